@@ -5,15 +5,20 @@ package agent
 
 import (
 	"context"
+	"crypto/ed25519"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/hashicorp/hcdiag/analyze"
 	"github.com/hashicorp/hcdiag/hcl"
 	"github.com/hashicorp/hcdiag/redact"
+	"github.com/hashicorp/hcdiag/runner"
 
 	"github.com/hashicorp/hcdiag/op"
 
@@ -29,23 +34,84 @@ type Config struct {
 	HCL         hcl.HCL   `json:"hcl"`
 	OS          string    `json:"operating_system"`
 	Dryrun      bool      `json:"dry_run"`
-	Consul      bool      `json:"consul_enabled"`
-	Nomad       bool      `json:"nomad_enabled"`
-	TFE         bool      `json:"terraform_ent_enabled"`
-	Vault       bool      `json:"vault_enabled"`
 	Since       time.Time `json:"since"`
 	Until       time.Time `json:"until"`
 	Destination string    `json:"destination"`
 	TmpDir      string    `json:"tmp_dir"`
+	// RawDir is a private directory, outside TmpDir, that large JSON outputs are held in until they're redacted into
+	// the bundle. Empty means the system's temporary directory. It is omitted from JSON, like PseudonymMap.
+	RawDir string `json:"-"`
+	// Products are the names of the products to gather diagnostics for, in addition to the host, which is always
+	// included. Each must be registered in Registry, which includes the products defined in HCL.
+	Products []product.Name `json:"products"`
+	// Autodetect is true if Products were detected, rather than named by the user. Products defined in HCL are enabled
+	// when the agent is created if so; otherwise, they're only enabled if they're named in Products.
+	Autodetect bool `json:"autodetect"`
+	// Registry holds the products that may be enabled. If nil, product.DefaultRegistry() is used. Products defined in
+	// HCL are registered in a copy of it, so it may be shared between agents.
+	Registry *product.Registry `json:"-"`
+	// Timeout bounds the whole run. Once it is reached, any runners that have not finished are recorded as timed out,
+	// and the output is written with whatever has been gathered. Zero means no limit.
+	Timeout time.Duration `json:"timeout"`
+	// MaxConcurrency limits how many runners may execute at once across all products. Zero means no limit.
+	MaxConcurrency int `json:"max_concurrency"`
+	// HTMLReport writes report.html into the bundle, alongside results.json.
+	HTMLReport bool `json:"html_report"`
 
 	// DebugDuration
 	DebugDuration time.Duration `json:"debug_duration"`
 	// DebugInterval
 	DebugInterval time.Duration `json:"debug_interval"`
+	// PseudonymMap is the path to write the value behind each pseudonym to, if set. It must be outside the bundle, so it
+	// is omitted from JSON.
+	PseudonymMap string `json:"-"`
+	// SigningKey is the path to a PEM-encoded ed25519 private key to sign the manifest with, if set.
+	SigningKey string `json:"-"`
 	// We omit this from JSON to avoid duplicates in manifest.json; it is copied and serialized in Agent instead.
 	Environment Environment `json:"-"`
 }
 
+// Enabled returns true if the product called name is in c.Products.
+func (c Config) Enabled(name product.Name) bool {
+	for _, n := range c.Products {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyProducts are the fields that enabled each product in manifests written before Config.Products.
+var legacyProducts = map[string]product.Name{
+	"boundary_enabled":      product.Boundary,
+	"consul_enabled":        product.Consul,
+	"nomad_enabled":         product.Nomad,
+	"terraform_ent_enabled": product.TFE,
+	"vault_enabled":         product.Vault,
+}
+
+// UnmarshalJSON decodes a Config, including those in manifests from earlier versions of hcdiag, which enabled each
+// built-in product with a field of its own, such as "consul_enabled", rather than listing them in "products".
+func (c *Config) UnmarshalJSON(b []byte) error {
+	// config has Config's fields, but not its methods, so that decoding into it doesn't recurse.
+	type config Config
+	if err := json.Unmarshal(b, (*config)(c)); err != nil {
+		return err
+	}
+	var legacy map[string]json.RawMessage
+	if err := json.Unmarshal(b, &legacy); err != nil {
+		return err
+	}
+	for field, name := range legacyProducts {
+		var enabled bool
+		if raw, ok := legacy[field]; ok && json.Unmarshal(raw, &enabled) == nil && enabled && !c.Enabled(name) {
+			c.Products = append(c.Products, name)
+		}
+	}
+	sort.Slice(c.Products, func(i, j int) bool { return c.Products[i] < c.Products[j] })
+	return nil
+}
+
 // Environment describes runtime details about the execution environment of the Agent.
 type Environment struct {
 	// Command is the CLI command entered to execute a Run.
@@ -64,6 +130,9 @@ type Agent struct {
 	results     map[product.Name]map[string]op.Op
 	resultsLock sync.Mutex
 	tmpDir      string
+	signingKey  ed25519.PrivateKey
+	// pseudonymizer gives pseudonyms to the matches of every redaction in the run that has one.
+	pseudonymizer *redact.Pseudonymizer
 
 	Start    time.Time       `json:"started_at"`
 	End      time.Time       `json:"ended_at"`
@@ -75,8 +144,23 @@ type Agent struct {
 	ManifestOps map[string][]ManifestOp `json:"ops"`
 	// Agent-level redactions are passed through to all products
 	Redactions []*redact.Redact `json:"redactions"`
+	// RedactionPatternsVersion is the version of the built-in redactions, as of when the agent was built.
+	RedactionPatternsVersion string `json:"redaction_patterns_version"`
+	// RedactionSummary reports how many replacements each redaction made, in total and in each op.
+	RedactionSummary RedactionSummary `json:"redaction_summary"`
 	// Environment describes details about the process that constructed the agent
 	Environment Environment `json:"environment"`
+	// Findings holds the outcome of analyzing the results of the run, which is written to findings.json.
+	Findings *analyze.Report `json:"-"`
+	// Interrupted is true if the run was canceled before all runners finished, e.g. because hcdiag received a signal.
+	// Results for any runners that were still in flight are recorded as canceled.
+	Interrupted bool `json:"interrupted"`
+	// TimedOut is true if Config.Timeout was reached before all runners finished. Results for any runners that were
+	// still in flight are recorded as timed out.
+	TimedOut bool `json:"timed_out"`
+	// Checksums is the SHA-256 of every file in the bundle other than the manifest and its signature, by path relative
+	// to the root of the bundle. It is set by WriteChecksums.
+	Checksums map[string]string `json:"checksums,omitempty"`
 }
 
 // NewAgent produces a new Agent, initialized for subsequent running.
@@ -86,25 +170,95 @@ func NewAgent(config Config, logger hclog.Logger) (*Agent, error) {
 
 // NewAgentWithContext produces a new Agent that includes a custom context.Context, initialized for subsequent running.
 func NewAgentWithContext(ctx context.Context, config Config, logger hclog.Logger) (*Agent, error) {
-	redacts, err := agentRedactions()
+	var disabled []string
+	if config.HCL.Agent != nil {
+		disabled = config.HCL.Agent.DisableRedactions
+	}
+	redacts, err := agentRedactions(disabled)
 	if err != nil {
 		return nil, err
 	}
 
+	// Every redaction in the run shares one Pseudonymizer, so that a value has the same pseudonym throughout the bundle.
+	pseudonymizer := redact.NewPseudonymizer()
+
 	// Is there an HCL Agent config that contains redactions?
 	if config.HCL.Agent != nil && len(config.HCL.Agent.Redactions) > 0 {
-		hclRedacts, err := hcl.MapRedacts(config.HCL.Agent.Redactions)
+		hclRedacts, err := hcl.MapRedacts(config.HCL.Agent.Redactions, pseudonymizer)
 		if err != nil {
 			return nil, err
 		}
 		redacts = redact.Flatten(hclRedacts, redacts)
 	}
 
+	// Catch invalid rules before the run starts, rather than after diagnostics have been gathered.
+	for _, r := range config.HCL.Rules {
+		if err := r.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Products defined in HCL are registered, and enabled along with autodetected products, so that they are checked and
+	// built alongside the others. When the user names the products to run, they're only enabled if they're named.
+	registry := product.DefaultRegistry()
+	if config.Registry != nil {
+		registry = config.Registry.Clone()
+	}
+	if err := product.RegisterUserDefined(registry, config.HCL.Products); err != nil {
+		return nil, err
+	}
+	config.Registry = registry
+	for _, p := range config.HCL.Products {
+		name := product.Name(p.Name)
+		if config.Autodetect && p.IsDefinition() && !product.IsBuiltIn(name) && !config.Enabled(name) {
+			config.Products = append(config.Products, name)
+		}
+	}
+	for _, name := range config.Products {
+		if _, ok := registry.Get(name); !ok {
+			return nil, fmt.Errorf("unknown product, product=%s", name)
+		}
+	}
+
+	// A concurrency limit from the CLI takes precedence over one from the HCL Agent config.
+	if config.MaxConcurrency == 0 && config.HCL.Agent != nil {
+		config.MaxConcurrency = config.HCL.Agent.MaxConcurrency
+	}
+	if config.MaxConcurrency < 0 {
+		return nil, fmt.Errorf("Agent.Config.MaxConcurrency must be zero, for no limit, or positive, max_concurrency=%d", config.MaxConcurrency)
+	}
+
 	// Ensure temporary directory exists
 	if _, err = os.Stat(config.TmpDir); err != nil {
 		return nil, fmt.Errorf("Agent.Config.TmpDir doesn't exist: %w", err)
 	}
 
+	// Unredacted outputs must never end up in the bundle either.
+	if config.RawDir != "" {
+		if inside, err := util.IsInside(config.RawDir, config.TmpDir); err != nil || inside {
+			return nil, fmt.Errorf("Agent.Config.RawDir must be outside the bundle, path=%s", config.RawDir)
+		}
+	}
+
+	// The mapping of pseudonyms to values must never end up in the bundle.
+	if config.PseudonymMap != "" {
+		if inside, err := util.IsInside(config.PseudonymMap, config.TmpDir); err != nil || inside {
+			return nil, fmt.Errorf("Agent.Config.PseudonymMap must be outside the bundle, path=%s", config.PseudonymMap)
+		}
+		pseudonymizer.KeepMapping()
+	}
+
+	// A signing key from the CLI takes precedence over one from the HCL Agent config.
+	if config.SigningKey == "" && config.HCL.Agent != nil {
+		config.SigningKey = config.HCL.Agent.SigningKey
+	}
+	var signingKey ed25519.PrivateKey
+	if config.SigningKey != "" {
+		if signingKey, err = util.ParseSigningKey(config.SigningKey); err != nil {
+			return nil, err
+		}
+	}
+
 	return &Agent{
 		l:           logger,
 		ctx:         ctx,
@@ -116,6 +270,11 @@ func NewAgentWithContext(ctx context.Context, config Config, logger hclog.Logger
 		Redactions:  redacts,
 		Environment: config.Environment,
 		tmpDir:      config.TmpDir,
+		signingKey:  signingKey,
+
+		pseudonymizer: pseudonymizer,
+
+		RedactionPatternsVersion: redact.PatternsVersion,
 	}, nil
 }
 
@@ -127,11 +286,21 @@ func (a *Agent) Run() []error {
 
 	a.Start = time.Now()
 
+	// Bound the whole run, if requested. Products and their runners inherit the deadline from a.ctx.
+	if 0 < a.Config.Timeout {
+		var cancel context.CancelFunc
+		a.ctx, cancel = context.WithTimeout(a.ctx, a.Config.Timeout)
+		defer cancel()
+	}
+
 	// If dryrun is enabled we short circuit the main lifecycle and run the dryrun mode instead.
 	if a.Config.Dryrun {
 		return a.DryRun()
 	}
 
+	// Runners write outputs too large to hold in memory into the bundle, and results.json refers to them by path.
+	a.ctx = runner.WithOutputConfig(a.ctx, runner.OutputConfig{Dir: a.tmpDir, RawDir: a.Config.RawDir})
+
 	a.l.Info("Ensuring destination directory exists", "directory", a.Config.Destination)
 	errDest := util.EnsureDirectory(a.Config.Destination)
 	if errDest != nil {
@@ -179,11 +348,35 @@ func (a *Agent) Run() []error {
 		a.l.Error("Failed running Products", "error", errProduct)
 	}
 
+	// Runners that outlived the run, because it timed out or was canceled, may still be writing their outputs into the
+	// bundle. Closing those outputs stops them, so that nothing changes once the bundle's checksums are recorded.
+	if errOutputs := runner.CloseOutputs(a.ctx); errOutputs != nil {
+		errs = append(errs, errOutputs)
+		a.l.Error("Failed closing outputs", "error", errOutputs)
+	}
+
+	// If our context was canceled or hit the run deadline while gathering diagnostics, we still write out whatever we
+	// have so far, but we mark the run accordingly so that nobody mistakes the bundle for a complete one.
+	switch {
+	case errors.Is(a.ctx.Err(), context.Canceled):
+		a.Interrupted = true
+		a.l.Warn("Run was interrupted; the bundle will only include results gathered before the interruption")
+	case errors.Is(a.ctx.Err(), context.DeadlineExceeded):
+		a.TimedOut = true
+		a.l.Warn("Run timed out; the bundle will only include results gathered before the deadline", "timeout", a.Config.Timeout)
+	}
+
 	// Record metadata
 	// Build op metadata
 	a.l.Info("Recording manifest")
 	a.RecordManifest()
 
+	// Look for known problems in the results. Analysis is best-effort, so a failure here doesn't fail the run.
+	a.l.Info("Analyzing results")
+	if errAnalyze := a.Analyze(); errAnalyze != nil {
+		a.l.Error("Failed analyzing results", "error", errAnalyze)
+	}
+
 	// Execution finished, write our results and cleanup
 	a.recordEnd()
 
@@ -192,6 +385,23 @@ func (a *Agent) Run() []error {
 		a.l.Error("Failed running output", "error", errWrite)
 	}
 
+	// The report only renders what's already in the bundle, so a failure here doesn't fail the run either.
+	if a.Config.HTMLReport {
+		if errReport := a.WriteReport(); errReport != nil {
+			a.l.Error("Failed writing "+ReportFile, "error", errReport)
+		} else {
+			a.l.Info("Created "+ReportFile+" file", "dest", filepath.Join(a.tmpDir, ReportFile))
+		}
+	}
+
+	if a.Config.PseudonymMap != "" {
+		a.l.Info("Writing pseudonym mapping outside the bundle", "path", a.Config.PseudonymMap)
+		if errMap := a.pseudonymizer.WriteMapping(a.Config.PseudonymMap); errMap != nil {
+			errs = append(errs, errMap)
+			a.l.Error("Failed writing pseudonym mapping", "error", errMap)
+		}
+	}
+
 	return errs
 }
 
@@ -292,6 +502,46 @@ func (a *Agent) RecordManifest() {
 		result[string(productName)] = manifestOps
 	}
 	a.ManifestOps = result
+
+	// Every configured redaction is included in the summary, so that it's clear which ones never matched.
+	known := a.Redactions
+	results := make(map[string]map[string]op.Op, len(a.results))
+	for productName, ops := range a.results {
+		results[string(productName)] = ops
+		if p, ok := a.products[productName]; ok {
+			known = append(known[:len(known):len(known)], p.Config.Redactions...)
+		}
+	}
+	a.RedactionSummary = SummarizeRedactions(results, known)
+}
+
+// Analyze evaluates the built-in rules, and any rules from the HCL config, against the results of the run, and records
+// the outcome in Agent.Findings.
+func (a *Agent) Analyze() error {
+	rules, err := analyze.Builtin()
+	if err != nil {
+		return err
+	}
+	rules = append(rules, a.Config.HCL.Rules...)
+
+	results, err := analyze.DecodeResults(a.results)
+	if err != nil {
+		return err
+	}
+	report := analyze.Analyze(rules, results)
+	a.Findings = &report
+
+	for _, f := range report.Findings {
+		if f.Severity == analyze.Info {
+			a.l.Info("Finding", "product", f.Product, "rule", f.Rule, "severity", f.Severity, "message", f.Message)
+			continue
+		}
+		a.l.Warn("Finding", "product", f.Product, "rule", f.Rule, "severity", f.Severity, "message", f.Message)
+	}
+	for _, s := range report.Skipped {
+		a.l.Debug("Skipped rule", "product", s.Product, "rule", s.Rule, "reason", s.Reason)
+	}
+	return nil
 }
 
 // WriteOutput renders the manifest and results of the diagnostics run into Agent.tmpDir
@@ -308,7 +558,7 @@ func (a *Agent) WriteOutput() (err error) {
 	a.l.Info("Created results.json file", "dest", rFile)
 
 	// Write out manifest
-	mFile := filepath.Join(a.tmpDir, "manifest.json")
+	mFile := filepath.Join(a.tmpDir, ManifestFile)
 	err = util.WriteJSON(a, mFile)
 	if err != nil {
 		a.l.Error("util.WriteJSON", "error", err)
@@ -316,30 +566,32 @@ func (a *Agent) WriteOutput() (err error) {
 	}
 	a.l.Info("Created manifest.json file", "dest", mFile)
 
+	// Write out findings, if the results were analyzed
+	if a.Findings != nil {
+		fFile := filepath.Join(a.tmpDir, analyze.FindingsFile)
+		err = util.WriteJSON(a.Findings, fFile)
+		if err != nil {
+			a.l.Error("util.WriteJSON", "error", err)
+			return err
+		}
+		a.l.Info("Created "+analyze.FindingsFile+" file", "dest", fFile)
+	}
+
 	return nil
 }
 
 // CheckAvailable runs healthchecks for each enabled product
 func (a *Agent) CheckAvailable() error {
-	if a.Config.Consul {
-		err := product.CommandHealthCheckWithContext(a.ctx, product.ConsulClientCheck, product.ConsulAgentCheck)
-		if err != nil {
-			return err
+	for _, name := range a.Config.Products {
+		reg, ok := a.Config.Registry.Get(name)
+		if !ok {
+			return fmt.Errorf("unknown product, product=%s", name)
 		}
-	}
-	if a.Config.Nomad {
-		err := product.CommandHealthCheckWithContext(a.ctx, product.NomadClientCheck, product.NomadAgentCheck)
-		if err != nil {
-			return err
+		if reg.CheckAvailable == nil {
+			continue
 		}
-	}
-	// NOTE(mkcp): We don't have a TFE healthcheck because we don't support API checks yet.
-	// if cfg.TFE {
-	// }
-	if a.Config.Vault {
-		err := product.CommandHealthCheckWithContext(a.ctx, product.VaultClientCheck, product.VaultAgentCheck)
-		if err != nil {
-			return err
+		if err := reg.CheckAvailable(a.ctx); err != nil {
+			return fmt.Errorf("product %s is not available: %w", name, err)
 		}
 	}
 	return nil
@@ -364,47 +616,20 @@ func (a *Agent) Setup() error {
 		DebugDuration: a.Config.DebugDuration,
 		DebugInterval: a.Config.DebugInterval,
 		Redactions:    a.Redactions,
+		Pseudonymizer: a.pseudonymizer,
+		// Every product shares one scheduler, so that the concurrency limit applies to the run as a whole.
+		Scheduler: runner.NewScheduler(a.ctx, a.Config.MaxConcurrency),
 	}
 
-	// Build Consul and assign it to the product map.
-	if a.Config.Consul {
+	// Build each enabled product from the registry and assign it to the product map.
+	for _, name := range a.Config.Products {
 		cfg := baseCfg
-		cfg.HCL = hclProducts["consul"]
-		newConsul, err := product.NewConsulWithContext(a.ctx, a.l, cfg)
+		cfg.HCL = hclProducts[string(name)]
+		newProduct, err := a.Config.Registry.NewWithContext(a.ctx, a.l, name, cfg)
 		if err != nil {
 			return err
 		}
-		a.products[product.Consul] = newConsul
-	}
-	// Build Nomad and assign it to the product map.
-	if a.Config.Nomad {
-		cfg := baseCfg
-		cfg.HCL = hclProducts["nomad"]
-		newNomad, err := product.NewNomadWithContext(a.ctx, a.l, cfg)
-		if err != nil {
-			return err
-		}
-		a.products[product.Nomad] = newNomad
-	}
-	// Build TFE and assign it to the product map.
-	if a.Config.TFE {
-		cfg := baseCfg
-		cfg.HCL = hclProducts["terraform-ent"]
-		newTFE, err := product.NewTFEWithContext(a.ctx, a.l, cfg)
-		if err != nil {
-			return err
-		}
-		a.products[product.TFE] = newTFE
-	}
-	// Build Vault and assign it to the product map.
-	if a.Config.Vault {
-		cfg := baseCfg
-		cfg.HCL = hclProducts["vault"]
-		newVault, err := product.NewVaultWithContext(a.ctx, a.l, cfg)
-		if err != nil {
-			return err
-		}
-		a.products[product.Vault] = newVault
+		a.products[name] = newProduct
 	}
 
 	// Build host and assign it to the product map.
@@ -417,18 +642,8 @@ func (a *Agent) Setup() error {
 	return nil
 }
 
-// agentRedactions returns the default agent-level redactions that we ship with hcdiag
-func agentRedactions() ([]*redact.Redact, error) {
-	configs := []redact.Config{
-		// Email redactions applied to all products/runners
-		{
-			Matcher: redact.EmailPattern,
-			Replace: redact.EmailReplace,
-		},
-	}
-	redactions, err := redact.MapNew(configs)
-	if err != nil {
-		return nil, err
-	}
-	return redactions, nil
+// agentRedactions returns the default agent-level redactions that we ship with hcdiag, less any that are disabled.
+// They are applied to all products/runners.
+func agentRedactions(disabled []string) ([]*redact.Redact, error) {
+	return redact.Defaults(disabled)
 }
@@ -5,12 +5,15 @@ package agent
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/hcdiag/analyze"
 	"github.com/hashicorp/hcdiag/hcl"
+	"github.com/hashicorp/hcdiag/redact"
 	"github.com/hashicorp/hcdiag/util"
 
 	"github.com/hashicorp/hcdiag/op"
@@ -74,6 +77,90 @@ func TestStartAndEnd(t *testing.T) {
 	assert.NotEqual(t, "", a.Duration, "Duration value still an empty string after recordEnd()")
 }
 
+func TestRunInterrupted(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	// Canceling before Run means every runner finishes as canceled, as if we had received a signal mid-run.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a, err := NewAgentWithContext(ctx, Config{OS: "auto", TmpDir: tmp, Destination: tmp}, emptyLogger)
+	require.NoError(t, err)
+
+	errs := a.Run()
+	assert.Empty(t, errs)
+	assert.True(t, a.Interrupted, "agent should record that the run was interrupted")
+
+	statuses, err := op.StatusCounts(a.results[product.Host])
+	require.NoError(t, err)
+	assert.NotZero(t, statuses[op.Canceled], "expected in-flight runners to be canceled")
+
+	// A partial bundle is still written
+	_, err = os.Stat(filepath.Join(tmp, "manifest.json"))
+	assert.NoError(t, err)
+}
+
+func TestNewAgentMaxConcurrency(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	testTable := []struct {
+		desc      string
+		config    Config
+		expected  int
+		expectErr bool
+	}{
+		{
+			desc:     "no limit by default",
+			config:   Config{TmpDir: tmp},
+			expected: 0,
+		},
+		{
+			desc:     "HCL agent block sets the limit",
+			config:   Config{TmpDir: tmp, HCL: hcl.HCL{Agent: &hcl.Agent{MaxConcurrency: 3}}},
+			expected: 3,
+		},
+		{
+			desc:     "CLI takes precedence over HCL",
+			config:   Config{TmpDir: tmp, MaxConcurrency: 2, HCL: hcl.HCL{Agent: &hcl.Agent{MaxConcurrency: 3}}},
+			expected: 2,
+		},
+		{
+			desc:      "negative limit is rejected",
+			config:    Config{TmpDir: tmp, HCL: hcl.HCL{Agent: &hcl.Agent{MaxConcurrency: -1}}},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testTable {
+		a, err := NewAgent(tc.config, emptyLogger)
+		if tc.expectErr {
+			assert.Error(t, err, tc.desc)
+			continue
+		}
+		require.NoError(t, err, tc.desc)
+		assert.Equal(t, tc.expected, a.Config.MaxConcurrency, tc.desc)
+	}
+}
+
+func TestRunTimedOut(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	a, err := NewAgent(Config{OS: "auto", TmpDir: tmp, Destination: tmp, Timeout: time.Nanosecond}, emptyLogger)
+	require.NoError(t, err)
+
+	errs := a.Run()
+	assert.Empty(t, errs)
+	assert.True(t, a.TimedOut, "agent should record that the run deadline was reached")
+	assert.False(t, a.Interrupted)
+
+	statuses, err := op.StatusCounts(a.results[product.Host])
+	require.NoError(t, err)
+	assert.NotZero(t, statuses[op.Timeout], "expected unfinished runners to time out")
+}
+
 func TestRunProducts(t *testing.T) {
 	l := hclog.Default()
 	pCfg := product.Config{OS: "auto"}
@@ -130,6 +217,106 @@ func TestWriteOutput(t *testing.T) {
 	}
 }
 
+func TestAnalyze(t *testing.T) {
+	rules, err := analyze.Parse("rules.hcl", []byte(`
+rule "uptime-low" {
+  product  = "host"
+  severity = "info"
+  when     = result("uptime", "seconds") < 60
+  message  = "The host booted ${result("uptime", "seconds")} seconds ago."
+}
+`))
+	require.NoError(t, err)
+
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+	a, err := NewAgent(Config{TmpDir: tmp, HCL: hcl.HCL{Rules: rules}}, emptyLogger)
+	require.NoError(t, err)
+
+	a.results[product.Host] = map[string]op.Op{
+		"uptime": op.New("uptime", map[string]any{"seconds": 30}, op.Success, nil, nil, time.Now(), time.Now()),
+	}
+	require.NoError(t, a.Analyze())
+	require.NotNil(t, a.Findings)
+	assert.Contains(t, a.Findings.Findings, analyze.Finding{
+		Rule:     "uptime-low",
+		Product:  "host",
+		Severity: analyze.Info,
+		Message:  "The host booted 30 seconds ago.",
+	})
+
+	require.NoError(t, a.WriteOutput())
+	_, err = os.Stat(filepath.Join(tmp, analyze.FindingsFile))
+	assert.NoError(t, err)
+}
+
+func TestNewAgentInvalidRule(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	rule := analyze.Rule{Name: "bad", Product: "host", Severity: "urgent"}
+	_, err := NewAgent(Config{TmpDir: tmp, HCL: hcl.HCL{Rules: []analyze.Rule{rule}}}, emptyLogger)
+	assert.Error(t, err)
+}
+
+func TestConfigUnmarshalLegacyProducts(t *testing.T) {
+	var cfg Config
+	require.NoError(t, json.Unmarshal([]byte(`{"consul_enabled": true, "nomad_enabled": false, "vault_enabled": true, "products": ["acme"]}`), &cfg))
+	assert.Equal(t, []product.Name{"acme", product.Consul, product.Vault}, cfg.Products)
+	assert.True(t, cfg.Enabled(product.Consul))
+	assert.False(t, cfg.Enabled(product.Nomad))
+}
+
+func TestNewAgentRegistry(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	registry := product.DefaultRegistry()
+	require.NoError(t, registry.Register(product.Registration{
+		Name: "acme",
+		New: func(ctx context.Context, logger hclog.Logger, cfg product.Config) (*product.Product, error) {
+			return &product.Product{Name: "acme"}, nil
+		},
+	}))
+	products := []*hcl.Product{{Name: "acme-gateway", HealthCheck: "true"}}
+
+	a, err := NewAgent(Config{TmpDir: tmp, Products: []product.Name{"acme"}, Autodetect: true, Registry: registry, HCL: hcl.HCL{Products: products}}, emptyLogger)
+	require.NoError(t, err)
+	assert.Equal(t, []product.Name{"acme", "acme-gateway"}, a.Config.Products, "products defined in HCL are enabled with autodetected products")
+	_, ok := registry.Get("acme-gateway")
+	assert.False(t, ok, "products defined in HCL are registered in a copy of the registry")
+
+	require.NoError(t, a.Setup())
+	assert.Contains(t, a.products, product.Name("acme"))
+	assert.Contains(t, a.products, product.Name("acme-gateway"))
+
+	a, err = NewAgent(Config{TmpDir: tmp, Products: []product.Name{"acme"}, Registry: registry, HCL: hcl.HCL{Products: products}}, emptyLogger)
+	require.NoError(t, err)
+	assert.Equal(t, []product.Name{"acme"}, a.Config.Products, "products defined in HCL aren't enabled when products are named")
+
+	a, err = NewAgent(Config{TmpDir: tmp, Products: []product.Name{"acme-gateway"}, Registry: registry, HCL: hcl.HCL{Products: products}}, emptyLogger)
+	require.NoError(t, err)
+	assert.Equal(t, []product.Name{"acme-gateway"}, a.Config.Products, "products defined in HCL may be named")
+
+	_, err = NewAgent(Config{TmpDir: tmp, Products: []product.Name{"widget"}}, emptyLogger)
+	assert.ErrorContains(t, err, "widget")
+}
+
+func TestCheckAvailableUserDefined(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	newAgent := func(healthcheck string) *Agent {
+		products := []*hcl.Product{{Name: "acme-gateway", HealthCheck: healthcheck}}
+		a, err := NewAgent(Config{TmpDir: tmp, Autodetect: true, HCL: hcl.HCL{Products: products}}, emptyLogger)
+		require.NoError(t, err)
+		return a
+	}
+
+	assert.NoError(t, newAgent("true").CheckAvailable())
+	assert.ErrorContains(t, newAgent("false").CheckAvailable(), "acme-gateway")
+}
+
 func TestSetup(t *testing.T) {
 	tmp, cleanup, _ := util.CreateTemp(".")
 	defer cleanup(emptyLogger)
@@ -150,9 +337,22 @@ func TestSetup(t *testing.T) {
 		{
 			name: "Should have host and nomad enabled",
 			cfg: Config{
-				Nomad:  true,
-				OS:     "auto",
-				TmpDir: tmp,
+				Products: []product.Name{product.Nomad},
+				OS:       "auto",
+				TmpDir:   tmp,
+			},
+			expectedLen: 2,
+		},
+		{
+			name: "Should have host and products defined in HCL",
+			cfg: Config{
+				OS:         "auto",
+				TmpDir:     tmp,
+				Autodetect: true,
+				HCL: hcl.HCL{Products: []*hcl.Product{
+					{Name: "acme-gateway", HealthCheck: "acme-gateway version"},
+					{Name: "consol"},
+				}},
 			},
 			expectedLen: 2,
 		},
@@ -169,3 +369,61 @@ func TestSetup(t *testing.T) {
 		})
 	}
 }
+
+func TestNewAgentDisableRedactions(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	a, err := NewAgent(Config{TmpDir: tmp, HCL: hcl.HCL{Agent: &hcl.Agent{DisableRedactions: []string{"email-address"}}}}, emptyLogger)
+	require.NoError(t, err)
+	assert.Len(t, a.Redactions, len(redact.Patterns())-1)
+	for _, r := range a.Redactions {
+		assert.NotEqual(t, "email-address", r.ID)
+	}
+	assert.Equal(t, redact.PatternsVersion, a.RedactionPatternsVersion)
+
+	_, err = NewAgent(Config{TmpDir: tmp, HCL: hcl.HCL{Agent: &hcl.Agent{DisableRedactions: []string{"not-a-pattern"}}}}, emptyLogger)
+	assert.ErrorContains(t, err, "not-a-pattern")
+}
+
+func TestNewAgentPseudonymMap(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	_, err := NewAgent(Config{TmpDir: tmp, PseudonymMap: filepath.Join(tmp, "pseudonyms.json")}, emptyLogger)
+	assert.Error(t, err, "the mapping must not be written into the bundle")
+
+	_, err = NewAgent(Config{TmpDir: tmp, PseudonymMap: filepath.Join(t.TempDir(), "pseudonyms.json")}, emptyLogger)
+	assert.NoError(t, err)
+}
+
+func TestNewAgentPseudonymizer(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	cfg := Config{TmpDir: tmp, HCL: hcl.HCL{Agent: &hcl.Agent{Redactions: []hcl.Redact{
+		{Label: "regex", ID: "ip", Match: `\d+\.\d+\.\d+\.\d+`, Pseudonym: "IP"},
+	}}}}
+	a1, err := NewAgent(cfg, emptyLogger)
+	require.NoError(t, err)
+	a2, err := NewAgent(cfg, emptyLogger)
+	require.NoError(t, err)
+
+	s1, err := redact.String("10.0.0.1", a1.Redactions)
+	require.NoError(t, err)
+	s2, err := redact.String("10.0.0.1", a2.Redactions)
+	require.NoError(t, err)
+	assert.Equal(t, a1.pseudonymizer.Pseudonym("IP", "10.0.0.1"), s1)
+	assert.NotEqual(t, s1, s2, "each agent has its own Pseudonymizer")
+}
+
+func TestNewAgentRawDir(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	_, err := NewAgent(Config{TmpDir: tmp, RawDir: filepath.Join(tmp, "raw")}, emptyLogger)
+	assert.Error(t, err, "unredacted outputs must not be written into the bundle")
+
+	_, err = NewAgent(Config{TmpDir: tmp, RawDir: t.TempDir()}, emptyLogger)
+	assert.NoError(t, err)
+}
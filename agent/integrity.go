@@ -0,0 +1,89 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package agent
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/hashicorp/hcdiag/util"
+)
+
+const (
+	// ManifestFile is the name of the manifest at the root of every bundle.
+	ManifestFile = "manifest.json"
+	// SignatureFile is the name of the detached signature of the manifest, at the root of a signed bundle. It holds
+	// the base64-encoded ed25519 signature of manifest.json.
+	SignatureFile = "manifest.json.sig"
+)
+
+// Checksums returns the hex-encoded SHA-256 of every file under dir, by path relative to dir, with forward slashes.
+// The manifest and its signature at the root of dir are left out, since the manifest holds the checksums.
+func Checksums(dir string) (map[string]string, error) {
+	checksums := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if name == ManifestFile || name == SignatureFile {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return err
+		}
+		checksums[name] = hex.EncodeToString(h.Sum(nil))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return checksums, nil
+}
+
+// WriteChecksums records the checksum of every file in Agent.tmpDir in the manifest, and rewrites it, then signs the
+// manifest if a signing key was configured. It must be the last thing to write to Agent.tmpDir before the bundle is
+// archived, since any later change to a file, including a log line written to hcdiag.log, won't match its checksum.
+func (a *Agent) WriteChecksums() error {
+	checksums, err := Checksums(a.tmpDir)
+	if err != nil {
+		return err
+	}
+	a.Checksums = checksums
+
+	mFile := filepath.Join(a.tmpDir, ManifestFile)
+	if err := util.WriteJSON(a, mFile); err != nil {
+		return err
+	}
+	if a.signingKey == nil {
+		return nil
+	}
+
+	manifest, err := os.ReadFile(mFile)
+	if err != nil {
+		return err
+	}
+	sig := ed25519.Sign(a.signingKey, manifest)
+	return os.WriteFile(filepath.Join(a.tmpDir, SignatureFile), []byte(base64.StdEncoding.EncodeToString(sig)+"\n"), 0644)
+}
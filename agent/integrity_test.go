@@ -0,0 +1,96 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package agent
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/hcl"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+const (
+	testSigningKey = "../tests/resources/keys/signing.pem"
+	testPublicKey  = "../tests/resources/keys/signing.pub.pem"
+)
+
+func TestChecksums(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "results.json"), []byte("{}"), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "host"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "host", "hosts"), []byte("127.0.0.1 localhost"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFile), []byte("{}"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, SignatureFile), []byte("sig"), 0600))
+
+	checksums, err := Checksums(dir)
+	require.NoError(t, err)
+
+	sum := sha256.Sum256([]byte("127.0.0.1 localhost"))
+	assert.Equal(t, hex.EncodeToString(sum[:]), checksums["host/hosts"])
+	assert.Len(t, checksums, 2, "the manifest and its signature are left out")
+}
+
+func TestWriteChecksums(t *testing.T) {
+	t.Run("unsigned", func(t *testing.T) {
+		a, cleanup := newTestAgent(t)
+		defer cleanup(emptyLogger)
+		require.NoError(t, a.WriteOutput())
+		require.NoError(t, a.WriteChecksums())
+
+		var m struct {
+			Checksums map[string]string `json:"checksums"`
+		}
+		bts, err := os.ReadFile(filepath.Join(a.tmpDir, ManifestFile))
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(bts, &m))
+		assert.Contains(t, m.Checksums, "results.json")
+		assert.NotContains(t, m.Checksums, ManifestFile)
+		assert.NoFileExists(t, filepath.Join(a.tmpDir, SignatureFile))
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		tmp, cleanup, _ := util.CreateTemp(".")
+		defer cleanup(emptyLogger)
+		// The key is taken from the HCL agent block when it isn't set on the CLI.
+		a, err := NewAgent(Config{TmpDir: tmp, HCL: hcl.HCL{Agent: &hcl.Agent{SigningKey: testSigningKey}}}, emptyLogger)
+		require.NoError(t, err)
+		require.NoError(t, a.WriteOutput())
+		require.NoError(t, a.WriteChecksums())
+
+		manifest, err := os.ReadFile(filepath.Join(tmp, ManifestFile))
+		require.NoError(t, err)
+		sig, err := os.ReadFile(filepath.Join(tmp, SignatureFile))
+		require.NoError(t, err)
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		require.NoError(t, err)
+
+		pub, err := util.ParseVerifyingKey(testPublicKey)
+		require.NoError(t, err)
+		assert.True(t, ed25519.Verify(pub, manifest, decoded))
+	})
+}
+
+func TestNewAgentSigningKey(t *testing.T) {
+	tmp, cleanup, _ := util.CreateTemp(".")
+	defer cleanup(emptyLogger)
+
+	_, err := NewAgent(Config{TmpDir: tmp, SigningKey: testPublicKey}, emptyLogger)
+	assert.Error(t, err, "a public key can't sign anything")
+
+	// The CLI takes precedence over HCL.
+	a, err := NewAgent(Config{TmpDir: tmp, SigningKey: testSigningKey, HCL: hcl.HCL{Agent: &hcl.Agent{SigningKey: "missing.pem"}}}, emptyLogger)
+	require.NoError(t, err)
+	assert.NotNil(t, a.signingKey)
+}
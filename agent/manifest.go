@@ -7,6 +7,7 @@ import (
 	"fmt"
 
 	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/redact"
 )
 
 // ManifestOp provides a subset of op state, specifically excluding results, so we can safely render metadata
@@ -16,6 +17,8 @@ type ManifestOp struct {
 	Error    string    `json:"error"`
 	Status   op.Status `json:"status"`
 	Duration string    `json:"duration"`
+	// QueueWait is how long the op waited for a free slot before it started, in nanoseconds.
+	QueueWait string `json:"queue_wait"`
 }
 
 // WalkResultsForManifest translates maps of arbitrarily deeply nested op.Ops and flattens them into a slice of ManifestOp.
@@ -35,10 +38,11 @@ func walk(res map[string]any, acc *[]ManifestOp) []ManifestOp {
 		switch o := v.(type) {
 		case op.Op:
 			manifestOp := ManifestOp{
-				ID:       o.Identifier,
-				Error:    o.ErrString,
-				Status:   o.Status,
-				Duration: fmt.Sprintf("%d", o.End.Sub(o.Start).Nanoseconds()),
+				ID:        o.Identifier,
+				Error:     o.ErrString,
+				Status:    o.Status,
+				Duration:  fmt.Sprintf("%d", o.End.Sub(o.Start).Nanoseconds()),
+				QueueWait: fmt.Sprintf("%d", o.QueueWait.Nanoseconds()),
 			}
 			*acc = append(*acc, manifestOp)
 			walk(o.Result, acc)
@@ -48,3 +52,54 @@ func walk(res map[string]any, acc *[]ManifestOp) []ManifestOp {
 	}
 	return *acc
 }
+
+// RedactionSummary reports how many replacements each redaction made during a run, so that it's clear which redactions
+// fired and where. The text that was replaced is never included.
+type RedactionSummary struct {
+	// Total is the number of replacements made by each redaction across the run, by redaction ID. Redactions that were
+	// configured but never matched anything are included with a count of zero.
+	Total map[string]int `json:"total"`
+	// Ops is the number of replacements made in each op, by product, then op, then redaction ID. Only ops that
+	// redacted something are included.
+	Ops map[string]map[string]map[string]int `json:"ops"`
+}
+
+// SummarizeRedactions totals the replacements recorded on each product's ops, including nested ops, by redaction ID and
+// by op. Every redaction in known is included in the totals, even if it made no replacements.
+func SummarizeRedactions(results map[string]map[string]op.Op, known []*redact.Redact) RedactionSummary {
+	summary := RedactionSummary{
+		Total: make(map[string]int),
+		Ops:   make(map[string]map[string]map[string]int),
+	}
+	for _, r := range known {
+		summary.Total[r.ID] = 0
+	}
+	for productName, ops := range results {
+		m := make(map[string]any, len(ops))
+		for k, v := range ops {
+			m[k] = v
+		}
+		walkRedactions(m, func(o op.Op) {
+			if len(o.Redactions) == 0 {
+				return
+			}
+			if summary.Ops[productName] == nil {
+				summary.Ops[productName] = make(map[string]map[string]int)
+			}
+			summary.Ops[productName][o.Identifier] = redact.MergeCounts(summary.Ops[productName][o.Identifier], o.Redactions)
+			for id, n := range o.Redactions {
+				summary.Total[id] += n
+			}
+		})
+	}
+	return summary
+}
+
+func walkRedactions(res map[string]any, fn func(op.Op)) {
+	for _, v := range res {
+		if o, ok := v.(op.Op); ok {
+			fn(o)
+			walkRedactions(o.Result, fn)
+		}
+	}
+}
@@ -7,8 +7,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/redact"
 )
 
 func TestWalkResultsForManifest(t *testing.T) {
@@ -99,3 +101,32 @@ func TestWalkResultsForManifest(t *testing.T) {
 		})
 	}
 }
+
+func TestSummarizeRedactions(t *testing.T) {
+	email, err := redact.New(redact.Config{ID: "email"})
+	require.NoError(t, err)
+	unused, err := redact.New(redact.Config{ID: "unused"})
+	require.NoError(t, err)
+
+	results := map[string]map[string]op.Op{
+		"host": {
+			"do host": {
+				Identifier: "do host",
+				Result: map[string]any{
+					"lsof":   op.Op{Identifier: "lsof", Redactions: map[string]int{"email": 2}},
+					"memory": op.Op{Identifier: "memory"},
+				},
+			},
+		},
+		"vault": {
+			"vault status": {Identifier: "vault status", Redactions: map[string]int{"email": 1, "runner-level": 3}},
+		},
+	}
+
+	summary := SummarizeRedactions(results, []*redact.Redact{email, unused})
+	assert.Equal(t, map[string]int{"email": 3, "unused": 0, "runner-level": 3}, summary.Total)
+	assert.Equal(t, map[string]map[string]map[string]int{
+		"host":  {"lsof": {"email": 2}},
+		"vault": {"vault status": {"email": 1, "runner-level": 3}},
+	}, summary.Ops)
+}
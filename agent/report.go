@@ -0,0 +1,241 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package agent
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/hcdiag/analyze"
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/product"
+)
+
+// ReportFile is the name of the HTML report at the root of a bundle, when one is requested with Config.HTMLReport.
+const ReportFile = "report.html"
+
+// maxReportResultSize is the most of each op's result that is included in the report, so that runners with very large
+// results, such as lsof, don't make the report too big to open. results.json always has the whole result.
+const maxReportResultSize = 256 * 1024
+
+//go:embed report.html.tmpl
+var reportTemplateText string
+
+var reportTemplate = template.Must(template.New(ReportFile).Parse(reportTemplateText))
+
+// reportStatuses are the op statuses, in the order that they're counted in the report.
+var reportStatuses = []op.Status{op.Success, op.Fail, op.Skip, op.Canceled, op.Timeout, op.Unknown}
+
+// hostInfoFields are the fields of the host info runner's result that are shown in the report's summary of the host,
+// in order, with their labels.
+var hostInfoFields = []struct{ key, label string }{
+	{"hostname", "Hostname"},
+	{"os", "OS"},
+	{"platform", "Platform"},
+	{"platformVersion", "Platform version"},
+	{"kernelVersion", "Kernel"},
+	{"kernelArch", "Architecture"},
+	{"virtualizationSystem", "Virtualization"},
+	{"uptime", "Uptime"},
+	{"procs", "Processes"},
+}
+
+type reportData struct {
+	Version     string
+	Command     string
+	Username    string
+	Hostname    string
+	Start       string
+	End         string
+	Duration    time.Duration
+	Interrupted bool
+	TimedOut    bool
+	Host        []reportField
+	Findings    []analyze.Finding
+	Statuses    []op.Status
+	Products    []reportProduct
+}
+
+type reportField struct {
+	Label string
+	Value string
+}
+
+type reportProduct struct {
+	Name   string
+	Counts []reportCount
+	Total  int
+	Ops    []reportOp
+}
+
+type reportCount struct {
+	Status op.Status
+	Count  int
+}
+
+type reportOp struct {
+	ID        string
+	Status    op.Status
+	Error     string
+	Duration  time.Duration
+	QueueWait time.Duration
+	// Result is the op's result, as indented JSON. It has already been redacted by the runner.
+	Result    string
+	Truncated bool
+}
+
+// WriteReport renders a self-contained HTML report of the run into Agent.tmpDir, with a section for each product,
+// the status, duration, and error of each op, and its result. It should be called after RecordManifest and Analyze.
+func (a *Agent) WriteReport() error {
+	f, err := os.Create(filepath.Join(a.tmpDir, ReportFile))
+	if err != nil {
+		return err
+	}
+	if err := reportTemplate.Execute(f, a.reportData()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// reportData gathers what the report shows from the manifest, findings, and results of the run.
+func (a *Agent) reportData() reportData {
+	data := reportData{
+		Version:     a.Version.Version,
+		Command:     a.Environment.Command,
+		Username:    a.Environment.Username,
+		Hostname:    a.Environment.Hostname,
+		Start:       a.Start.UTC().Format(time.RFC3339),
+		End:         a.End.UTC().Format(time.RFC3339),
+		Duration:    a.End.Sub(a.Start).Round(time.Millisecond),
+		Interrupted: a.Interrupted,
+		TimedOut:    a.TimedOut,
+		Host:        hostSummary(a.results[product.Host]),
+		Statuses:    reportStatuses,
+	}
+	if a.Findings != nil {
+		data.Findings = a.Findings.Findings
+	}
+
+	names := make([]string, 0, len(a.ManifestOps))
+	for name := range a.ManifestOps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data.Products = append(data.Products, reportProductFor(name, a.ManifestOps[name], a.results[product.Name(name)]))
+	}
+	return data
+}
+
+// reportProductFor builds the report's section for a product, from its manifest ops and its results.
+func reportProductFor(name string, manifestOps []ManifestOp, results map[string]op.Op) reportProduct {
+	ops := make(map[string]op.Op)
+	flattenOps(results, ops)
+
+	counts := make(map[op.Status]int)
+	p := reportProduct{Name: name, Total: len(manifestOps)}
+	for _, m := range manifestOps {
+		counts[m.Status]++
+		rop := reportOp{
+			ID:        m.ID,
+			Status:    m.Status,
+			Error:     m.Error,
+			Duration:  parseNanoseconds(m.Duration),
+			QueueWait: parseNanoseconds(m.QueueWait),
+		}
+		if o, ok := ops[m.ID]; ok && o.Result != nil {
+			rop.Result, rop.Truncated = reportResult(o.Result)
+		}
+		p.Ops = append(p.Ops, rop)
+	}
+	sort.SliceStable(p.Ops, func(i, j int) bool { return p.Ops[i].ID < p.Ops[j].ID })
+	for _, status := range reportStatuses {
+		p.Counts = append(p.Counts, reportCount{Status: status, Count: counts[status]})
+	}
+	return p
+}
+
+// flattenOps adds every op in results, and every op nested in their results, such as those of do and seq runners, to
+// acc by ID.
+func flattenOps(results map[string]op.Op, acc map[string]op.Op) {
+	for id, o := range results {
+		acc[id] = o
+		nested := make(map[string]op.Op)
+		for k, v := range o.Result {
+			if n, ok := v.(op.Op); ok {
+				nested[k] = n
+			}
+		}
+		flattenOps(nested, acc)
+	}
+}
+
+// reportResult returns result as indented JSON, truncated to maxReportResultSize, and whether it was truncated.
+func reportResult(result map[string]any) (string, bool) {
+	// The template escapes the result, so it isn't escaped for HTML here too, which would make it harder to read.
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return fmt.Sprintf("unable to render result: %s", err), false
+	}
+	b := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	if len(b) > maxReportResultSize {
+		return string(b[:maxReportResultSize]), true
+	}
+	return string(b), false
+}
+
+// hostSummary returns the fields of the host info runner's result that the report summarizes, if it succeeded.
+func hostSummary(results map[string]op.Op) []reportField {
+	o, ok := results["info"]
+	if !ok || o.Result == nil {
+		return nil
+	}
+	// The result holds a host.InfoStat, so round-trip it through JSON to read its fields by name.
+	b, err := json.Marshal(o.Result["hostInfo"])
+	if err != nil {
+		return nil
+	}
+	var info map[string]any
+	if err := json.Unmarshal(b, &info); err != nil {
+		return nil
+	}
+
+	fields := make([]reportField, 0, len(hostInfoFields))
+	for _, f := range hostInfoFields {
+		v, ok := info[f.key]
+		if !ok || v == "" || v == nil {
+			continue
+		}
+		value := fmt.Sprint(v)
+		if n, ok := v.(float64); ok {
+			value = strconv.FormatFloat(n, 'f', -1, 64)
+			if f.key == "uptime" {
+				value = (time.Duration(n) * time.Second).String()
+			}
+		}
+		fields = append(fields, reportField{Label: f.label, Value: value})
+	}
+	return fields
+}
+
+// parseNanoseconds parses a duration in nanoseconds, as recorded in ManifestOp, returning zero if it's malformed.
+func parseNanoseconds(s string) time.Duration {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(n)
+}
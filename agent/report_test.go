@@ -0,0 +1,82 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/analyze"
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/product"
+	"github.com/hashicorp/hcdiag/runner/host"
+)
+
+func TestWriteReport(t *testing.T) {
+	a, cleanup := newTestAgent(t)
+	defer cleanup(emptyLogger)
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.Start, a.End = start, start.Add(90*time.Second)
+	a.Environment = Environment{Command: "hcdiag run -vault -html-report", Username: "ops", Hostname: "vault-0"}
+	nested := op.New("vault status", map[string]any{"sealed": false}, op.Success, nil, nil, start, start.Add(2*time.Second))
+	a.results = map[product.Name]map[string]op.Op{
+		product.Host: {
+			"info": op.New("info", map[string]any{"hostInfo": host.InfoStat{Hostname: "vault-0", OS: "linux", Uptime: 3600}}, op.Success, nil, nil, start, start.Add(time.Second)),
+		},
+		product.Vault: {
+			"do":                 op.New("do", map[string]any{"vault status": nested}, op.Success, nil, nil, start, start.Add(3*time.Second)),
+			"GET /v1/sys/health": op.New("GET /v1/sys/health", map[string]any{"body": "<script>alert(1)</script>"}, op.Fail, assert.AnError, nil, start, start.Add(time.Second)),
+			"lsof":               op.New("lsof", map[string]any{"output": strings.Repeat("x", maxReportResultSize)}, op.Success, nil, nil, start, start),
+		},
+	}
+	a.RecordManifest()
+	a.Findings = &analyze.Report{Findings: []analyze.Finding{{Rule: "vault-sealed", Product: "vault", Severity: analyze.Critical, Message: "Vault is sealed."}}}
+
+	require.NoError(t, a.WriteReport())
+	b, err := os.ReadFile(filepath.Join(a.tmpDir, ReportFile))
+	require.NoError(t, err)
+	report := string(b)
+
+	for _, want := range []string{
+		"<title>hcdiag report for vault-0</title>",
+		"hcdiag run -vault -html-report",
+		"1m30s",
+		// The summary of the host.
+		"<td>OS</td><td>linux</td>",
+		"<td>Uptime</td><td>1h0m0s</td>",
+		// The findings.
+		"Vault is sealed.",
+		// A section per product, with nested ops, their durations, and errors.
+		`<h2 id="product-vault">vault</h2>`,
+		"<code>vault status</code>",
+		"2s",
+		assert.AnError.Error(),
+		"The result was truncated",
+	} {
+		assert.Contains(t, report, want)
+	}
+	// Results are escaped, and the report doesn't load anything from elsewhere.
+	assert.NotContains(t, report, "<script>")
+	assert.Contains(t, report, "&lt;script&gt;")
+	assert.NotContains(t, report, "src=")
+	assert.NotContains(t, report, `href="http`)
+}
+
+func TestWriteReportNoResults(t *testing.T) {
+	a, cleanup := newTestAgent(t)
+	defer cleanup(emptyLogger)
+
+	require.NoError(t, a.WriteReport())
+	b, err := os.ReadFile(filepath.Join(a.tmpDir, ReportFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(b), "<h2>Summary</h2>")
+	assert.NotContains(t, string(b), "<h2>Host</h2>")
+}
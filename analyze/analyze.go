@@ -0,0 +1,187 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Package analyze evaluates declarative health rules against the results of an hcdiag run, and reports what it finds.
+// Rules are written in HCL. Each one names a product, a severity, a `when` expression that is true if something is
+// wrong, and a `message` that describes it. Built-in rule packs ship with hcdiag for each product; see Builtin.
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	hcl2 "github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// FindingsFile is the name of the file, at the root of a bundle, that findings are written to.
+const FindingsFile = "findings.json"
+
+// Severity describes how urgently a finding should be looked at.
+type Severity string
+
+const (
+	Info     Severity = "info"
+	Warning  Severity = "warning"
+	Critical Severity = "critical"
+)
+
+// Rule is a single health check. When is evaluated against the results of Product, and if it is true, a Finding is
+// reported with the evaluated Message. Both expressions may use the functions described in EvalContext.
+type Rule struct {
+	Name        string          `hcl:"name,label" json:"name"`
+	Product     string          `hcl:"product" json:"product"`
+	Severity    Severity        `hcl:"severity" json:"severity"`
+	Description string          `hcl:"description,optional" json:"description,omitempty"`
+	When        hcl2.Expression `hcl:"when" json:"-"`
+	Message     hcl2.Expression `hcl:"message" json:"-"`
+}
+
+// Validate returns an error if the rule is missing required settings or has an unknown severity.
+func (r Rule) Validate() error {
+	if r.Product == "" {
+		return fmt.Errorf("rule %q must set a product", r.Name)
+	}
+	switch r.Severity {
+	case Info, Warning, Critical:
+	default:
+		return fmt.Errorf("rule %q has unknown severity %q; expected %q, %q, or %q", r.Name, r.Severity, Info, Warning, Critical)
+	}
+	if isMissing(r.When) || isMissing(r.Message) {
+		return fmt.Errorf("rule %q must set both when and message", r.Name)
+	}
+	return nil
+}
+
+// isMissing returns true if expr was not set. When decoding, an attribute of type hcl.Expression that is absent from
+// the body is filled in with a static null.
+func isMissing(expr hcl2.Expression) bool {
+	if expr == nil {
+		return true
+	}
+	v, diags := expr.Value(nil)
+	return !diags.HasErrors() && v.IsNull()
+}
+
+// Finding is a rule whose condition was true for the results of a run.
+type Finding struct {
+	Rule        string   `json:"rule"`
+	Product     string   `json:"product"`
+	Severity    Severity `json:"severity"`
+	Message     string   `json:"message"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Skipped is a rule that could not be evaluated, for example because an op that it refers to failed or was filtered out.
+type Skipped struct {
+	Rule    string `json:"rule"`
+	Product string `json:"product"`
+	Reason  string `json:"reason"`
+}
+
+// Report is the outcome of evaluating a set of rules, as written to findings.json.
+type Report struct {
+	Findings []Finding `json:"findings"`
+	Skipped  []Skipped `json:"skipped"`
+}
+
+// Analyze evaluates rules against results, which are keyed by product and then by op ID, in the same shape as
+// results.json. Rules for products that have no results are ignored. Findings are sorted by severity, most severe
+// first, and then by product and rule name.
+func Analyze(rules []Rule, results map[string]map[string]any) Report {
+	report := Report{
+		Findings: make([]Finding, 0),
+		Skipped:  make([]Skipped, 0),
+	}
+
+	for _, rule := range rules {
+		ops, ok := results[rule.Product]
+		if !ok {
+			continue
+		}
+		finding, err := evaluate(rule, ops)
+		if err != nil {
+			report.Skipped = append(report.Skipped, Skipped{Rule: rule.Name, Product: rule.Product, Reason: err.Error()})
+			continue
+		}
+		if finding != nil {
+			report.Findings = append(report.Findings, *finding)
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		fi, fj := report.Findings[i], report.Findings[j]
+		if fi.Severity != fj.Severity {
+			return severityRank(fi.Severity) > severityRank(fj.Severity)
+		}
+		if fi.Product != fj.Product {
+			return fi.Product < fj.Product
+		}
+		return fi.Rule < fj.Rule
+	})
+	return report
+}
+
+// DecodeResults converts results, such as the in-memory results of a run, into the generic shape that Analyze expects
+// by round-tripping them through JSON.
+func DecodeResults(results any) (map[string]map[string]any, error) {
+	b, err := json.Marshal(results)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode results for analysis: %w", err)
+	}
+	var decoded map[string]map[string]any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		return nil, fmt.Errorf("unable to decode results for analysis: %w", err)
+	}
+	return decoded, nil
+}
+
+// evaluate returns a Finding if rule's condition is true for ops, or nil if it is false.
+func evaluate(rule Rule, ops map[string]any) (*Finding, error) {
+	ctx := EvalContext(ops)
+
+	when, diags := rule.When.Value(ctx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	when, err := convert.Convert(when, cty.Bool)
+	if err != nil {
+		return nil, fmt.Errorf("when must be a bool: %w", err)
+	}
+	if when.IsNull() || !when.IsKnown() {
+		return nil, fmt.Errorf("when did not evaluate to a known bool")
+	}
+	if when.False() {
+		return nil, nil
+	}
+
+	msg, diags := rule.Message.Value(ctx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	msg, err = convert.Convert(msg, cty.String)
+	if err != nil || msg.IsNull() || !msg.IsKnown() {
+		return nil, fmt.Errorf("message did not evaluate to a string")
+	}
+
+	return &Finding{
+		Rule:        rule.Name,
+		Product:     rule.Product,
+		Severity:    rule.Severity,
+		Message:     msg.AsString(),
+		Description: rule.Description,
+	}, nil
+}
+
+func severityRank(s Severity) int {
+	switch s {
+	case Critical:
+		return 2
+	case Warning:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package analyze
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/client"
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/runner"
+	"github.com/hashicorp/hcdiag/runner/do"
+)
+
+// okOp builds a successful op, as it appears in a decoded results.json.
+func okOp(result map[string]any) map[string]any {
+	return map[string]any{"status": "success", "result": result}
+}
+
+func TestAnalyze(t *testing.T) {
+	rules, err := Parse("test.hcl", []byte(`
+rule "always" {
+  product  = "host"
+  severity = "info"
+  when     = true
+  message  = "always ${upper("on")}"
+}
+
+rule "never" {
+  product  = "host"
+  severity = "critical"
+  when     = false
+  message  = "never"
+}
+
+rule "nested" {
+  product  = "host"
+  severity = "critical"
+  when     = result("inner", "out", "count") > 1
+  message  = "count is ${result("inner", "out", "count")}"
+}
+
+rule "failed-op" {
+  product  = "host"
+  severity = "warning"
+  when     = result("broken") != null
+  message  = "unreachable"
+}
+
+rule "status" {
+  product  = "host"
+  severity = "warning"
+  when     = status("broken") == "fail"
+  message  = "broken failed"
+}
+
+rule "other-product" {
+  product  = "vault"
+  severity = "critical"
+  when     = true
+  message  = "vault has no results"
+}
+`))
+	require.NoError(t, err)
+
+	results := map[string]map[string]any{
+		"host": {
+			"outer": okOp(map[string]any{
+				"inner": okOp(map[string]any{"out": map[string]any{"count": 2}}),
+			}),
+			"broken": map[string]any{"status": "fail", "result": nil},
+		},
+	}
+
+	report := Analyze(rules, results)
+	assert.Equal(t, []Finding{
+		{Rule: "nested", Product: "host", Severity: Critical, Message: "count is 2"},
+		{Rule: "status", Product: "host", Severity: Warning, Message: "broken failed"},
+		{Rule: "always", Product: "host", Severity: Info, Message: "always ON"},
+	}, report.Findings)
+	require.Len(t, report.Skipped, 1)
+	assert.Equal(t, "failed-op", report.Skipped[0].Rule)
+	assert.Contains(t, report.Skipped[0].Reason, `op "broken" did not succeed`)
+}
+
+func TestParseInvalid(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+	}{
+		{
+			name: "unknown severity",
+			src: `rule "r" {
+  product  = "host"
+  severity = "bad"
+  when     = true
+  message  = "m"
+}`,
+		},
+		{
+			name: "missing product",
+			src: `rule "r" {
+  product  = ""
+  severity = "info"
+  when     = true
+  message  = "m"
+}`,
+		},
+		{
+			name: "missing when",
+			src: `rule "r" {
+  product  = "host"
+  severity = "info"
+  message  = "m"
+}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse("test.hcl", []byte(tc.src))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBuiltin(t *testing.T) {
+	rules, err := Builtin()
+	require.NoError(t, err)
+
+	products := make(map[string]bool)
+	for _, r := range rules {
+		products[r.Product] = true
+	}
+	for _, p := range []string{"consul", "host", "nomad", "terraform-ent", "vault"} {
+		assert.True(t, products[p], "expected built-in rules for %s", p)
+	}
+
+	results := map[string]map[string]any{
+		"host": {
+			"disks": okOp(map[string]any{"partitions": []any{
+				map[string]any{"device": "/dev/sda1", "mountpoint": "/", "fstype": "ext4", "opts": []any{}, "used_percent": 95.5},
+				map[string]any{"device": "/dev/sda2", "mountpoint": "/data", "fstype": "ext4", "opts": []any{}, "used_percent": 10.0},
+				map[string]any{"device": "/dev/loop0", "mountpoint": "/snap/core", "fstype": "squashfs", "opts": []any{}, "used_percent": 100.0},
+				map[string]any{"device": "proc", "mountpoint": "/proc", "fstype": "proc", "opts": []any{}},
+			}}),
+		},
+		"vault": {
+			"GET /v1/sys/seal-status": okOp(map[string]any{"response": map[string]any{"sealed": true}}),
+		},
+		"nomad": {
+			"GET /v1/operator/raft/configuration?stale=true": okOp(map[string]any{"response": map[string]any{
+				"Servers": []any{map[string]any{"Node": "server-1", "Leader": true, "Voter": true}},
+			}}),
+			"GET /v1/agent/members?stale=true": okOp(map[string]any{"response": map[string]any{
+				"Members": []any{map[string]any{"Name": "server-1.global", "Status": "alive"}},
+			}}),
+		},
+		"terraform-ent": {
+			"replicatedctl app status --output json": okOp(map[string]any{"json": []any{
+				map[string]any{"State": "started", "DesiredState": "started"},
+			}}),
+		},
+		"consul": {
+			"GET /v1/status/peers": okOp(map[string]any{"response": []any{"10.0.0.1:8300", "10.0.0.2:8300"}}),
+			"GET /v1/agent/members?cached": okOp(map[string]any{"response": []any{
+				map[string]any{"Name": "server-1", "Status": 1, "Tags": map[string]any{"role": "consul"}},
+				map[string]any{"Name": "server-2", "Status": 1, "Tags": map[string]any{"role": "consul"}},
+				map[string]any{"Name": "server-3", "Status": 1, "Tags": map[string]any{"role": "consul"}},
+				map[string]any{"Name": "client-1", "Status": 4, "Tags": map[string]any{"role": "node"}},
+			}}),
+		},
+	}
+
+	report := Analyze(rules, results)
+	assert.Empty(t, report.Skipped)
+	assert.Equal(t, []Finding{
+		{
+			Rule:        "consul-raft-peers-mismatch",
+			Product:     "consul",
+			Severity:    Critical,
+			Message:     "Consul has 2 raft peers, but 3 alive servers.",
+			Description: rules[ruleIndex(t, rules, "consul-raft-peers-mismatch")].Description,
+		},
+		{
+			Rule:        "vault-sealed",
+			Product:     "vault",
+			Severity:    Critical,
+			Message:     "Vault is sealed.",
+			Description: rules[ruleIndex(t, rules, "vault-sealed")].Description,
+		},
+		{
+			Rule:        "consul-members-failed",
+			Product:     "consul",
+			Severity:    Warning,
+			Message:     "Consul members have failed: client-1",
+			Description: rules[ruleIndex(t, rules, "consul-members-failed")].Description,
+		},
+		{
+			Rule:        "disk-usage-high",
+			Product:     "host",
+			Severity:    Warning,
+			Message:     "Partitions are at least 90% full: / (95.5%)",
+			Description: rules[ruleIndex(t, rules, "disk-usage-high")].Description,
+		},
+	}, report.Findings)
+}
+
+func TestBuiltinVaultSealed(t *testing.T) {
+	rules, err := Builtin()
+	require.NoError(t, err)
+	sealed := rules[ruleIndex(t, rules, "vault-sealed")]
+
+	testCases := []struct {
+		name       string
+		sealStatus string
+		expectHit  bool
+	}{
+		{
+			name:       "sealed",
+			sealStatus: `{"type":"shamir","initialized":true,"sealed":true,"t":3,"n":5,"progress":0,"version":"1.15.0","storage_type":"raft"}`,
+			expectHit:  true,
+		},
+		{
+			name:       "unsealed",
+			sealStatus: `{"type":"shamir","initialized":true,"sealed":false,"t":3,"n":5,"progress":0,"version":"1.15.0","storage_type":"raft"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Vault responds 200 OK to sys/seal-status whether or not it's sealed.
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tc.sealStatus))
+			}))
+			defer srv.Close()
+			api, err := client.NewAPIClient(client.APIConfig{Product: "vault", BaseURL: srv.URL})
+			require.NoError(t, err)
+			h, err := runner.NewHTTP(runner.HttpConfig{Client: api, Path: "/v1/sys/seal-status"})
+			require.NoError(t, err)
+
+			// Built-in runners are wrapped in a Do, as they are in a run.
+			o := do.New(hclog.NewNullLogger(), "vault", "vault runners", []runner.Runner{h}).Run()
+			results, err := DecodeResults(map[string]map[string]op.Op{"vault": {o.Identifier: o}})
+			require.NoError(t, err)
+
+			report := Analyze([]Rule{sealed}, results)
+			assert.Empty(t, report.Skipped)
+			if tc.expectHit {
+				require.Len(t, report.Findings, 1)
+				assert.Equal(t, "Vault is sealed.", report.Findings[0].Message)
+			} else {
+				assert.Empty(t, report.Findings)
+			}
+		})
+	}
+}
+
+func ruleIndex(t *testing.T, rules []Rule, name string) int {
+	t.Helper()
+	for i, r := range rules {
+		if r.Name == name {
+			return i
+		}
+	}
+	t.Fatalf("no rule named %q", name)
+	return -1
+}
@@ -0,0 +1,62 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package analyze
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclsimple"
+)
+
+// rulePacks holds the built-in rules, with one file per product.
+//
+//go:embed rules/*.hcl
+var rulePacks embed.FS
+
+// ruleFile is the top-level structure of a file of rules.
+type ruleFile struct {
+	Rules []Rule `hcl:"rule,block"`
+}
+
+// Builtin returns the rules that ship with hcdiag, for every product.
+func Builtin() ([]Rule, error) {
+	names, err := fs.Glob(rulePacks, "rules/*.hcl")
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	var rules []Rule
+	for _, name := range names {
+		src, err := rulePacks.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := Parse(path.Base(name), src)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, parsed...)
+	}
+	return rules, nil
+}
+
+// Parse decodes and validates the rules in src, which is HCL made up of `rule` blocks. The filename is only used in
+// error messages.
+func Parse(filename string, src []byte) ([]Rule, error) {
+	var f ruleFile
+	if err := hclsimple.Decode(filename, src, nil, &f); err != nil {
+		return nil, err
+	}
+	for _, r := range f.Rules {
+		if err := r.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	return f.Rules, nil
+}
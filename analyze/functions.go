@@ -0,0 +1,164 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+
+	hcl2 "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/tryfunc"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+	"github.com/zclconf/go-cty/cty/function/stdlib"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+// EvalContext returns the context that a rule's expressions are evaluated in, for the ops of one product. Along with
+// general-purpose functions such as length, contains, regex, and try, it provides:
+//
+//   - result(op, keys...), which returns the result of the op with the given ID, descending into it by map keys the
+//     same way as util.FindInInterface. It fails if the op is missing or did not succeed.
+//   - status(op), which returns the status of the op with the given ID, or fails if it is missing.
+//
+// Ops nested in do and seq blocks may be referred to by their own IDs.
+func EvalContext(ops map[string]any) *hcl2.EvalContext {
+	return &hcl2.EvalContext{
+		Functions: map[string]function.Function{
+			"result": resultFunc(ops),
+			"status": statusFunc(ops),
+
+			"abs":       stdlib.AbsoluteFunc,
+			"can":       tryfunc.CanFunc,
+			"coalesce":  stdlib.CoalesceFunc,
+			"concat":    stdlib.ConcatFunc,
+			"contains":  stdlib.ContainsFunc,
+			"distinct":  stdlib.DistinctFunc,
+			"flatten":   stdlib.FlattenFunc,
+			"format":    stdlib.FormatFunc,
+			"join":      stdlib.JoinFunc,
+			"keys":      stdlib.KeysFunc,
+			"length":    stdlib.LengthFunc,
+			"lookup":    stdlib.LookupFunc,
+			"lower":     stdlib.LowerFunc,
+			"max":       stdlib.MaxFunc,
+			"min":       stdlib.MinFunc,
+			"regex":     stdlib.RegexFunc,
+			"regexall":  stdlib.RegexAllFunc,
+			"split":     stdlib.SplitFunc,
+			"strlen":    stdlib.StrlenFunc,
+			"tonumber":  stdlib.MakeToFunc(cty.Number),
+			"tostring":  stdlib.MakeToFunc(cty.String),
+			"trimspace": stdlib.TrimSpaceFunc,
+			"try":       tryfunc.TryFunc,
+			"upper":     stdlib.UpperFunc,
+			"values":    stdlib.ValuesFunc,
+		},
+	}
+}
+
+// resultFunc returns the result() function for ops.
+func resultFunc(ops map[string]any) function.Function {
+	lookup := func(args []cty.Value) (cty.Value, error) {
+		id := args[0].AsString()
+		o, err := findOp(ops, id)
+		if err != nil {
+			return cty.NilVal, err
+		}
+		if status, _ := o["status"].(string); op.Status(status) != op.Success {
+			return cty.NilVal, fmt.Errorf("op %q did not succeed; its status is %q", id, status)
+		}
+
+		var v any = o["result"]
+		if 1 < len(args) {
+			keys := make([]string, len(args)-1)
+			for i, arg := range args[1:] {
+				keys[i] = arg.AsString()
+			}
+			if v, err = util.FindInInterface(v, keys...); err != nil {
+				return cty.NilVal, fmt.Errorf("unable to find %v in the result of op %q: %w", keys, id, err)
+			}
+		}
+		return toValue(v)
+	}
+
+	return function.New(&function.Spec{
+		Params:   []function.Parameter{{Name: "op", Type: cty.String}},
+		VarParam: &function.Parameter{Name: "keys", Type: cty.String},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			v, err := lookup(args)
+			if err != nil {
+				return cty.NilType, err
+			}
+			return v.Type(), nil
+		},
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			return lookup(args)
+		},
+	})
+}
+
+// statusFunc returns the status() function for ops.
+func statusFunc(ops map[string]any) function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{{Name: "op", Type: cty.String}},
+		Type:   function.StaticReturnType(cty.String),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			o, err := findOp(ops, args[0].AsString())
+			if err != nil {
+				return cty.NilVal, err
+			}
+			status, _ := o["status"].(string)
+			return cty.StringVal(status), nil
+		},
+	})
+}
+
+// findOp returns the op with the given ID, looking first at the top level of ops and then inside the results of any
+// ops that nest others, such as do and seq blocks.
+func findOp(ops map[string]any, id string) (map[string]any, error) {
+	if o, ok := ops[id].(map[string]any); ok && isOp(o) {
+		return o, nil
+	}
+	for _, v := range ops {
+		o, ok := v.(map[string]any)
+		if !ok || !isOp(o) {
+			continue
+		}
+		nested, ok := o["result"].(map[string]any)
+		if !ok {
+			continue
+		}
+		if found, err := findOp(nested, id); err == nil {
+			return found, nil
+		}
+	}
+	return nil, fmt.Errorf("op %q not found", id)
+}
+
+// isOp returns true if m looks like an op.Op that was serialized to JSON.
+func isOp(m map[string]any) bool {
+	_, hasStatus := m["status"].(string)
+	_, hasResult := m["result"]
+	return hasStatus && hasResult
+}
+
+// toValue converts a value decoded from JSON into a cty.Value, so that rules can use it in expressions.
+func toValue(v any) (cty.Value, error) {
+	if v == nil {
+		return cty.NullVal(cty.DynamicPseudoType), nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	t, err := ctyjson.ImpliedType(b)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return ctyjson.Unmarshal(b, t)
+}
@@ -0,0 +1,19 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+# Servers are the members tagged with the "consul" role; a status of 1 means the member is alive.
+rule "consul-raft-peers-mismatch" {
+  product     = "consul"
+  severity    = "critical"
+  description = "Every alive Consul server should be a raft peer. A mismatch usually means a server failed to join, or a dead one was never removed."
+  when        = length(result("GET /v1/status/peers", "response")) != length([for m in result("GET /v1/agent/members?cached", "response") : m if try(m.Tags.role, "") == "consul" && m.Status == 1])
+  message     = "Consul has ${length(result("GET /v1/status/peers", "response"))} raft peers, but ${length([for m in result("GET /v1/agent/members?cached", "response") : m if try(m.Tags.role, "") == "consul" && m.Status == 1])} alive servers."
+}
+
+rule "consul-members-failed" {
+  product     = "consul"
+  severity    = "warning"
+  description = "Failed members are unreachable by the rest of the cluster, and are removed after the reconnect timeout."
+  when        = length([for m in result("GET /v1/agent/members?cached", "response") : m if m.Status == 4]) > 0
+  message     = "Consul members have failed: ${join(", ", [for m in result("GET /v1/agent/members?cached", "response") : m.Name if m.Status == 4])}"
+}
@@ -0,0 +1,11 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+# Read-only and pseudo filesystems, such as squashfs snap mounts, always report themselves as full, so they are ignored.
+rule "disk-usage-high" {
+  product     = "host"
+  severity    = "warning"
+  description = "A full disk can stop HashiCorp products from writing data, snapshots, or logs."
+  when        = length([for p in result("disks", "partitions") : p if try(p.used_percent, 0) >= 90 && !contains(["squashfs", "iso9660", "udf"], p.fstype)]) > 0
+  message     = "Partitions are at least 90% full: ${join(", ", [for p in result("disks", "partitions") : format("%s (%.1f%%)", p.mountpoint, p.used_percent) if try(p.used_percent, 0) >= 90 && !contains(["squashfs", "iso9660", "udf"], p.fstype)])}"
+}
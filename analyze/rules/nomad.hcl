@@ -0,0 +1,18 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+rule "nomad-raft-no-leader" {
+  product     = "nomad"
+  severity    = "critical"
+  description = "Without a raft leader, Nomad servers can't schedule work or accept writes."
+  when        = length([for s in result("GET /v1/operator/raft/configuration?stale=true", "response", "Servers") : s if s.Leader]) == 0
+  message     = "Nomad's raft configuration has no leader."
+}
+
+rule "nomad-members-not-alive" {
+  product     = "nomad"
+  severity    = "warning"
+  description = "Servers that aren't alive don't take part in raft, which reduces the cluster's failure tolerance."
+  when        = length([for m in result("GET /v1/agent/members?stale=true", "response", "Members") : m if m.Status != "alive"]) > 0
+  message     = "Nomad servers are not alive: ${join(", ", [for m in result("GET /v1/agent/members?stale=true", "response", "Members") : "${m.Name} (${m.Status})" if m.Status != "alive"])}"
+}
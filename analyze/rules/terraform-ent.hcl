@@ -0,0 +1,10 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+rule "tfe-app-not-started" {
+  product     = "terraform-ent"
+  severity    = "critical"
+  description = "Terraform Enterprise only serves requests once its application has reached the state it was asked to be in."
+  when        = length([for s in result("replicatedctl app status --output json", "json") : s if s.State != s.DesiredState]) > 0
+  message     = "The Terraform Enterprise application is not in its desired state: ${join(", ", [for s in result("replicatedctl app status --output json", "json") : "${s.State} (wanted ${s.DesiredState})" if s.State != s.DesiredState])}"
+}
@@ -0,0 +1,11 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+rule "vault-sealed" {
+  product     = "vault"
+  severity    = "critical"
+  description = "A sealed Vault node can't serve requests until it is unsealed."
+  # sys/seal-status responds 200 OK whether or not Vault is sealed, unlike `vault status`, which exits 2 when it is.
+  when        = result("GET /v1/sys/seal-status", "response", "sealed")
+  message     = "Vault is sealed."
+}
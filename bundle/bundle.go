@@ -0,0 +1,205 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Package bundle reads support bundles that were written by an hcdiag run, so that they can be inspected and compared
+// without extracting them to disk.
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcdiag/agent"
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/util"
+	"github.com/hashicorp/hcdiag/version"
+)
+
+const (
+	// ManifestFile is the name of the manifest at the root of every bundle.
+	ManifestFile = agent.ManifestFile
+	// SignatureFile is the name of the detached signature of the manifest, at the root of a signed bundle.
+	SignatureFile = agent.SignatureFile
+	// ResultsFile is the name of the results at the root of every bundle.
+	ResultsFile = "results.json"
+)
+
+// Manifest is the content of a bundle's manifest.json.
+type Manifest struct {
+	Start       time.Time                     `json:"started_at"`
+	End         time.Time                     `json:"ended_at"`
+	Duration    string                        `json:"duration"`
+	NumOps      int                           `json:"num_ops"`
+	Config      agent.Config                  `json:"configuration"`
+	Version     version.Version               `json:"version"`
+	Ops         map[string][]agent.ManifestOp `json:"ops"`
+	Environment agent.Environment             `json:"environment"`
+	Interrupted bool                          `json:"interrupted"`
+	TimedOut    bool                          `json:"timed_out"`
+	// Checksums is the SHA-256 of every file in the bundle other than the manifest and its signature, by name.
+	Checksums map[string]string `json:"checksums,omitempty"`
+	// PostRedactions records each time the bundle was redacted after it was written, in order.
+	PostRedactions []Redaction `json:"post_redactions,omitempty"`
+}
+
+// File describes a file in a bundle. Its Name is relative to the root of the bundle and always uses forward slashes.
+type File struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// Bundle is the content of an hcdiag bundle, read from an archive or a directory.
+type Bundle struct {
+	// Path is where the bundle was read from.
+	Path     string
+	Manifest Manifest
+	// Results holds the decoded results.json, keyed by product and then by op ID.
+	Results map[string]map[string]any
+	// Files lists every file in the bundle, including manifest.json and results.json, sorted by name.
+	Files []File
+
+	hasManifest bool
+}
+
+// Open reads the bundle at path, which may be an archive written by hcdiag, in any of the formats it writes, or a
+// directory, such as one that an archive was extracted into. The format of an archive is detected from its contents,
+// not its name. Only manifest.json and results.json are read into memory; nothing is written to disk.
+func Open(path string) (*Bundle, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{Path: path}
+	if info.IsDir() {
+		err = b.readDir(path)
+	} else {
+		err = b.readArchive(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to read bundle %s: %w", path, err)
+	}
+
+	if !b.hasManifest {
+		return nil, fmt.Errorf("unable to read bundle %s: %w", path, ErrNoManifest)
+	}
+	sort.Slice(b.Files, func(i, j int) bool { return b.Files[i].Name < b.Files[j].Name })
+	return b, nil
+}
+
+// ErrNoManifest is returned by Open when a bundle does not include a manifest.json at its root.
+var ErrNoManifest = errors.New("no " + ManifestFile + " found")
+
+// readArchive reads the files in the archive at path, in any format that hcdiag writes.
+func (b *Bundle) readArchive(path string) error {
+	return util.WalkArchive(path, func(entry util.ArchiveEntry, r io.Reader) error {
+		// hcdiag archives everything under a single top-level directory, named after the bundle.
+		name := stripBaseDir(entry.Name)
+		b.Files = append(b.Files, File{Name: name, Size: entry.Size})
+		return b.decode(name, r)
+	})
+}
+
+func (b *Bundle) readDir(root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		b.Files = append(b.Files, File{Name: name, Size: info.Size()})
+
+		if name != ManifestFile && name != ResultsFile {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return b.decode(name, f)
+	})
+}
+
+// decode reads manifest.json and results.json into b, and ignores every other file.
+func (b *Bundle) decode(name string, r io.Reader) error {
+	switch name {
+	case ManifestFile:
+		if err := json.NewDecoder(r).Decode(&b.Manifest); err != nil {
+			return fmt.Errorf("unable to decode %s: %w", ManifestFile, err)
+		}
+		b.hasManifest = true
+	case ResultsFile:
+		if err := json.NewDecoder(r).Decode(&b.Results); err != nil {
+			return fmt.Errorf("unable to decode %s: %w", ResultsFile, err)
+		}
+	}
+	return nil
+}
+
+// stripBaseDir removes the leading directory from a path within an archive.
+func stripBaseDir(name string) string {
+	name = strings.TrimPrefix(filepath.ToSlash(name), "./")
+	if i := strings.Index(name, "/"); i != -1 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// ProductOp is an op from the manifest, along with the product it belongs to.
+type ProductOp struct {
+	Product string `json:"product"`
+	agent.ManifestOp
+}
+
+// OpsWithStatus returns the manifest ops that finished with any of statuses, sorted by product and then by op ID.
+func (b *Bundle) OpsWithStatus(statuses ...op.Status) []ProductOp {
+	ops := make([]ProductOp, 0)
+	for product, manifestOps := range b.Manifest.Ops {
+		for _, o := range manifestOps {
+			for _, status := range statuses {
+				if o.Status == status {
+					ops = append(ops, ProductOp{Product: product, ManifestOp: o})
+					break
+				}
+			}
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool {
+		if ops[i].Product != ops[j].Product {
+			return ops[i].Product < ops[j].Product
+		}
+		return ops[i].ID < ops[j].ID
+	})
+	return ops
+}
+
+// StatusCounts returns the number of ops in the manifest for each product and status.
+func (b *Bundle) StatusCounts() map[string]map[op.Status]int {
+	counts := make(map[string]map[op.Status]int, len(b.Manifest.Ops))
+	for product, manifestOps := range b.Manifest.Ops {
+		counts[product] = make(map[op.Status]int)
+		for _, o := range manifestOps {
+			counts[product][o.Status]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,108 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/product"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+const testManifest = `{
+  "version": {"version": "0.6.0"},
+  "environment": {"command": "hcdiag run", "username": "tester", "hostname": "node1"},
+  "configuration": {"consul_enabled": true},
+  "ops": {
+    "consul": [
+      {"op": "consul version", "status": "success", "error": "", "duration": "1"},
+      {"op": "GET /v1/agent/self", "status": "fail", "error": "403 Forbidden", "duration": "1"}
+    ],
+    "host": [
+      {"op": "uname", "status": "timeout", "error": "context deadline exceeded", "duration": "1"}
+    ]
+  }
+}`
+
+const testResults = `{
+  "consul": {"consul version": {"result": {"version": "1.15.0"}, "status": "success"}}
+}`
+
+// writeTestBundle writes a bundle directory with a manifest, results, and one other file, and returns its path.
+func writeTestBundle(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFile), []byte(testManifest), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ResultsFile), []byte(testResults), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "host"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "host", "hosts"), []byte("127.0.0.1 localhost"), 0600))
+	return dir
+}
+
+func TestOpen(t *testing.T) {
+	dir := writeTestBundle(t)
+	tarGz := filepath.Join(t.TempDir(), "hcdiag2022-01-01T000000Z.tar.gz")
+	require.NoError(t, util.TarGz(dir, tarGz, "hcdiag2022-01-01T000000Z"))
+	tarZst := filepath.Join(t.TempDir(), "hcdiag2022-01-01T000000Z.tar.zst")
+	require.NoError(t, util.Archive(dir, tarZst, "hcdiag2022-01-01T000000Z", util.FormatTarZst, util.DefaultCompressionLevel))
+	// The format is detected from the contents, so the name doesn't matter.
+	zip := filepath.Join(t.TempDir(), "hcdiag2022-01-01T000000Z.bundle")
+	require.NoError(t, util.Archive(dir, zip, "hcdiag2022-01-01T000000Z", util.FormatZip, util.DefaultCompressionLevel))
+
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{name: "directory", path: dir},
+		{name: "tar.gz", path: tarGz},
+		{name: "tar.zst", path: tarZst},
+		{name: "zip", path: zip},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := Open(tc.path)
+			require.NoError(t, err)
+
+			assert.Equal(t, "0.6.0", b.Manifest.Version.Version)
+			assert.Equal(t, "node1", b.Manifest.Environment.Hostname)
+			assert.True(t, b.Manifest.Config.Enabled(product.Consul))
+			assert.Len(t, b.Manifest.Ops["consul"], 2)
+			assert.Contains(t, b.Results["consul"], "consul version")
+
+			names := make([]string, len(b.Files))
+			for i, f := range b.Files {
+				names[i] = f.Name
+			}
+			assert.Equal(t, []string{"host/hosts", ManifestFile, ResultsFile}, names)
+		})
+	}
+}
+
+func TestOpenWithoutManifest(t *testing.T) {
+	_, err := Open(t.TempDir())
+	assert.ErrorIs(t, err, ErrNoManifest)
+}
+
+func TestBundle_OpsWithStatus(t *testing.T) {
+	b, err := Open(writeTestBundle(t))
+	require.NoError(t, err)
+
+	ops := b.OpsWithStatus(op.Fail, op.Timeout)
+	require.Len(t, ops, 2)
+	assert.Equal(t, "consul", ops[0].Product)
+	assert.Equal(t, "403 Forbidden", ops[0].Error)
+	assert.Equal(t, "host", ops[1].Product)
+	assert.Equal(t, op.Timeout, ops[1].Status)
+
+	counts := b.StatusCounts()
+	assert.Equal(t, 1, counts["consul"][op.Success])
+	assert.Equal(t, 1, counts["host"][op.Timeout])
+}
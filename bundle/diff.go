@@ -0,0 +1,239 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/hcdiag/op"
+)
+
+// OpPathSeparator joins the IDs of nested ops, such as the runners in a Do or Seq block, into a single op path.
+const OpPathSeparator = " > "
+
+// Diff describes the differences between two bundles, A and B.
+type Diff struct {
+	StatusChanges []StatusChange `json:"status_changes"`
+	ResultChanges []ResultChange `json:"result_changes"`
+	OpsOnlyInA    []OpRef        `json:"ops_only_in_a"`
+	OpsOnlyInB    []OpRef        `json:"ops_only_in_b"`
+	FilesOnlyInA  []string       `json:"files_only_in_a"`
+	FilesOnlyInB  []string       `json:"files_only_in_b"`
+}
+
+// OpRef identifies an op within a bundle. Op is the op's ID, preceded by the IDs of any ops it is nested in.
+type OpRef struct {
+	Product string `json:"product"`
+	Op      string `json:"op"`
+}
+
+// StatusChange is an op that finished with a different status in each bundle.
+type StatusChange struct {
+	OpRef
+	A op.Status `json:"a"`
+	B op.Status `json:"b"`
+}
+
+// ResultChange lists the values in an op's results that differ between the bundles.
+type ResultChange struct {
+	OpRef
+	Changes []ValueChange `json:"changes"`
+}
+
+// ValueChange is a single value that differs between the bundles. Path locates the value within the op's results,
+// with map keys separated by dots and list indexes in brackets. A or B is nil if the value is missing from that bundle.
+type ValueChange struct {
+	Path string `json:"path"`
+	A    any    `json:"a"`
+	B    any    `json:"b"`
+}
+
+// Empty returns true if the bundles had no differences.
+func (d Diff) Empty() bool {
+	return len(d.StatusChanges) == 0 && len(d.ResultChanges) == 0 && len(d.OpsOnlyInA) == 0 &&
+		len(d.OpsOnlyInB) == 0 && len(d.FilesOnlyInA) == 0 && len(d.FilesOnlyInB) == 0
+}
+
+// Compare walks the op trees in the results of bundles a and b, and returns the ops whose status or results differ,
+// the ops that are only in one of them, and the files that are only in one of them.
+func Compare(a, b *Bundle) Diff {
+	d := Diff{
+		StatusChanges: make([]StatusChange, 0),
+		ResultChanges: make([]ResultChange, 0),
+		OpsOnlyInA:    make([]OpRef, 0),
+		OpsOnlyInB:    make([]OpRef, 0),
+		FilesOnlyInA:  make([]string, 0),
+		FilesOnlyInB:  make([]string, 0),
+	}
+
+	opsA, opsB := flattenOps(a.Results), flattenOps(b.Results)
+	for _, ref := range sortedRefs(opsA) {
+		oa := opsA[ref]
+		ob, ok := opsB[ref]
+		if !ok {
+			d.OpsOnlyInA = append(d.OpsOnlyInA, ref)
+			continue
+		}
+		if oa.status != ob.status {
+			d.StatusChanges = append(d.StatusChanges, StatusChange{OpRef: ref, A: oa.status, B: ob.status})
+		}
+		var changes []ValueChange
+		diffValues("", oa.result, ob.result, &changes)
+		if 0 < len(changes) {
+			d.ResultChanges = append(d.ResultChanges, ResultChange{OpRef: ref, Changes: changes})
+		}
+	}
+	for _, ref := range sortedRefs(opsB) {
+		if _, ok := opsA[ref]; !ok {
+			d.OpsOnlyInB = append(d.OpsOnlyInB, ref)
+		}
+	}
+
+	filesA, filesB := fileSet(a.Files), fileSet(b.Files)
+	for _, f := range a.Files {
+		if !filesB[f.Name] {
+			d.FilesOnlyInA = append(d.FilesOnlyInA, f.Name)
+		}
+	}
+	for _, f := range b.Files {
+		if !filesA[f.Name] {
+			d.FilesOnlyInB = append(d.FilesOnlyInB, f.Name)
+		}
+	}
+
+	return d
+}
+
+// opEntry is the part of a decoded op that Compare looks at. Its result excludes any nested ops.
+type opEntry struct {
+	status op.Status
+	result map[string]any
+}
+
+// flattenOps walks the op tree of each product in a decoded results.json, like op.WalkStatuses, and returns every op
+// keyed by its product and path.
+func flattenOps(results map[string]map[string]any) map[OpRef]opEntry {
+	acc := make(map[OpRef]opEntry)
+	for product, ops := range results {
+		walkOps(product, "", ops, acc)
+	}
+	return acc
+}
+
+func walkOps(product, parent string, results map[string]any, acc map[OpRef]opEntry) {
+	for id, v := range results {
+		m, ok := v.(map[string]any)
+		if !ok {
+			continue
+		}
+		if !isOp(m) {
+			walkOps(product, parent, m, acc)
+			continue
+		}
+
+		path := id
+		if parent != "" {
+			path = parent + OpPathSeparator + id
+		}
+		result, _ := m["result"].(map[string]any)
+		status, _ := m["status"].(string)
+
+		// Keep only the values that belong to this op; nested ops are compared on their own.
+		own := make(map[string]any, len(result))
+		for k, rv := range result {
+			if child, ok := rv.(map[string]any); ok && isOp(child) {
+				continue
+			}
+			own[k] = rv
+		}
+		acc[OpRef{Product: product, Op: path}] = opEntry{status: op.Status(status), result: own}
+		walkOps(product, path, result, acc)
+	}
+}
+
+// isOp returns true if m looks like an op.Op that was serialized to JSON.
+func isOp(m map[string]any) bool {
+	_, hasStatus := m["status"].(string)
+	_, hasResult := m["result"]
+	_, hasStart := m["start"]
+	return hasStatus && hasResult && hasStart
+}
+
+// diffValues appends a ValueChange to acc for each value that differs between a and b, descending into maps and lists.
+func diffValues(path string, a, b any, acc *[]ValueChange) {
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool, len(av)+len(bv))
+		for k := range av {
+			keys[k] = true
+		}
+		for k := range bv {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			diffValues(p, av[k], bv[k], acc)
+		}
+		return
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			break
+		}
+		n := len(av)
+		if len(bv) > n {
+			n = len(bv)
+		}
+		for i := 0; i < n; i++ {
+			var ai, bi any
+			if i < len(av) {
+				ai = av[i]
+			}
+			if i < len(bv) {
+				bi = bv[i]
+			}
+			diffValues(fmt.Sprintf("%s[%d]", path, i), ai, bi, acc)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a, b) {
+		*acc = append(*acc, ValueChange{Path: path, A: a, B: b})
+	}
+}
+
+func sortedRefs(ops map[OpRef]opEntry) []OpRef {
+	refs := make([]OpRef, 0, len(ops))
+	for ref := range ops {
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Product != refs[j].Product {
+			return refs[i].Product < refs[j].Product
+		}
+		return refs[i].Op < refs[j].Op
+	})
+	return refs
+}
+
+func fileSet(files []File) map[string]bool {
+	set := make(map[string]bool, len(files))
+	for _, f := range files {
+		set[f.Name] = true
+	}
+	return set
+}
@@ -0,0 +1,115 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/runner"
+)
+
+// testOp returns a decoded op, as it appears in results.json.
+func testOp(status op.Status, result map[string]any) map[string]any {
+	return map[string]any{
+		"result": result,
+		"error":  "",
+		"status": string(status),
+		"start":  "2022-01-01T00:00:00Z",
+		"end":    "2022-01-01T00:00:01Z",
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := &Bundle{
+		Results: map[string]map[string]any{
+			"vault": {
+				"vault status": testOp(op.Success, map[string]any{"json": map[string]any{"sealed": false, "version": "1.12.0"}}),
+				"vault read":   testOp(op.Fail, map[string]any{}),
+				"seq raft": testOp(op.Success, map[string]any{
+					"vault operator raft list-peers": testOp(op.Success, map[string]any{"json": []any{"a", "b", "c"}}),
+				}),
+			},
+		},
+		Files: []File{{Name: ManifestFile}, {Name: "vault/a.log"}},
+	}
+	b := &Bundle{
+		Results: map[string]map[string]any{
+			"vault": {
+				"vault status": testOp(op.Success, map[string]any{"json": map[string]any{"sealed": true, "version": "1.12.0"}}),
+				"vault read":   testOp(op.Success, map[string]any{}),
+				"seq raft": testOp(op.Success, map[string]any{
+					"vault operator raft list-peers": testOp(op.Success, map[string]any{"json": []any{"a", "b"}}),
+				}),
+				"vault audit list": testOp(op.Success, map[string]any{}),
+			},
+		},
+		Files: []File{{Name: ManifestFile}, {Name: "vault/b.log"}},
+	}
+
+	d := Compare(a, b)
+	assert.False(t, d.Empty())
+
+	assert.Equal(t, []StatusChange{
+		{OpRef: OpRef{Product: "vault", Op: "vault read"}, A: op.Fail, B: op.Success},
+	}, d.StatusChanges)
+
+	require.Len(t, d.ResultChanges, 2)
+	assert.Equal(t, "seq raft > vault operator raft list-peers", d.ResultChanges[0].Op)
+	assert.Equal(t, []ValueChange{{Path: "json[2]", A: "c", B: nil}}, d.ResultChanges[0].Changes)
+	assert.Equal(t, "vault status", d.ResultChanges[1].Op)
+	assert.Equal(t, []ValueChange{{Path: "json.sealed", A: false, B: true}}, d.ResultChanges[1].Changes)
+
+	assert.Empty(t, d.OpsOnlyInA)
+	assert.Equal(t, []OpRef{{Product: "vault", Op: "vault audit list"}}, d.OpsOnlyInB)
+	assert.Equal(t, []string{"vault/a.log"}, d.FilesOnlyInA)
+	assert.Equal(t, []string{"vault/b.log"}, d.FilesOnlyInB)
+}
+
+func TestCompareIdentical(t *testing.T) {
+	a := &Bundle{
+		Results: map[string]map[string]any{
+			"host": {"uname": testOp(op.Success, map[string]any{"shell": "Linux"})},
+		},
+		Files: []File{{Name: ManifestFile}},
+	}
+	assert.True(t, Compare(a, a).Empty())
+}
+
+func TestCompareSpilledOutputs(t *testing.T) {
+	// writeBundle writes a bundle whose only op has an output too large to keep in results.json.
+	writeBundle := func(t *testing.T) string {
+		dir := t.TempDir()
+		ctx := runner.WithOutputConfig(context.Background(), runner.OutputConfig{Dir: dir, MaxInline: 16})
+		out := runner.NewOutput(ctx, "shell journalctl -u vault", ".txt", nil)
+		_, err := out.Write([]byte(strings.Repeat("a log line\n", 4)))
+		require.NoError(t, err)
+		require.NoError(t, out.Close())
+		require.True(t, out.InFile())
+
+		results, err := json.Marshal(map[string]any{
+			"host": map[string]any{"shell journalctl -u vault": testOp(op.Success, out.FileResult())},
+		})
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFile), []byte(testManifest), 0600))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ResultsFile), results, 0600))
+		return dir
+	}
+
+	a, err := Open(writeBundle(t))
+	require.NoError(t, err)
+	b, err := Open(writeBundle(t))
+	require.NoError(t, err)
+
+	d := Compare(a, b)
+	assert.True(t, d.Empty(), "%+v", d)
+}
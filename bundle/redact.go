@@ -0,0 +1,290 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcdiag/agent"
+	"github.com/hashicorp/hcdiag/redact"
+	"github.com/hashicorp/hcdiag/runner"
+	"github.com/hashicorp/hcdiag/util"
+	"github.com/hashicorp/hcdiag/version"
+)
+
+// Redaction records redactions that were applied to a bundle after it was written, by Redact. Each one is appended to
+// the post_redactions in the bundle's manifest.
+type Redaction struct {
+	RedactedAt time.Time       `json:"redacted_at"`
+	Version    version.Version `json:"version"`
+	// Redactions is the number of replacements made by each redaction across the bundle, by redaction ID. Redactions
+	// that never matched anything are included with a count of zero.
+	Redactions map[string]int `json:"redactions"`
+	// SignatureRemoved is true if the bundle was signed. The signature no longer matches the redacted manifest, so it
+	// is left out of the redacted bundle.
+	SignatureRemoved bool `json:"signature_removed,omitempty"`
+}
+
+// Redact writes a copy of the archived bundle at src to dst, with redactions applied to every file, and the redaction
+// recorded in its manifest. results.json and the JSON outputs in outputs/ are redacted as JSON, so that path redactions
+// apply to them, and so is manifest.json, though without path redactions; every other file is redacted as text. The
+// checksums in the manifest are recomputed, and any signature is removed, since it can't match. dst is written in the
+// same format as src, with the same layout as the archive written by an hcdiag run, and must not already exist. Files
+// are only extracted into a private temporary directory once they have been redacted, and it is removed before Redact
+// returns.
+func Redact(src, dst string, redactions []*redact.Redact) (Redaction, error) {
+	if _, err := os.Stat(dst); err == nil {
+		return Redaction{}, fmt.Errorf("destination %s already exists", dst)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return Redaction{}, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "hcdiag-redact-*")
+	if err != nil {
+		return Redaction{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	format, err := util.DetectArchiveFormat(src)
+	if err != nil {
+		return Redaction{}, fmt.Errorf("unable to redact bundle %s: %w", src, err)
+	}
+
+	tracked, tally := redact.Track(redactions)
+	baseName, manifest, signed, err := extractRedacted(src, tmpDir, tracked)
+	if err != nil {
+		return Redaction{}, fmt.Errorf("unable to redact bundle %s: %w", src, err)
+	}
+	if manifest == nil {
+		return Redaction{}, fmt.Errorf("unable to redact bundle %s: %w", src, ErrNoManifest)
+	}
+
+	// The manifest is redacted last, so that the record includes the replacements made in it. Path redactions are
+	// written against the results of ops, so they're left out, rather than risk replacing the manifest's own fields.
+	manifest.contents, err = redactJSON(manifest.contents, withoutPaths(tracked))
+	if err != nil {
+		return Redaction{}, fmt.Errorf("unable to redact %s: %w", ManifestFile, err)
+	}
+	record := Redaction{
+		RedactedAt: time.Now(),
+		Version:    version.GetVersion(),
+		Redactions: make(map[string]int, len(redactions)),
+
+		SignatureRemoved: signed,
+	}
+	for _, r := range redactions {
+		record.Redactions[r.ID] = 0
+	}
+	for id, n := range tally.Counts() {
+		record.Redactions[id] = n
+	}
+	checksums, err := agent.Checksums(tmpDir)
+	if err != nil {
+		return Redaction{}, err
+	}
+	if err := writeManifest(manifest, record, checksums); err != nil {
+		return Redaction{}, err
+	}
+
+	if err := util.Archive(tmpDir, dst, baseName, format, util.DefaultCompressionLevel); err != nil {
+		return Redaction{}, err
+	}
+	return record, nil
+}
+
+// manifestEntry holds the unredacted manifest until the rest of the bundle has been redacted.
+type manifestEntry struct {
+	path     string
+	entry    util.ArchiveEntry
+	contents []byte
+}
+
+// extractRedacted writes each file in the archive at src into dir, with redactions applied, and returns the name of the
+// bundle's top-level directory. The manifest is returned, unredacted, rather than written, and the signature is left
+// out; signed is true if there was one.
+func extractRedacted(src, dir string, redactions []*redact.Redact) (baseName string, manifest *manifestEntry, signed bool, err error) {
+	err = util.WalkArchive(src, func(entry util.ArchiveEntry, r io.Reader) error {
+		// hcdiag archives everything under a single top-level directory, named after the bundle.
+		name := strings.TrimPrefix(filepath.ToSlash(entry.Name), "./")
+		base, rel, ok := strings.Cut(name, "/")
+		if !ok || rel == "" || !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return fmt.Errorf("unexpected file %q in bundle", entry.Name)
+		}
+		if baseName == "" {
+			baseName = base
+		} else if base != baseName {
+			return fmt.Errorf("unexpected file %q outside of the bundle's directory %q", entry.Name, baseName)
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return err
+		}
+		switch rel {
+		case SignatureFile:
+			signed = true
+		case ManifestFile:
+			contents, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			manifest = &manifestEntry{path: path, entry: entry, contents: contents}
+		case ResultsFile:
+			contents, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			contents, err = redactJSON(contents, redactions)
+			if err != nil {
+				return fmt.Errorf("unable to redact %s: %w", ResultsFile, err)
+			}
+			return writeFile(path, entry, bytes.NewReader(contents), nil)
+		default:
+			if isJSONOutput(rel) {
+				return writeJSONOutput(path, entry, r, redactions)
+			}
+			return writeFile(path, entry, r, redactions)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, false, err
+	}
+	return baseName, manifest, signed, nil
+}
+
+// isJSONOutput returns true if rel, a path relative to the root of a bundle, is an output that a runner wrote to a file
+// as JSON, because it was too large to include in results.json.
+func isJSONOutput(rel string) bool {
+	dir, file := path.Split(rel)
+	return dir == runner.OutputsDir+"/" && path.Ext(file) == ".json"
+}
+
+// writeJSONOutput writes the JSON output r to path, redacted value by value with redact.StreamJSON, as it was when the
+// bundle was written. Since outputs may be too large to hold in memory, r is first copied to a temporary file next to
+// path, in the private directory that the bundle is being redacted into. An output that isn't valid JSON, which is
+// redacted as text when it's written, is redacted as text here too.
+func writeJSONOutput(path string, entry util.ArchiveEntry, r io.Reader, redactions []*redact.Redact) error {
+	raw, err := os.CreateTemp(filepath.Dir(path), ".raw-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = raw.Close()
+		_ = os.Remove(raw.Name())
+	}()
+	if _, err := io.Copy(raw, r); err != nil {
+		return err
+	}
+	if _, err := raw.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	valid := redact.StreamJSON(io.Discard, raw, nil) == nil
+	if _, err := raw.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if !valid {
+		return writeFile(path, entry, raw, redactions)
+	}
+	return writeFileWith(path, entry, func(w io.Writer) error {
+		return redact.StreamJSON(w, raw, redactions)
+	})
+}
+
+// writeFile writes r to path, with redactions applied, and gives it the mode and modification time of entry.
+func writeFile(path string, entry util.ArchiveEntry, r io.Reader, redactions []*redact.Redact) error {
+	return writeFileWith(path, entry, func(w io.Writer) error {
+		return redact.Apply(redactions, w, r)
+	})
+}
+
+// writeFileWith writes to path with write, and gives it the mode and modification time of entry.
+func writeFileWith(path string, entry util.ArchiveEntry, write func(w io.Writer) error) error {
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := write(out); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, entry.Mode.Perm()); err != nil {
+		return err
+	}
+	return os.Chtimes(path, entry.ModTime, entry.ModTime)
+}
+
+// redactJSON applies redactions to the JSON document in contents, and returns it in the format written by an hcdiag
+// run. Numbers are kept as they were written.
+func redactJSON(contents []byte, redactions []*redact.Redact) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(contents))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	doc, err := redact.JSON(doc, redactions)
+	if err != nil {
+		return nil, err
+	}
+	return util.InterfaceToJSON(doc)
+}
+
+// writeManifest appends record to the post_redactions in the manifest, replaces its checksums, and writes it out.
+func writeManifest(manifest *manifestEntry, record Redaction, checksums map[string]string) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(manifest.contents, &m); err != nil {
+		return fmt.Errorf("unable to decode %s: %w", ManifestFile, err)
+	}
+	var records []Redaction
+	if raw, ok := m[postRedactionsKey]; ok {
+		if err := json.Unmarshal(raw, &records); err != nil {
+			return fmt.Errorf("unable to decode %s in %s: %w", postRedactionsKey, ManifestFile, err)
+		}
+	}
+	raw, err := json.Marshal(append(records, record))
+	if err != nil {
+		return err
+	}
+	m[postRedactionsKey] = raw
+	if m[checksumsKey], err = json.Marshal(checksums); err != nil {
+		return err
+	}
+
+	contents, err := util.InterfaceToJSON(m)
+	if err != nil {
+		return err
+	}
+	return writeFile(manifest.path, manifest.entry, bytes.NewReader(contents), nil)
+}
+
+const (
+	// postRedactionsKey is the key in manifest.json that Redact records redactions under.
+	postRedactionsKey = "post_redactions"
+	// checksumsKey is the key in manifest.json that holds the checksums of the bundle's files.
+	checksumsKey = "checksums"
+)
+
+// withoutPaths returns the redactions that aren't path redactions.
+func withoutPaths(redactions []*redact.Redact) []*redact.Redact {
+	filtered := make([]*redact.Redact, 0, len(redactions))
+	for _, r := range redactions {
+		if r.Path == "" {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,173 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/redact"
+	"github.com/hashicorp/hcdiag/runner"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+func TestRedact(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "hcdiag2022-01-01T000000Z.tar.gz")
+	require.NoError(t, util.TarGz(writeTestBundle(t), src, "hcdiag2022-01-01T000000Z"))
+
+	hostname, err := redact.New(redact.Config{ID: "hostname", Matcher: "node1"})
+	require.NoError(t, err)
+	localhost, err := redact.New(redact.Config{ID: "localhost", Matcher: `127\.0\.0\.1`})
+	require.NoError(t, err)
+	unused, err := redact.New(redact.Config{ID: "unused", Matcher: "nothing matches this"})
+	require.NoError(t, err)
+	versions, err := redact.NewPath(redact.Config{ID: "versions"}, "**.version")
+	require.NoError(t, err)
+	redactions := []*redact.Redact{hostname, localhost, unused, versions}
+
+	dst := filepath.Join(t.TempDir(), "redacted.tar.gz")
+	record, err := Redact(src, dst, redactions)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"hostname": 1, "localhost": 1, "unused": 0, "versions": 1}, record.Redactions)
+
+	b, err := Open(dst)
+	require.NoError(t, err)
+	assert.Equal(t, redact.DefaultReplace, b.Manifest.Environment.Hostname)
+	// Path redactions aren't applied to the manifest, so its version is intact.
+	assert.Equal(t, "0.6.0", b.Manifest.Version.Version)
+	assert.Len(t, b.Manifest.Ops["consul"], 2)
+	require.Len(t, b.Manifest.PostRedactions, 1)
+	assert.Equal(t, record.Redactions, b.Manifest.PostRedactions[0].Redactions)
+	assert.Equal(t, map[string]any{"version": redact.DefaultReplace}, b.Results["consul"]["consul version"].(map[string]any)["result"])
+
+	names := make([]string, len(b.Files))
+	for i, f := range b.Files {
+		names[i] = f.Name
+	}
+	assert.Equal(t, []string{"host/hosts", ManifestFile, ResultsFile}, names)
+	assert.Equal(t, map[string]string{
+		"hcdiag2022-01-01T000000Z/host/hosts": redact.DefaultReplace + " localhost",
+	}, readTarGzFiles(t, dst, "hcdiag2022-01-01T000000Z/host/hosts"))
+
+	t.Run("records each redaction", func(t *testing.T) {
+		again := filepath.Join(t.TempDir(), "again.tar.gz")
+		_, err := Redact(dst, again, []*redact.Redact{unused})
+		require.NoError(t, err)
+
+		b, err := Open(again)
+		require.NoError(t, err)
+		require.Len(t, b.Manifest.PostRedactions, 2)
+		assert.Equal(t, map[string]int{"unused": 0}, b.Manifest.PostRedactions[1].Redactions)
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		signed := writeSealedBundle(t, true, nil)
+		redacted := filepath.Join(t.TempDir(), "redacted.tar.gz")
+		record, err := Redact(signed, redacted, []*redact.Redact{localhost})
+		require.NoError(t, err)
+		assert.True(t, record.SignatureRemoved)
+
+		// The checksums are recomputed, so the redacted bundle still verifies, but it's no longer signed.
+		v, err := Verify(redacted, nil)
+		require.NoError(t, err)
+		assert.True(t, v.OK())
+		assert.Equal(t, 2, v.Verified)
+		assert.Equal(t, SignatureNone, v.Signature)
+	})
+
+	t.Run("keeps the format", func(t *testing.T) {
+		zip := filepath.Join(t.TempDir(), "hcdiag2022-01-01T000000Z.zip")
+		require.NoError(t, util.Archive(writeTestBundle(t), zip, "hcdiag2022-01-01T000000Z", util.FormatZip, util.DefaultCompressionLevel))
+		redacted := filepath.Join(t.TempDir(), "redacted.zip")
+		_, err := Redact(zip, redacted, []*redact.Redact{hostname})
+		require.NoError(t, err)
+
+		format, err := util.DetectArchiveFormat(redacted)
+		require.NoError(t, err)
+		assert.Equal(t, util.FormatZip, format)
+		b, err := Open(redacted)
+		require.NoError(t, err)
+		assert.Equal(t, redact.DefaultReplace, b.Manifest.Environment.Hostname)
+	})
+
+	t.Run("spilled outputs", func(t *testing.T) {
+		dir := writeTestBundle(t)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, runner.OutputsDir), 0700))
+		jsonOutput := filepath.Join(runner.OutputsDir, "GET_v1_agent_self-1234.json")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, jsonOutput), []byte(`{"version":"1.15.0","node":"node1","port":8500}`), 0600))
+		textOutput := filepath.Join(runner.OutputsDir, "consul_members-5678.txt")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, textOutput), []byte(`{"version":"1.15.0","node":"node1"}`), 0600))
+		invalidOutput := filepath.Join(runner.OutputsDir, "consul_info-9012.json")
+		require.NoError(t, os.WriteFile(filepath.Join(dir, invalidOutput), []byte("node1 is not JSON"), 0600))
+		spilled := filepath.Join(t.TempDir(), "hcdiag2022-01-01T000000Z.tar.gz")
+		require.NoError(t, util.TarGz(dir, spilled, "hcdiag2022-01-01T000000Z"))
+
+		redacted := filepath.Join(t.TempDir(), "redacted.tar.gz")
+		_, err := Redact(spilled, redacted, []*redact.Redact{hostname, versions})
+		require.NoError(t, err)
+
+		prefix := "hcdiag2022-01-01T000000Z/"
+		files := readTarGzFiles(t, redacted, prefix+filepath.ToSlash(jsonOutput), prefix+filepath.ToSlash(textOutput), prefix+filepath.ToSlash(invalidOutput))
+		// Path redactions apply to JSON outputs, but not to text.
+		assert.JSONEq(t, `{"version":"<REDACTED>","node":"<REDACTED>","port":8500}`, files[prefix+filepath.ToSlash(jsonOutput)])
+		assert.Equal(t, `{"version":"1.15.0","node":"<REDACTED>"}`, files[prefix+filepath.ToSlash(textOutput)])
+		assert.Equal(t, "<REDACTED> is not JSON", files[prefix+filepath.ToSlash(invalidOutput)])
+
+		v, err := Verify(redacted, nil)
+		require.NoError(t, err)
+		assert.True(t, v.OK())
+	})
+
+	t.Run("destination exists", func(t *testing.T) {
+		_, err := Redact(src, src, redactions)
+		assert.Error(t, err)
+	})
+
+	t.Run("no manifest", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ResultsFile), []byte(testResults), 0600))
+		noManifest := filepath.Join(t.TempDir(), "no-manifest.tar.gz")
+		require.NoError(t, util.TarGz(dir, noManifest, "hcdiag"))
+
+		dst := filepath.Join(t.TempDir(), "redacted.tar.gz")
+		_, err := Redact(noManifest, dst, redactions)
+		assert.ErrorIs(t, err, ErrNoManifest)
+		assert.NoFileExists(t, dst)
+	})
+}
+
+// readTarGzFiles returns the contents of each of names in the .tar.gz at path.
+func readTarGzFiles(t *testing.T, path string, names ...string) map[string]string {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	tr := tar.NewReader(gz)
+
+	files := make(map[string]string)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		for _, name := range names {
+			if header.Name == name {
+				bts, err := io.ReadAll(tr)
+				require.NoError(t, err)
+				files[name] = string(bts)
+			}
+		}
+	}
+	return files
+}
@@ -0,0 +1,150 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcdiag/util"
+)
+
+// SignatureStatus describes the outcome of checking a bundle's signature.
+type SignatureStatus string
+
+const (
+	// SignatureValid means the manifest's signature was made by the given public key.
+	SignatureValid SignatureStatus = "valid"
+	// SignatureInvalid means the manifest's signature was not made by the given public key, or the manifest was
+	// modified after it was signed.
+	SignatureInvalid SignatureStatus = "invalid"
+	// SignatureMissing means a public key was given, but the bundle isn't signed.
+	SignatureMissing SignatureStatus = "missing"
+	// SignatureUnchecked means the bundle is signed, but no public key was given to check it with.
+	SignatureUnchecked SignatureStatus = "unchecked"
+	// SignatureNone means the bundle isn't signed, and no public key was given.
+	SignatureNone SignatureStatus = "none"
+)
+
+// ErrNoChecksums is returned by Verify when a bundle's manifest has no checksums, such as a bundle that was written
+// by a version of hcdiag that didn't record them.
+var ErrNoChecksums = errors.New("no checksums found in " + ManifestFile)
+
+// Verification is the outcome of checking the files in a bundle against the checksums in its manifest, and the
+// manifest against its signature.
+type Verification struct {
+	// Verified is the number of files whose checksums matched.
+	Verified int `json:"verified"`
+	// Modified lists the files whose checksums didn't match, sorted by name.
+	Modified []string `json:"modified"`
+	// Missing lists the files that have checksums, but aren't in the bundle, sorted by name.
+	Missing []string `json:"missing"`
+	// Unexpected lists the files that are in the bundle, but have no checksum, sorted by name.
+	Unexpected []string        `json:"unexpected"`
+	Signature  SignatureStatus `json:"signature"`
+}
+
+// OK returns true if every file matched its checksum, and the signature, if one was checked, was valid.
+func (v Verification) OK() bool {
+	return len(v.Modified) == 0 && len(v.Missing) == 0 && len(v.Unexpected) == 0 &&
+		v.Signature != SignatureInvalid && v.Signature != SignatureMissing
+}
+
+// Verify checks every file in the archived bundle at path, in any format that hcdiag writes, against the checksums in
+// its manifest. If key is set, the manifest's signature is checked against it too. Files are hashed as they're read, so
+// nothing is extracted. An error is returned if the bundle can't be read at all, such as because it was truncated.
+func Verify(path string, key ed25519.PublicKey) (Verification, error) {
+	sums, manifest, sig, err := hashArchive(path)
+	if err != nil {
+		return Verification{}, fmt.Errorf("unable to read bundle %s: %w", path, err)
+	}
+	if manifest == nil {
+		return Verification{}, fmt.Errorf("unable to read bundle %s: %w", path, ErrNoManifest)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return Verification{}, fmt.Errorf("unable to decode %s: %w", ManifestFile, err)
+	}
+	if len(m.Checksums) == 0 {
+		return Verification{}, fmt.Errorf("unable to verify bundle %s: %w", path, ErrNoChecksums)
+	}
+
+	v := Verification{
+		Modified:   make([]string, 0),
+		Missing:    make([]string, 0),
+		Unexpected: make([]string, 0),
+		Signature:  checkSignature(manifest, sig, key),
+	}
+	for name, want := range m.Checksums {
+		got, ok := sums[name]
+		switch {
+		case !ok:
+			v.Missing = append(v.Missing, name)
+		case got != want:
+			v.Modified = append(v.Modified, name)
+		default:
+			v.Verified++
+		}
+	}
+	for name := range sums {
+		if _, ok := m.Checksums[name]; !ok {
+			v.Unexpected = append(v.Unexpected, name)
+		}
+	}
+	sort.Strings(v.Modified)
+	sort.Strings(v.Missing)
+	sort.Strings(v.Unexpected)
+	return v, nil
+}
+
+// hashArchive returns the SHA-256 of every file in the archive at path other than the manifest and its signature, by
+// name, along with the contents of the manifest and the signature, if there are any.
+func hashArchive(path string) (sums map[string]string, manifest, sig []byte, err error) {
+	sums = make(map[string]string)
+	err = util.WalkArchive(path, func(entry util.ArchiveEntry, r io.Reader) error {
+		var err error
+		switch name := stripBaseDir(entry.Name); name {
+		case ManifestFile:
+			manifest, err = io.ReadAll(r)
+		case SignatureFile:
+			sig, err = io.ReadAll(r)
+		default:
+			h := sha256.New()
+			if _, err = io.Copy(h, r); err == nil {
+				sums[name] = hex.EncodeToString(h.Sum(nil))
+			}
+		}
+		return err
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return sums, manifest, sig, nil
+}
+
+// checkSignature checks sig, the contents of a bundle's signature file, against manifest with key.
+func checkSignature(manifest, sig []byte, key ed25519.PublicKey) SignatureStatus {
+	switch {
+	case sig == nil && key == nil:
+		return SignatureNone
+	case sig == nil:
+		return SignatureMissing
+	case key == nil:
+		return SignatureUnchecked
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil || !ed25519.Verify(key, manifest, decoded) {
+		return SignatureInvalid
+	}
+	return SignatureValid
+}
@@ -0,0 +1,185 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package bundle
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/agent"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+const (
+	testSigningKey = "../tests/resources/keys/signing.pem"
+	testPublicKey  = "../tests/resources/keys/signing.pub.pem"
+	testOtherKey   = "../tests/resources/keys/other.pub.pem"
+)
+
+// writeSealedBundle writes a bundle like writeTestBundle, with checksums in its manifest, and signed if signed is
+// true. tamper, if set, is called on the bundle's directory before it is archived. It returns the path to the .tar.gz.
+func writeSealedBundle(t *testing.T, signed bool, tamper func(dir string)) string {
+	t.Helper()
+	dir := writeTestBundle(t)
+	checksums, err := agent.Checksums(dir)
+	require.NoError(t, err)
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal([]byte(testManifest), &m))
+	m["checksums"] = checksums
+	manifest, err := json.Marshal(m)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ManifestFile), manifest, 0600))
+
+	if signed {
+		key, err := util.ParseSigningKey(testSigningKey)
+		require.NoError(t, err)
+		sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, SignatureFile), []byte(sig+"\n"), 0600))
+	}
+	if tamper != nil {
+		tamper(dir)
+	}
+
+	tarGz := filepath.Join(t.TempDir(), "hcdiag.tar.gz")
+	require.NoError(t, util.TarGz(dir, tarGz, "hcdiag"))
+	return tarGz
+}
+
+func TestVerify(t *testing.T) {
+	publicKey, err := util.ParseVerifyingKey(testPublicKey)
+	require.NoError(t, err)
+	otherKey, err := util.ParseVerifyingKey(testOtherKey)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		signed bool
+		tamper func(dir string)
+		key    ed25519.PublicKey
+		expect Verification
+		ok     bool
+	}{
+		{
+			name:   "signed",
+			signed: true,
+			key:    publicKey,
+			expect: Verification{Verified: 2, Signature: SignatureValid},
+			ok:     true,
+		},
+		{
+			name:   "signed without a key",
+			signed: true,
+			expect: Verification{Verified: 2, Signature: SignatureUnchecked},
+			ok:     true,
+		},
+		{
+			name:   "unsigned",
+			expect: Verification{Verified: 2, Signature: SignatureNone},
+			ok:     true,
+		},
+		{
+			name:   "unsigned with a key",
+			key:    publicKey,
+			expect: Verification{Verified: 2, Signature: SignatureMissing},
+		},
+		{
+			name:   "signed by another key",
+			signed: true,
+			key:    otherKey,
+			expect: Verification{Verified: 2, Signature: SignatureInvalid},
+		},
+		{
+			name:   "manifest modified",
+			signed: true,
+			key:    publicKey,
+			tamper: func(dir string) {
+				f, err := os.OpenFile(filepath.Join(dir, ManifestFile), os.O_APPEND|os.O_WRONLY, 0600)
+				require.NoError(t, err)
+				_, err = f.WriteString("\n")
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			},
+			expect: Verification{Verified: 2, Signature: SignatureInvalid},
+		},
+		{
+			name: "files changed",
+			tamper: func(dir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "host", "hosts"), []byte("10.0.0.1 localhost"), 0600))
+				require.NoError(t, os.Remove(filepath.Join(dir, ResultsFile)))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "extra.txt"), []byte("extra"), 0600))
+			},
+			expect: Verification{
+				Modified:   []string{"host/hosts"},
+				Missing:    []string{ResultsFile},
+				Unexpected: []string{"extra.txt"},
+				Signature:  SignatureNone,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeSealedBundle(t, tc.signed, tc.tamper)
+			v, err := Verify(path, tc.key)
+			require.NoError(t, err)
+
+			for _, s := range []*[]string{&tc.expect.Modified, &tc.expect.Missing, &tc.expect.Unexpected} {
+				if *s == nil {
+					*s = []string{}
+				}
+			}
+			assert.Equal(t, tc.expect, v)
+			assert.Equal(t, tc.ok, v.OK())
+		})
+	}
+
+	t.Run("tar.zst", func(t *testing.T) {
+		dir := t.TempDir()
+		tarGz := writeSealedBundle(t, true, nil)
+		// Repack the sealed bundle as a .tar.zst, which leaves the checksums and signature as they were.
+		require.NoError(t, util.WalkArchive(tarGz, func(entry util.ArchiveEntry, r io.Reader) error {
+			path := filepath.Join(dir, filepath.FromSlash(stripBaseDir(entry.Name)))
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				return err
+			}
+			bts, err := io.ReadAll(r)
+			if err != nil {
+				return err
+			}
+			return os.WriteFile(path, bts, 0600)
+		}))
+		tarZst := filepath.Join(t.TempDir(), "hcdiag.tar.zst")
+		require.NoError(t, util.Archive(dir, tarZst, "hcdiag", util.FormatTarZst, 19))
+
+		v, err := Verify(tarZst, publicKey)
+		require.NoError(t, err)
+		assert.True(t, v.OK())
+		assert.Equal(t, 2, v.Verified)
+		assert.Equal(t, SignatureValid, v.Signature)
+	})
+
+	t.Run("no checksums", func(t *testing.T) {
+		tarGz := filepath.Join(t.TempDir(), "hcdiag.tar.gz")
+		require.NoError(t, util.TarGz(writeTestBundle(t), tarGz, "hcdiag"))
+		_, err := Verify(tarGz, nil)
+		assert.ErrorIs(t, err, ErrNoChecksums)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		bts, err := os.ReadFile(writeSealedBundle(t, false, nil))
+		require.NoError(t, err)
+		truncated := filepath.Join(t.TempDir(), "truncated.tar.gz")
+		require.NoError(t, os.WriteFile(truncated, bts[:len(bts)/2], 0600))
+		_, err = Verify(truncated, nil)
+		assert.Error(t, err)
+	})
+}
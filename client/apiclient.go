@@ -80,6 +80,25 @@ func (c *APIClient) RedactGet(path string, redactions []*redact.Redact) (any, er
 // RedactGetWithContext behaves similarly to RedactGet, however it takes a context object as a parameter, which is
 // then used when making HTTP requests. This allows for timeouts and cancellations to propagate.
 func (c *APIClient) RedactGetWithContext(ctx context.Context, path string, redactions []*redact.Redact) (result any, err error) {
+	var body bytes.Buffer
+	err = c.GetToWithContext(ctx, path, &body)
+	var statusErr StatusError
+	if err != nil && !errors.As(err, &statusErr) {
+		return nil, err
+	}
+
+	redResult, decodeErr := DecodeResponse(body.Bytes(), redactions)
+
+	// Error-return the status code if it's not 200 OK
+	if err != nil {
+		return redResult, err
+	}
+	return redResult, decodeErr
+}
+
+// GetToWithContext makes a GET request to path, and streams the response body to w rather than holding it in memory.
+// A status other than 200 OK is returned as a StatusError, once the body has been written.
+func (c *APIClient) GetToWithContext(ctx context.Context, path string, w io.Writer) (err error) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
@@ -91,7 +110,7 @@ func (c *APIClient) RedactGetWithContext(ctx context.Context, path string, redac
 	url := fmt.Sprintf("%s%s", c.BaseURL, path)
 	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(data))
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	// Set headers
@@ -103,32 +122,44 @@ func (c *APIClient) RedactGetWithContext(ctx context.Context, path string, redac
 	// Make request
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer func() {
 		err = errors.Join(err, resp.Body.Close())
 	}()
 
-	// Grab response contents
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	// Stream response contents
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return err
 	}
 
-	// Convert to interface{}
+	if resp.StatusCode != http.StatusOK {
+		return StatusError{Status: resp.Status, StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// StatusError is returned when an API responds with a status other than 200 OK.
+type StatusError struct {
+	// Status is the status line, such as "403 Forbidden", and StatusCode is its code.
+	Status     string
+	StatusCode int
+}
+
+func (e StatusError) Error() string {
+	return e.Status
+}
+
+// DecodeResponse decodes a JSON response body, and applies redactions to every string in it. The result is nil if
+// redactions could not be applied.
+func DecodeResponse(body []byte, redactions []*redact.Redact) (any, error) {
 	var v any
-	err = json.Unmarshal(body, &v)
+	err := json.Unmarshal(body, &v)
 
 	redResult, redErr := redact.JSON(v, redactions)
 	if redErr != nil {
 		return nil, redErr
 	}
-
-	// Error-return the status code if it's not 200 OK
-	if resp.StatusCode != http.StatusOK {
-		return redResult, errors.New(resp.Status)
-	}
-
 	return redResult, err
 }
 
@@ -0,0 +1,110 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+// https://developer.hashicorp.com/boundary/api-docs
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+const (
+	DefaultBoundaryAddr = "http://127.0.0.1:9200"
+	// DefaultBoundaryOpsAddr is the address of the ops listener, which serves the health endpoint, on its default port.
+	DefaultBoundaryOpsAddr = "http://127.0.0.1:9203"
+
+	EnvBoundaryAddr          = "BOUNDARY_ADDR"
+	EnvBoundaryOpsAddr       = "BOUNDARY_OPS_ADDR"
+	EnvBoundaryToken         = "BOUNDARY_TOKEN"
+	EnvBoundaryCaCert        = "BOUNDARY_CACERT"
+	EnvBoundaryCaPath        = "BOUNDARY_CAPATH"
+	EnvBoundaryClientCert    = "BOUNDARY_CLIENT_CERT"
+	EnvBoundaryClientKey     = "BOUNDARY_CLIENT_KEY"
+	EnvBoundaryTLSInsecure   = "BOUNDARY_TLS_INSECURE"
+	EnvBoundaryTlsServerName = "BOUNDARY_TLS_SERVER_NAME"
+)
+
+// NewBoundaryAPI returns an APIClient for a Boundary controller's API.
+func NewBoundaryAPI() (*APIClient, error) {
+	product := "boundary"
+
+	addr := os.Getenv(EnvBoundaryAddr)
+	if addr == "" {
+		addr = DefaultBoundaryAddr
+	}
+
+	headers := map[string]string{}
+	token := os.Getenv(EnvBoundaryToken)
+	if token == "" {
+		hclog.L().Warn("missing Boundary token; diagnostic information may be incomplete (please set the BOUNDARY_TOKEN environment variable to gather all information)")
+	} else {
+		headers["Authorization"] = "Bearer " + token
+	}
+
+	tlsConfig, err := NewBoundaryTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := APIConfig{
+		Product:   product,
+		BaseURL:   addr,
+		TLSConfig: tlsConfig,
+		headers:   headers,
+	}
+
+	apiClient, err := NewAPIClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiClient, nil
+}
+
+// NewBoundaryOpsAPI returns an APIClient for the ops listener of a Boundary controller or worker, which serves the
+// health endpoint without authentication. Its address is read from BOUNDARY_OPS_ADDR, and its TLS configuration is
+// shared with NewBoundaryAPI.
+func NewBoundaryOpsAPI() (*APIClient, error) {
+	addr := os.Getenv(EnvBoundaryOpsAddr)
+	if addr == "" {
+		addr = DefaultBoundaryOpsAddr
+	}
+
+	tlsConfig, err := NewBoundaryTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAPIClient(APIConfig{
+		Product:   "boundary",
+		BaseURL:   addr,
+		TLSConfig: tlsConfig,
+		headers:   map[string]string{},
+	})
+}
+
+// NewBoundaryTLSConfig returns a TLSConfig object, using
+// default environment variables to build up the object.
+func NewBoundaryTLSConfig() (TLSConfig, error) {
+	insecure := false
+	if v := os.Getenv(EnvBoundaryTLSInsecure); v != "" {
+		var err error
+		insecure, err = strconv.ParseBool(v)
+		if err != nil {
+			return TLSConfig{}, err
+		}
+	}
+
+	return TLSConfig{
+		CACert:        os.Getenv(EnvBoundaryCaCert),
+		CAPath:        os.Getenv(EnvBoundaryCaPath),
+		ClientCert:    os.Getenv(EnvBoundaryClientCert),
+		ClientKey:     os.Getenv(EnvBoundaryClientKey),
+		TLSServerName: os.Getenv(EnvBoundaryTlsServerName),
+		Insecure:      insecure,
+	}, nil
+}
@@ -0,0 +1,107 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBoundaryAPI(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		t.Setenv(EnvBoundaryAddr, "")
+		t.Setenv(EnvBoundaryToken, "")
+		api, err := NewBoundaryAPI()
+		require.NoError(t, err)
+		assert.Equal(t, "boundary", api.Product)
+		assert.Equal(t, DefaultBoundaryAddr, api.BaseURL)
+		assert.NotContains(t, api.headers, "Authorization")
+	})
+
+	t.Run("from the environment", func(t *testing.T) {
+		t.Setenv(EnvBoundaryAddr, "https://boundary.example.com:9200")
+		t.Setenv(EnvBoundaryToken, "at_1234567890_s1testytest")
+		api, err := NewBoundaryAPI()
+		require.NoError(t, err)
+		assert.Equal(t, "https://boundary.example.com:9200", api.BaseURL)
+		assert.Equal(t, "Bearer at_1234567890_s1testytest", api.headers["Authorization"])
+	})
+
+	t.Run("ops listener", func(t *testing.T) {
+		t.Setenv(EnvBoundaryOpsAddr, "")
+		api, err := NewBoundaryOpsAPI()
+		require.NoError(t, err)
+		assert.Equal(t, DefaultBoundaryOpsAddr, api.BaseURL)
+		assert.Empty(t, api.headers)
+	})
+}
+
+func TestNewBoundaryTLSConfig(t *testing.T) {
+	testCases := []struct {
+		name          string
+		expectErr     bool
+		caCert        string
+		caPath        string
+		clientCert    string
+		clientKey     string
+		tlsServerName string
+		insecure      string
+		expected      TLSConfig
+	}{
+		{
+			name:          "Test All Values Set",
+			caCert:        "/this_is_not_a_real_location/testcerts/ca.crt",
+			caPath:        "/this_is_not_a_real_location/testcerts/",
+			clientCert:    "/this_is_not_a_real_location/clientcerts/client.crt",
+			clientKey:     "/this_is_not_a_real_location/clientcerts/client.key",
+			tlsServerName: "servername.domain",
+			insecure:      "false",
+			expected: TLSConfig{
+				CACert:        "/this_is_not_a_real_location/testcerts/ca.crt",
+				CAPath:        "/this_is_not_a_real_location/testcerts/",
+				ClientCert:    "/this_is_not_a_real_location/clientcerts/client.crt",
+				ClientKey:     "/this_is_not_a_real_location/clientcerts/client.key",
+				TLSServerName: "servername.domain",
+				Insecure:      false,
+			},
+		},
+		{
+			name:     "Test No Values Set",
+			expected: TLSConfig{},
+		},
+		{
+			name:     "Test Insecure Set To True",
+			insecure: "true",
+			expected: TLSConfig{
+				Insecure: true,
+			},
+		},
+		{
+			name:      "Test Insecure Not Boolean Returns Error",
+			insecure:  "12345",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(EnvBoundaryCaCert, tc.caCert)
+			t.Setenv(EnvBoundaryCaPath, tc.caPath)
+			t.Setenv(EnvBoundaryClientCert, tc.clientCert)
+			t.Setenv(EnvBoundaryClientKey, tc.clientKey)
+			t.Setenv(EnvBoundaryTlsServerName, tc.tlsServerName)
+			t.Setenv(EnvBoundaryTLSInsecure, tc.insecure)
+
+			actual, err := NewBoundaryTLSConfig()
+			if tc.expectErr {
+				require.Error(t, err, "an error was expected, but was not raised")
+			} else {
+				require.NoError(t, err, "encountered unexpected error in NewBoundaryTLSConfig")
+				require.Equal(t, tc.expected, actual, "actual TLSConfig does not match the expected struct")
+			}
+		})
+	}
+}
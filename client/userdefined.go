@@ -0,0 +1,104 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// UserDefinedAPIConfig describes the API of a product that's defined in HCL, rather than built into hcdiag. Like those
+// of built-in products, its address, token, and TLS settings may be read from the environment; each *Env field names
+// the environment variable to read a setting from, and is ignored if empty.
+type UserDefinedAPIConfig struct {
+	Product string
+	// Address is the base URL of the API. If the variable that AddressEnv names is set, it takes precedence.
+	Address    string
+	AddressEnv string
+	// AuthEnv names the variable that holds the token to send in AuthHeader, which is "Authorization" by default.
+	// AuthScheme, such as "Bearer", is prepended to the token if set.
+	AuthEnv    string
+	AuthHeader string
+	AuthScheme string
+
+	CACertEnv        string
+	CAPathEnv        string
+	ClientCertEnv    string
+	ClientKeyEnv     string
+	TLSServerNameEnv string
+	TLSInsecureEnv   string
+}
+
+// NewUserDefinedAPI returns an APIClient for the API of a product that's defined in HCL.
+func NewUserDefinedAPI(cfg UserDefinedAPIConfig) (*APIClient, error) {
+	addr := cfg.Address
+	if v := getenv(cfg.AddressEnv); v != "" {
+		addr = v
+	}
+	if addr == "" {
+		return nil, fmt.Errorf("no API address for product %s; set address, or the variable named by address_env", cfg.Product)
+	}
+
+	headers := map[string]string{}
+	if cfg.AuthEnv != "" {
+		token := os.Getenv(cfg.AuthEnv)
+		if token == "" {
+			hclog.L().Warn("missing token; diagnostic information may be incomplete", "product", cfg.Product, "env", cfg.AuthEnv)
+		} else {
+			header := cfg.AuthHeader
+			if header == "" {
+				header = "Authorization"
+			}
+			if cfg.AuthScheme != "" {
+				token = cfg.AuthScheme + " " + token
+			}
+			headers[header] = token
+		}
+	}
+
+	tlsConfig, err := NewUserDefinedTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAPIClient(APIConfig{
+		Product:   cfg.Product,
+		BaseURL:   addr,
+		TLSConfig: tlsConfig,
+		headers:   headers,
+	})
+}
+
+// NewUserDefinedTLSConfig returns a TLSConfig object, using the environment variables that cfg names to build up the
+// object.
+func NewUserDefinedTLSConfig(cfg UserDefinedAPIConfig) (TLSConfig, error) {
+	insecure := false
+	if v := getenv(cfg.TLSInsecureEnv); v != "" {
+		var err error
+		insecure, err = strconv.ParseBool(v)
+		if err != nil {
+			return TLSConfig{}, fmt.Errorf("invalid %s for product %s: %w", cfg.TLSInsecureEnv, cfg.Product, err)
+		}
+	}
+
+	return TLSConfig{
+		CACert:        getenv(cfg.CACertEnv),
+		CAPath:        getenv(cfg.CAPathEnv),
+		ClientCert:    getenv(cfg.ClientCertEnv),
+		ClientKey:     getenv(cfg.ClientKeyEnv),
+		TLSServerName: getenv(cfg.TLSServerNameEnv),
+		Insecure:      insecure,
+	}, nil
+}
+
+// getenv returns the value of the environment variable key, or "" if key is empty.
+func getenv(key string) string {
+	if key == "" {
+		return ""
+	}
+	return os.Getenv(key)
+}
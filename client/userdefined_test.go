@@ -0,0 +1,97 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUserDefinedAPI(t *testing.T) {
+	testCases := []struct {
+		name            string
+		cfg             UserDefinedAPIConfig
+		env             map[string]string
+		expectErr       bool
+		expectedURL     string
+		expectedHeaders map[string]string
+		expectedTLS     TLSConfig
+	}{
+		{
+			name:            "address only",
+			cfg:             UserDefinedAPIConfig{Product: "acme", Address: "http://127.0.0.1:8080"},
+			expectedURL:     "http://127.0.0.1:8080",
+			expectedHeaders: map[string]string{},
+		},
+		{
+			name:            "address from the environment takes precedence",
+			cfg:             UserDefinedAPIConfig{Product: "acme", Address: "http://127.0.0.1:8080", AddressEnv: "ACME_ADDR"},
+			env:             map[string]string{"ACME_ADDR": "https://acme.example.com"},
+			expectedURL:     "https://acme.example.com",
+			expectedHeaders: map[string]string{},
+		},
+		{
+			name:            "token in a custom header",
+			cfg:             UserDefinedAPIConfig{Product: "acme", Address: "http://127.0.0.1:8080", AuthEnv: "ACME_TOKEN", AuthHeader: "X-Acme-Token"},
+			env:             map[string]string{"ACME_TOKEN": "s3cr3t"},
+			expectedURL:     "http://127.0.0.1:8080",
+			expectedHeaders: map[string]string{"X-Acme-Token": "s3cr3t"},
+		},
+		{
+			name:            "bearer token",
+			cfg:             UserDefinedAPIConfig{Product: "acme", Address: "http://127.0.0.1:8080", AuthEnv: "ACME_TOKEN", AuthScheme: "Bearer"},
+			env:             map[string]string{"ACME_TOKEN": "s3cr3t"},
+			expectedURL:     "http://127.0.0.1:8080",
+			expectedHeaders: map[string]string{"Authorization": "Bearer s3cr3t"},
+		},
+		{
+			name: "TLS from the environment",
+			cfg: UserDefinedAPIConfig{
+				Product:          "acme",
+				Address:          "https://127.0.0.1:8443",
+				TLSServerNameEnv: "ACME_TLS_SERVER_NAME",
+				TLSInsecureEnv:   "ACME_SKIP_VERIFY",
+			},
+			env: map[string]string{
+				"ACME_TLS_SERVER_NAME": "acme.internal",
+				"ACME_SKIP_VERIFY":     "true",
+			},
+			expectedURL:     "https://127.0.0.1:8443",
+			expectedHeaders: map[string]string{},
+			expectedTLS:     TLSConfig{TLSServerName: "acme.internal", Insecure: true},
+		},
+		{
+			name:      "no address",
+			cfg:       UserDefinedAPIConfig{Product: "acme", AddressEnv: "ACME_ADDR"},
+			env:       map[string]string{"ACME_ADDR": ""},
+			expectErr: true,
+		},
+		{
+			name:      "insecure not boolean",
+			cfg:       UserDefinedAPIConfig{Product: "acme", Address: "http://127.0.0.1:8080", TLSInsecureEnv: "ACME_SKIP_VERIFY"},
+			env:       map[string]string{"ACME_SKIP_VERIFY": "12345"},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			api, err := NewUserDefinedAPI(tc.cfg)
+			if tc.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "acme", api.Product)
+			assert.Equal(t, tc.expectedURL, api.BaseURL)
+			assert.Equal(t, tc.expectedHeaders, api.headers)
+			assert.Equal(t, tc.expectedTLS, api.TLSConfig)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/hcdiag/util"
+)
+
+var _ cli.Command = &DecryptCommand{}
+
+type DecryptCommand struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	// identity is the path to a file of age private keys
+	identity string
+	// dest is the path to write the decrypted bundle to
+	dest string
+}
+
+func (c *DecryptCommand) init() {
+	const (
+		identityUsageText = "Path to a file of age private keys, as written by 'age-keygen', one of which the bundle was encrypted to (required)"
+		destUsageText     = "Path to write the decrypted bundle to. Defaults to the bundle's path, without the '.age' extension, or for a bundle that was split into parts, the path of its index, without the '.parts.json' and '.age' extensions"
+	)
+
+	c.flags = flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	c.flags.StringVar(&c.identity, "identity", "", identityUsageText)
+	c.flags.StringVar(&c.dest, "dest", "", destUsageText)
+	c.flags.SetOutput(io.Discard)
+}
+
+// NewDecryptCommand produces a new *DecryptCommand, initialized for use in a CLI application.
+func NewDecryptCommand(ui cli.Ui) *DecryptCommand {
+	c := &DecryptCommand{ui: ui}
+	c.init()
+	return c
+}
+
+// DecryptCommandFactory provides a cli.CommandFactory that will produce an appropriately-initiated *DecryptCommand.
+func DecryptCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return NewDecryptCommand(ui), nil
+	}
+}
+
+// Help provides the full help output for the command.
+func (c *DecryptCommand) Help() string {
+	helpText := `Usage: hcdiag decrypt [options] -identity <key file> <bundle>
+
+Decrypts a bundle that 'hcdiag run -recipient' encrypted with age, using the private key that matches one of its
+recipients. The decrypted bundle is written next to the encrypted one, without the '.age' extension, or to
+the path given with -dest, and is only readable by its owner. Nothing is written if the bundle can't be decrypted, or
+has been truncated or modified. A bundle that was split into parts with -max-bundle-size is decrypted from the index
+of its parts, and written whole.
+`
+	return Usage(helpText, c.flags)
+}
+
+// Synopsis provides a brief description of the command, for inclusion in the application's primary --help.
+func (c *DecryptCommand) Synopsis() string {
+	return "Decrypt an encrypted hcdiag bundle"
+}
+
+// Run executes the command.
+func (c *DecryptCommand) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Error parsing flags: %s", err))
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.flags.NArg() != 1 {
+		c.ui.Error("Expected exactly one bundle to decrypt")
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.identity == "" {
+		c.ui.Error("A private key to decrypt the bundle with is required; use -identity")
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+
+	src := c.flags.Arg(0)
+	dest := c.dest
+	if dest == "" {
+		// The decrypted bundle is written whole, whether or not the encrypted one was split into parts.
+		name := strings.TrimSuffix(src, util.PartsIndexExt)
+		if !strings.HasSuffix(name, util.EncryptedExt) {
+			c.ui.Error(fmt.Sprintf("The bundle's name doesn't end in %q; use -dest to choose where to write it", util.EncryptedExt))
+			return FlagParseError
+		}
+		dest = strings.TrimSuffix(name, util.EncryptedExt)
+	}
+
+	identities, err := util.ParseIdentities(c.identity)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return ConfigError
+	}
+
+	if err := util.Decrypt(src, dest, identities); err != nil {
+		c.ui.Error(fmt.Sprintf("Unable to decrypt %s: %s", src, err))
+		return BundleReadError
+	}
+	c.ui.Output(fmt.Sprintf("Wrote decrypted bundle to %s", dest))
+
+	return Success
+}
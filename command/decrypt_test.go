@@ -0,0 +1,68 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/bundle"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+func TestDecryptCommand_Run(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	keyFile := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(keyFile, []byte(identity.String()+"\n"), 0600))
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	otherKeyFile := filepath.Join(t.TempDir(), "other.txt")
+	require.NoError(t, os.WriteFile(otherKeyFile, []byte(other.String()+"\n"), 0600))
+
+	// Encrypt the bundle the same way that run does.
+	destination := t.TempDir()
+	src, err := compressOutputDir("testdata/bundles/before", "hcdiag2022-01-01T000000Z", destination, util.FormatTarGz, util.DefaultCompressionLevel, []age.Recipient{identity.Recipient()}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(destination, "hcdiag2022-01-01T000000Z.tar.gz.age"), src)
+	_, err = bundle.Open(src)
+	assert.Error(t, err, "the encrypted bundle should not be readable")
+
+	t.Run("decrypt", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewDecryptCommand(ui).Run([]string{"-identity", keyFile, src})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+
+		dest := filepath.Join(destination, "hcdiag2022-01-01T000000Z.tar.gz")
+		assert.Contains(t, ui.OutputWriter.String(), dest)
+		b, err := bundle.Open(dest)
+		require.NoError(t, err)
+		assert.Equal(t, "0.6.0", b.Manifest.Version.Version)
+	})
+
+	testCases := []struct {
+		name string
+		args []string
+		rc   int
+	}{
+		{name: "no bundle", args: []string{"-identity", keyFile}, rc: FlagParseError},
+		{name: "no identity", args: []string{src}, rc: FlagParseError},
+		{name: "no extension", args: []string{"-identity", keyFile, "testdata/bundles/before"}, rc: FlagParseError},
+		{name: "missing identity", args: []string{"-identity", filepath.Join(t.TempDir(), "missing.txt"), src}, rc: ConfigError},
+		{name: "wrong identity", args: []string{"-identity", otherKeyFile, "-dest", filepath.Join(t.TempDir(), "out.tar.gz"), src}, rc: BundleReadError},
+		{name: "destination exists", args: []string{"-identity", keyFile, "-dest", src, src}, rc: BundleReadError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			assert.Equal(t, tc.rc, NewDecryptCommand(ui).Run(tc.args))
+		})
+	}
+}
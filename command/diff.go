@@ -0,0 +1,231 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/hcdiag/bundle"
+)
+
+// maxDiffValueLength is the longest a value from a bundle's results may be in text output before it is truncated.
+const maxDiffValueLength = 80
+
+var _ cli.Command = &DiffCommand{}
+
+type DiffCommand struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	// format is the output format, either text or json
+	format string
+}
+
+func (c *DiffCommand) init() {
+	const formatUsageText = "Output format, either 'text' or 'json'"
+
+	c.flags = flag.NewFlagSet("diff", flag.ContinueOnError)
+	c.flags.StringVar(&c.format, "format", formatText, formatUsageText)
+	c.flags.SetOutput(io.Discard)
+}
+
+// NewDiffCommand produces a new *DiffCommand, initialized for use in a CLI application.
+func NewDiffCommand(ui cli.Ui) *DiffCommand {
+	c := &DiffCommand{ui: ui}
+	c.init()
+	return c
+}
+
+// DiffCommandFactory provides a cli.CommandFactory that will produce an appropriately-initiated *DiffCommand.
+func DiffCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return NewDiffCommand(ui), nil
+	}
+}
+
+// Help provides the full help output for the command.
+func (c *DiffCommand) Help() string {
+	helpText := `Usage: hcdiag diff [options] <bundle a> <bundle b>
+
+Compares two bundles written by 'hcdiag run', for example from a healthy node and a misbehaving one. Each bundle may
+be a .tar.gz, .tar.zst, or .zip file, the index of a bundle that was split into parts, or a directory, such as one
+that an archive was extracted into. The comparison lists ops whose status changed, values in each op's results that
+differ, ops that are only in one bundle, and files that are only in one bundle.
+`
+	return Usage(helpText, c.flags)
+}
+
+// Synopsis provides a brief description of the command, for inclusion in the application's primary --help.
+func (c *DiffCommand) Synopsis() string {
+	return "Compare two hcdiag bundles"
+}
+
+// diffOutput is the JSON rendering of a comparison between two bundles.
+type diffOutput struct {
+	A string `json:"a"`
+	B string `json:"b"`
+	bundle.Diff
+}
+
+// Run executes the command.
+func (c *DiffCommand) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Error parsing flags: %s", err))
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.flags.NArg() != 2 {
+		c.ui.Error("Expected exactly two bundles to compare")
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.format != formatText && c.format != formatJSON {
+		c.ui.Error(fmt.Sprintf("Unsupported format %q; expected %q or %q", c.format, formatText, formatJSON))
+		return FlagParseError
+	}
+
+	bundles := make([]*bundle.Bundle, 2)
+	for i, path := range c.flags.Args() {
+		b, err := bundle.Open(path)
+		if err != nil {
+			c.ui.Error(err.Error())
+			return BundleReadError
+		}
+		bundles[i] = b
+	}
+
+	out := diffOutput{
+		A:    bundles[0].Path,
+		B:    bundles[1].Path,
+		Diff: bundle.Compare(bundles[0], bundles[1]),
+	}
+
+	var rendered string
+	if c.format == formatJSON {
+		j, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			c.ui.Error(err.Error())
+			return OutputError
+		}
+		rendered = string(j)
+	} else {
+		var buf bytes.Buffer
+		if err := writeDiff(&buf, out); err != nil {
+			c.ui.Error(err.Error())
+			return OutputError
+		}
+		rendered = strings.TrimRight(buf.String(), "\n")
+	}
+	c.ui.Output(rendered)
+
+	return Success
+}
+
+// writeDiff renders out as human-readable text.
+func writeDiff(w io.Writer, out diffOutput) error {
+	if _, err := fmt.Fprintf(w, "a: %s\nb: %s\n", out.A, out.B); err != nil {
+		return err
+	}
+	if out.Empty() {
+		_, err := fmt.Fprint(w, "\nNo differences found.\n")
+		return err
+	}
+
+	if 0 < len(out.StatusChanges) {
+		if _, err := fmt.Fprint(w, "\nStatus changes\n\n"); err != nil {
+			return err
+		}
+		t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if _, err := fmt.Fprint(t, formatReportLine("product", "op", "a", "b")); err != nil {
+			return err
+		}
+		for _, s := range out.StatusChanges {
+			if _, err := fmt.Fprint(t, formatReportLine(s.Product, s.Op, string(s.A), string(s.B))); err != nil {
+				return err
+			}
+		}
+		if err := t.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if 0 < len(out.ResultChanges) {
+		if _, err := fmt.Fprint(w, "\nResult changes\n"); err != nil {
+			return err
+		}
+		for _, r := range out.ResultChanges {
+			if _, err := fmt.Fprintf(w, "\n%s: %s\n", r.Product, r.Op); err != nil {
+				return err
+			}
+			for _, v := range r.Changes {
+				path := v.Path
+				if path == "" {
+					path = "(result)"
+				}
+				if _, err := fmt.Fprintf(w, "  %s\n    a: %s\n    b: %s\n", path, formatDiffValue(v.A), formatDiffValue(v.B)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	sections := []struct {
+		title string
+		lines []string
+	}{
+		{"Ops only in a", opLines(out.OpsOnlyInA)},
+		{"Ops only in b", opLines(out.OpsOnlyInB)},
+		{"Files only in a", out.FilesOnlyInA},
+		{"Files only in b", out.FilesOnlyInB},
+	}
+	for _, s := range sections {
+		if len(s.lines) == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "\n%s\n\n", s.title); err != nil {
+			return err
+		}
+		for _, line := range s.lines {
+			if _, err := fmt.Fprintf(w, "  %s\n", line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func opLines(refs []bundle.OpRef) []string {
+	lines := make([]string, len(refs))
+	for i, ref := range refs {
+		lines[i] = ref.Product + ": " + ref.Op
+	}
+	return lines
+}
+
+// formatDiffValue renders a value from a bundle's results on a single line, truncating it if it is long.
+func formatDiffValue(v any) string {
+	if v == nil {
+		return "<missing>"
+	}
+	var s string
+	if str, ok := v.(string); ok {
+		s = fmt.Sprintf("%q", str)
+	} else if j, err := json.Marshal(v); err == nil {
+		s = string(j)
+	} else {
+		s = fmt.Sprint(v)
+	}
+	if len(s) > maxDiffValueLength {
+		s = s[:maxDiffValueLength] + "..."
+	}
+	return s
+}
@@ -0,0 +1,68 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/op"
+)
+
+func TestDiffCommand_Run(t *testing.T) {
+	const before, after = "testdata/bundles/before", "testdata/bundles/after"
+
+	t.Run("text", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewDiffCommand(ui).Run([]string{before, after})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+
+		out := ui.OutputWriter.String()
+		assert.Regexp(t, `consul\s+GET /v1/agent/self\s+fail\s+success`, out)
+		assert.Contains(t, out, "consul: consul operator raft list-peers\n  json[1].voter\n    a: true\n    b: false")
+		assert.Contains(t, out, "consul: seq members > consul members")
+		assert.Contains(t, out, "Files only in a\n\n  host/hosts")
+		assert.Contains(t, out, "Files only in b\n\n  host/sick-only.log")
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewDiffCommand(ui).Run([]string{"-format=json", before, after})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+
+		var out diffOutput
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &out))
+		require.Len(t, out.StatusChanges, 2)
+		assert.Equal(t, op.Timeout, out.StatusChanges[1].A)
+		assert.Len(t, out.ResultChanges, 3)
+		assert.Len(t, out.OpsOnlyInB, 2)
+	})
+
+	t.Run("identical", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewDiffCommand(ui).Run([]string{before, before})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "No differences found.")
+	})
+
+	testCases := []struct {
+		name string
+		args []string
+		rc   int
+	}{
+		{name: "one bundle", args: []string{before}, rc: FlagParseError},
+		{name: "unsupported format", args: []string{"-format=xml", before, after}, rc: FlagParseError},
+		{name: "missing bundle", args: []string{before, "testdata/bundles/missing"}, rc: BundleReadError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			assert.Equal(t, tc.rc, NewDiffCommand(ui).Run(tc.args))
+		})
+	}
+}
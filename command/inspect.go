@@ -0,0 +1,248 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/hcdiag/agent"
+	"github.com/hashicorp/hcdiag/bundle"
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/version"
+)
+
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+var _ cli.Command = &InspectCommand{}
+
+type InspectCommand struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	// format is the output format, either text or json
+	format string
+}
+
+func (c *InspectCommand) init() {
+	const formatUsageText = "Output format, either 'text' or 'json'"
+
+	c.flags = flag.NewFlagSet("inspect", flag.ContinueOnError)
+	c.flags.StringVar(&c.format, "format", formatText, formatUsageText)
+	c.flags.SetOutput(io.Discard)
+}
+
+// NewInspectCommand produces a new *InspectCommand, initialized for use in a CLI application.
+func NewInspectCommand(ui cli.Ui) *InspectCommand {
+	c := &InspectCommand{ui: ui}
+	c.init()
+	return c
+}
+
+// InspectCommandFactory provides a cli.CommandFactory that will produce an appropriately-initiated *InspectCommand.
+func InspectCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return NewInspectCommand(ui), nil
+	}
+}
+
+// Help provides the full help output for the command.
+func (c *InspectCommand) Help() string {
+	helpText := `Usage: hcdiag inspect [options] <bundle>
+
+Summarizes a bundle written by 'hcdiag run', without extracting it. The bundle may be a .tar.gz, .tar.zst, or .zip
+file, the index of a bundle that was split into parts, or a directory, such as one that an archive was extracted into.
+The summary includes the op status counts for each product, any failed or timed out ops along with their errors, and
+the version, environment, and configuration of the run.
+`
+	return Usage(helpText, c.flags)
+}
+
+// Synopsis provides a brief description of the command, for inclusion in the application's primary --help.
+func (c *InspectCommand) Synopsis() string {
+	return "Summarize an existing hcdiag bundle"
+}
+
+// inspectOutput is the JSON rendering of an inspected bundle.
+type inspectOutput struct {
+	Bundle      string                        `json:"bundle"`
+	Version     version.Version               `json:"version"`
+	Start       time.Time                     `json:"started_at"`
+	End         time.Time                     `json:"ended_at"`
+	Duration    string                        `json:"duration"`
+	Interrupted bool                          `json:"interrupted"`
+	TimedOut    bool                          `json:"timed_out"`
+	Environment agent.Environment             `json:"environment"`
+	Config      agent.Config                  `json:"configuration"`
+	Statuses    map[string]map[op.Status]int  `json:"statuses"`
+	Problems    []bundle.ProductOp            `json:"problems"`
+	Ops         map[string][]agent.ManifestOp `json:"-"`
+}
+
+// Run executes the command.
+func (c *InspectCommand) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Error parsing flags: %s", err))
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.flags.NArg() != 1 {
+		c.ui.Error("Expected exactly one bundle to inspect")
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.format != formatText && c.format != formatJSON {
+		c.ui.Error(fmt.Sprintf("Unsupported format %q; expected %q or %q", c.format, formatText, formatJSON))
+		return FlagParseError
+	}
+
+	b, err := bundle.Open(c.flags.Arg(0))
+	if err != nil {
+		c.ui.Error(err.Error())
+		return BundleReadError
+	}
+
+	m := b.Manifest
+	out := inspectOutput{
+		Bundle:      b.Path,
+		Version:     m.Version,
+		Start:       m.Start,
+		End:         m.End,
+		Duration:    m.Duration,
+		Interrupted: m.Interrupted,
+		TimedOut:    m.TimedOut,
+		Environment: m.Environment,
+		Config:      m.Config,
+		Statuses:    b.StatusCounts(),
+		Problems:    b.OpsWithStatus(op.Fail, op.Timeout),
+		Ops:         m.Ops,
+	}
+
+	var rendered string
+	if c.format == formatJSON {
+		j, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			c.ui.Error(err.Error())
+			return OutputError
+		}
+		rendered = string(j)
+	} else {
+		var buf bytes.Buffer
+		if err := writeInspection(&buf, out); err != nil {
+			c.ui.Error(err.Error())
+			return OutputError
+		}
+		rendered = strings.TrimRight(buf.String(), "\n")
+	}
+	c.ui.Output(rendered)
+
+	return Success
+}
+
+// writeInspection renders out as human-readable text.
+func writeInspection(w io.Writer, out inspectOutput) error {
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	rows := [][]string{
+		{"bundle", out.Bundle},
+		{"version", out.Version.FullVersionNumber(true)},
+		{"started", out.Start.Format(time.RFC3339)},
+		{"ended", out.End.Format(time.RFC3339)},
+		{"duration", out.Duration},
+		{"command", out.Environment.Command},
+		{"username", out.Environment.Username},
+		{"hostname", out.Environment.Hostname},
+	}
+	switch {
+	case out.Interrupted:
+		rows = append(rows, []string{"note", "the run was interrupted; results are incomplete"})
+	case out.TimedOut:
+		rows = append(rows, []string{"note", "the run timed out; results are incomplete"})
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprint(t, formatReportLine(row...)); err != nil {
+			return err
+		}
+	}
+	if err := t.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprint(w, "\nConfiguration\n\n"); err != nil {
+		return err
+	}
+	if err := writeConfig(w, out.Config); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprint(w, "\nOps\n\n"); err != nil {
+		return err
+	}
+	if err := writeStatusTable(w, out.Ops); err != nil {
+		return err
+	}
+
+	if len(out.Problems) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprint(w, "\nFailed and timed out ops\n\n"); err != nil {
+		return err
+	}
+	t = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprint(t, formatReportLine("product", "op", "status", "error")); err != nil {
+		return err
+	}
+	for _, p := range out.Problems {
+		if _, err := fmt.Fprint(t, formatReportLine(p.Product, p.ID, string(p.Status), p.Error)); err != nil {
+			return err
+		}
+	}
+	return t.Flush()
+}
+
+// writeConfig renders the parts of an agent.Config that describe what a run gathered.
+func writeConfig(w io.Writer, cfg agent.Config) error {
+	products := []string{"host"}
+	for _, name := range cfg.Products {
+		products = append(products, string(name))
+	}
+	sort.Strings(products)
+
+	timeout, maxConcurrency := "none", "none"
+	if 0 < cfg.Timeout {
+		timeout = cfg.Timeout.String()
+	}
+	if 0 < cfg.MaxConcurrency {
+		maxConcurrency = fmt.Sprint(cfg.MaxConcurrency)
+	}
+
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	rows := [][]string{
+		{"products", strings.Join(products, ", ")},
+		{"os", cfg.OS},
+		{"since", cfg.Since.Format(time.RFC3339)},
+		{"destination", cfg.Destination},
+		{"timeout", timeout},
+		{"max concurrency", maxConcurrency},
+		{"debug duration", cfg.DebugDuration.String()},
+		{"debug interval", cfg.DebugInterval.String()},
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprint(t, formatReportLine(row...)); err != nil {
+			return err
+		}
+	}
+	return t.Flush()
+}
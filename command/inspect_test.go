@@ -0,0 +1,60 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/op"
+)
+
+func TestInspectCommand_Run(t *testing.T) {
+	t.Run("text", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewInspectCommand(ui).Run([]string{"testdata/bundles/before"})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+
+		out := ui.OutputWriter.String()
+		assert.Contains(t, out, "hcdiag v0.6.0")
+		assert.Contains(t, out, "node1")
+		assert.Contains(t, out, "consul, host")
+		assert.Regexp(t, `consul\s+1\s+1\s+0\s+0\s+0\s+0\s+2`, out)
+		assert.Regexp(t, `consul\s+GET /v1/agent/self\s+fail\s+403 Forbidden`, out)
+		assert.Regexp(t, `host\s+uname -v\s+timeout\s+context deadline exceeded`, out)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewInspectCommand(ui).Run([]string{"-format=json", "testdata/bundles/before"})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+
+		var out inspectOutput
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &out))
+		assert.Equal(t, "0.6.0", out.Version.Version)
+		assert.Equal(t, 1, out.Statuses["consul"][op.Fail])
+		require.Len(t, out.Problems, 2)
+		assert.Equal(t, "GET /v1/agent/self", out.Problems[0].ID)
+	})
+
+	testCases := []struct {
+		name string
+		args []string
+		rc   int
+	}{
+		{name: "no bundle", args: []string{}, rc: FlagParseError},
+		{name: "unsupported format", args: []string{"-format=xml", "testdata/bundles/before"}, rc: FlagParseError},
+		{name: "missing bundle", args: []string{"testdata/bundles/missing.tar.gz"}, rc: BundleReadError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			assert.Equal(t, tc.rc, NewInspectCommand(ui).Run(tc.args))
+		})
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/hcdiag/util"
+)
+
+var _ cli.Command = &JoinCommand{}
+
+type JoinCommand struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	// dest is the path to write the reassembled bundle to
+	dest string
+}
+
+func (c *JoinCommand) init() {
+	const (
+		destUsageText = "Path to write the reassembled bundle to. Defaults to the index's path, without the '.parts.json' extension"
+	)
+
+	c.flags = flag.NewFlagSet("join", flag.ContinueOnError)
+	c.flags.StringVar(&c.dest, "dest", "", destUsageText)
+	c.flags.SetOutput(io.Discard)
+}
+
+// NewJoinCommand produces a new *JoinCommand, initialized for use in a CLI application.
+func NewJoinCommand(ui cli.Ui) *JoinCommand {
+	c := &JoinCommand{ui: ui}
+	c.init()
+	return c
+}
+
+// JoinCommandFactory provides a cli.CommandFactory that will produce an appropriately-initiated *JoinCommand.
+func JoinCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return NewJoinCommand(ui), nil
+	}
+}
+
+// Help provides the full help output for the command.
+func (c *JoinCommand) Help() string {
+	helpText := `Usage: hcdiag join [options] <index>
+
+Reassembles a bundle that 'hcdiag run -max-bundle-size' split into parts, from the index of its parts, such as
+'hcdiag2022-01-01T000000Z.tar.gz.parts.json'. Every part is checked against its size and checksum in the index first,
+and nothing is written if any of them is missing, truncated, or modified. The parts and the index are left as they
+are. There's no need to reassemble a bundle to inspect, verify, redact, or decrypt it, since those commands accept the
+index too.
+`
+	return Usage(helpText, c.flags)
+}
+
+// Synopsis provides a brief description of the command, for inclusion in the application's primary --help.
+func (c *JoinCommand) Synopsis() string {
+	return "Reassemble a bundle that was split into parts"
+}
+
+// Run executes the command.
+func (c *JoinCommand) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Error parsing flags: %s", err))
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.flags.NArg() != 1 {
+		c.ui.Error("Expected exactly one index of a bundle's parts to join")
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+
+	src := c.flags.Arg(0)
+	if !util.IsPartsIndex(src) {
+		c.ui.Error(fmt.Sprintf("Expected the index of a bundle's parts, whose name ends in %q", util.PartsIndexExt))
+		return FlagParseError
+	}
+	dest := c.dest
+	if dest == "" {
+		dest = strings.TrimSuffix(src, util.PartsIndexExt)
+	}
+
+	if err := util.JoinParts(src, dest); err != nil {
+		c.ui.Error(fmt.Sprintf("Unable to join the parts in %s: %s", src, err))
+		if errors.Is(err, util.ErrPartMismatch) {
+			c.ui.Error("A part may have been truncated or modified in transfer; try copying it again")
+		}
+		return BundleReadError
+	}
+	c.ui.Output(fmt.Sprintf("Wrote reassembled bundle to %s", filepath.Clean(dest)))
+	return Success
+}
@@ -0,0 +1,76 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/bundle"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+func TestJoinCommand_Run(t *testing.T) {
+	// Split the bundle the same way that run does.
+	destination := t.TempDir()
+	src, err := compressOutputDir("testdata/bundles/before", "hcdiag2022-01-01T000000Z", destination, util.FormatTarGz, util.DefaultCompressionLevel, nil, 256)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(destination, "hcdiag2022-01-01T000000Z.tar.gz"+util.PartsIndexExt), src)
+	index, err := util.ReadPartsIndex(src)
+	require.NoError(t, err)
+	assert.Greater(t, len(index.Parts), 1)
+
+	t.Run("inspect", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewInspectCommand(ui).Run([]string{src})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "0.6.0")
+	})
+
+	t.Run("join", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewJoinCommand(ui).Run([]string{src})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+
+		dest := filepath.Join(destination, "hcdiag2022-01-01T000000Z.tar.gz")
+		assert.Contains(t, ui.OutputWriter.String(), dest)
+		b, err := bundle.Open(dest)
+		require.NoError(t, err)
+		assert.Equal(t, "0.6.0", b.Manifest.Version.Version)
+	})
+
+	t.Run("modified part", func(t *testing.T) {
+		dir := t.TempDir()
+		modified, err := compressOutputDir("testdata/bundles/before", "hcdiag2022-01-01T000000Z", dir, util.FormatTarGz, util.DefaultCompressionLevel, nil, 256)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, index.Parts[0].Name), []byte("modified"), 0644))
+
+		ui := cli.NewMockUi()
+		assert.Equal(t, BundleReadError, NewJoinCommand(ui).Run([]string{modified}))
+		assert.Contains(t, ui.ErrorWriter.String(), "modified in transfer")
+		assert.NoFileExists(t, filepath.Join(dir, "hcdiag2022-01-01T000000Z.tar.gz"))
+	})
+
+	testCases := []struct {
+		name string
+		args []string
+		rc   int
+	}{
+		{name: "no index", args: []string{}, rc: FlagParseError},
+		{name: "not an index", args: []string{"testdata/bundles/before"}, rc: FlagParseError},
+		{name: "missing index", args: []string{filepath.Join(t.TempDir(), "missing.tar.gz.parts.json")}, rc: BundleReadError},
+		{name: "destination exists", args: []string{"-dest", src, src}, rc: BundleReadError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			assert.Equal(t, tc.rc, NewJoinCommand(ui).Run(tc.args))
+		})
+	}
+}
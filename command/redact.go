@@ -0,0 +1,198 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/hcdiag/bundle"
+	"github.com/hashicorp/hcdiag/hcl"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+var _ cli.Command = &RedactCommand{}
+
+type RedactCommand struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	// config is the path to an HCL file of redact blocks
+	config string
+	// dest is the path to write the redacted bundle to
+	dest string
+	// format is the output format, either text or json
+	format string
+}
+
+func (c *RedactCommand) init() {
+	const (
+		configUsageText = "Path to an HCL file of redact blocks to apply to the bundle (required)"
+		destUsageText   = "Path to write the redacted bundle to. Defaults to the bundle's path, with '-redacted' before its extension, such as '.tar.gz'"
+		formatUsageText = "Output format, either 'text' or 'json'"
+	)
+
+	c.flags = flag.NewFlagSet("redact", flag.ContinueOnError)
+	c.flags.StringVar(&c.config, "config", "", configUsageText)
+	c.flags.StringVar(&c.dest, "dest", "", destUsageText)
+	c.flags.StringVar(&c.format, "format", formatText, formatUsageText)
+	c.flags.SetOutput(io.Discard)
+}
+
+// NewRedactCommand produces a new *RedactCommand, initialized for use in a CLI application.
+func NewRedactCommand(ui cli.Ui) *RedactCommand {
+	c := &RedactCommand{ui: ui}
+	c.init()
+	return c
+}
+
+// RedactCommandFactory provides a cli.CommandFactory that will produce an appropriately-initiated *RedactCommand.
+func RedactCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return NewRedactCommand(ui), nil
+	}
+}
+
+// Help provides the full help output for the command.
+func (c *RedactCommand) Help() string {
+	helpText := `Usage: hcdiag redact [options] -config <redactions file> <bundle>
+
+Applies redactions to a bundle written by 'hcdiag run', for when it turns out to include something sensitive. The
+redactions file holds 'redact' blocks, in the same format as those in a configuration file. A redacted copy of the
+bundle is written, in the same archive format and with the same layout, and the redactions that were applied are
+recorded as 'post_redactions' in its manifest.json. The original bundle is left as it is. Files are only written to
+disk once they have been redacted, in a private temporary directory that is removed afterwards.
+`
+	return Usage(helpText, c.flags)
+}
+
+// Synopsis provides a brief description of the command, for inclusion in the application's primary --help.
+func (c *RedactCommand) Synopsis() string {
+	return "Apply redactions to an existing hcdiag bundle"
+}
+
+// redactOutput is the JSON rendering of a redacted bundle.
+type redactOutput struct {
+	Bundle string `json:"bundle"`
+	Dest   string `json:"dest"`
+	bundle.Redaction
+}
+
+// Run executes the command.
+func (c *RedactCommand) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Error parsing flags: %s", err))
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.flags.NArg() != 1 {
+		c.ui.Error("Expected exactly one bundle to redact")
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.config == "" {
+		c.ui.Error("A file of redactions to apply is required; use -config")
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.format != formatText && c.format != formatJSON {
+		c.ui.Error(fmt.Sprintf("Unsupported format %q; expected %q or %q", c.format, formatText, formatJSON))
+		return FlagParseError
+	}
+
+	src := c.flags.Arg(0)
+	format, err := util.DetectArchiveFormat(src)
+	if err != nil {
+		c.ui.Error(fmt.Sprintf("Unable to read bundle %s: %s", src, err))
+		return BundleReadError
+	} else if format == util.FormatDir {
+		c.ui.Error(fmt.Sprintf("Expected an archived bundle to redact, but %s is a directory", src))
+		return BundleReadError
+	}
+	dest := c.dest
+	if dest == "" {
+		dest = redactedName(src, format)
+	}
+
+	redactions, err := hcl.ParseRedactions(c.config)
+	if err != nil {
+		c.ui.Error(fmt.Sprintf("Failed to load redactions from %s: %s", c.config, err))
+		return ConfigError
+	}
+	if len(redactions) == 0 {
+		c.ui.Error(fmt.Sprintf("No redact blocks found in %s", c.config))
+		return ConfigError
+	}
+
+	record, err := bundle.Redact(src, dest, redactions)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return BundleWriteError
+	}
+
+	out := redactOutput{Bundle: src, Dest: dest, Redaction: record}
+	var rendered string
+	if c.format == formatJSON {
+		j, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			c.ui.Error(err.Error())
+			return OutputError
+		}
+		rendered = string(j)
+	} else {
+		var buf bytes.Buffer
+		if err := writeRedaction(&buf, out); err != nil {
+			c.ui.Error(err.Error())
+			return OutputError
+		}
+		rendered = strings.TrimRight(buf.String(), "\n")
+	}
+	c.ui.Output(rendered)
+
+	return Success
+}
+
+// redactedName returns the default destination for a redacted copy of the bundle at path, which is in format. The
+// redacted copy of a bundle that was split into parts is written whole.
+func redactedName(path string, format util.ArchiveFormat) string {
+	path = strings.TrimSuffix(path, util.PartsIndexExt)
+	for _, ext := range []string{format.Ext(), ".tgz"} {
+		if strings.HasSuffix(path, ext) {
+			return strings.TrimSuffix(path, ext) + "-redacted" + ext
+		}
+	}
+	return path + "-redacted" + format.Ext()
+}
+
+// writeRedaction renders out as human-readable text.
+func writeRedaction(w io.Writer, out redactOutput) error {
+	if _, err := fmt.Fprintf(w, "Wrote redacted copy of %s to %s\n\n", out.Bundle, out.Dest); err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(out.Redactions))
+	for id := range out.Redactions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprint(t, formatReportLine("redaction", "replacements")); err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, err := fmt.Fprint(t, formatReportLine(id, fmt.Sprint(out.Redactions[id]))); err != nil {
+			return err
+		}
+	}
+	return t.Flush()
+}
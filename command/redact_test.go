@@ -0,0 +1,85 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/bundle"
+	"github.com/hashicorp/hcdiag/redact"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+const testRedactions = "../tests/resources/config/redactions.hcl"
+
+func TestRedactCommand_Run(t *testing.T) {
+	src := filepath.Join(t.TempDir(), "hcdiag-2022-01-01T000000Z.tar.gz")
+	require.NoError(t, util.TarGz("testdata/bundles/before", src, "hcdiag-2022-01-01T000000Z"))
+
+	t.Run("text", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewRedactCommand(ui).Run([]string{"-config", testRedactions, src})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+
+		dest := filepath.Join(filepath.Dir(src), "hcdiag-2022-01-01T000000Z-redacted.tar.gz")
+		out := ui.OutputWriter.String()
+		assert.Contains(t, out, dest)
+		assert.Regexp(t, `hostname\s+1`, out)
+		assert.Regexp(t, `versions\s+1`, out)
+
+		b, err := bundle.Open(dest)
+		require.NoError(t, err)
+		assert.Equal(t, "<HOSTNAME>", b.Manifest.Environment.Hostname)
+		assert.Equal(t, map[string]any{"version": redact.DefaultReplace}, b.Results["consul"]["consul version"].(map[string]any)["result"])
+		require.Len(t, b.Manifest.PostRedactions, 1)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "redacted.tar.gz")
+		ui := cli.NewMockUi()
+		rc := NewRedactCommand(ui).Run([]string{"-config", testRedactions, "-dest", dest, "-format=json", src})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+
+		var out redactOutput
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &out))
+		assert.Equal(t, dest, out.Dest)
+		assert.Equal(t, map[string]int{"hostname": 1, "versions": 1}, out.Redactions)
+		assert.FileExists(t, dest)
+	})
+
+	testCases := []struct {
+		name string
+		args []string
+		rc   int
+	}{
+		{name: "no bundle", args: []string{"-config", testRedactions}, rc: FlagParseError},
+		{name: "no config", args: []string{src}, rc: FlagParseError},
+		{name: "unsupported format", args: []string{"-config", testRedactions, "-format=xml", src}, rc: FlagParseError},
+		{name: "missing bundle", args: []string{"-config", testRedactions, "testdata/bundles/missing.tar.gz"}, rc: BundleReadError},
+		{name: "directory", args: []string{"-config", testRedactions, "testdata/bundles/before"}, rc: BundleReadError},
+		{name: "invalid config", args: []string{"-config", "../tests/resources/config/invalid.hcl", src}, rc: ConfigError},
+		{name: "destination exists", args: []string{"-config", testRedactions, "-dest", src, src}, rc: BundleWriteError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			assert.Equal(t, tc.rc, NewRedactCommand(ui).Run(tc.args))
+		})
+	}
+}
+
+func TestRedactedName(t *testing.T) {
+	assert.Equal(t, "out/hcdiag-redacted.tar.gz", redactedName("out/hcdiag.tar.gz", util.FormatTarGz))
+	assert.Equal(t, "hcdiag-redacted.tgz", redactedName("hcdiag.tgz", util.FormatTarGz))
+	assert.Equal(t, "hcdiag-redacted.tar.gz", redactedName("hcdiag", util.FormatTarGz))
+	assert.Equal(t, "out/hcdiag-redacted.tar.zst", redactedName("out/hcdiag.tar.zst", util.FormatTarZst))
+	assert.Equal(t, "hcdiag-redacted.zip", redactedName("hcdiag", util.FormatZip))
+	assert.Equal(t, "hcdiag-redacted.tar.zst", redactedName("hcdiag.tar.zst.parts.json", util.FormatTarZst))
+}
@@ -22,6 +22,9 @@ const (
 
 	// SetupError is returned when errors are encountered while setting up prerequisites for an Agent to run; e.g. logfiles, temporary directories
 	SetupError
+
+	// UploadError indicates that the bundle was written, but could not be uploaded to the configured object store.
+	UploadError
 )
 
 // The following error group is intended for issues with the Agent.
@@ -31,4 +34,21 @@ const (
 
 	// AgentExecutionError is returned when the agent returns an error to the calling command.
 	AgentExecutionError
+
+	// AgentInterruptedError is returned when a run is interrupted by a signal. A partial bundle may still have been
+	// written, unless a second signal forced hcdiag to exit immediately.
+	AgentInterruptedError
+)
+
+// The following error group is intended for issues with existing bundles, such as those passed to inspect.
+const (
+	// BundleReadError is returned when a bundle cannot be opened or its contents cannot be decoded.
+	BundleReadError int = iota + 48
+
+	// BundleWriteError is returned when a new bundle cannot be written from an existing one, such as by redact.
+	BundleWriteError
+
+	// BundleVerifyError is returned when a bundle's files don't match the checksums in its manifest, or its signature
+	// is invalid or missing.
+	BundleVerifyError
 )
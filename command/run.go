@@ -4,19 +4,25 @@
 package command
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
 	"os/user"
 	"path"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"text/tabwriter"
 	"time"
 
+	"filippo.io/age"
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/hcdiag/op"
 	"github.com/mitchellh/cli"
@@ -24,6 +30,7 @@ import (
 	"github.com/hashicorp/hcdiag/agent"
 	"github.com/hashicorp/hcdiag/hcl"
 	"github.com/hashicorp/hcdiag/product"
+	"github.com/hashicorp/hcdiag/upload"
 	"github.com/hashicorp/hcdiag/util"
 )
 
@@ -41,10 +48,12 @@ type RunCommand struct {
 
 	// Products
 	autoDetectProducts bool
+	boundary           bool
 	consul             bool
 	nomad              bool
 	tfe                bool
 	vault              bool
+	products           string
 
 	// since provides a time range for ops to work from
 	since time.Duration
@@ -63,22 +72,60 @@ type RunCommand struct {
 
 	// debugInterval param for product debug bundles
 	debugInterval time.Duration
+
+	// timeout bounds the whole run
+	timeout time.Duration
+
+	// maxConcurrency limits how many runners may execute at once
+	maxConcurrency int
+
+	// pseudonymMap is where to write the value behind each pseudonym, outside the bundle
+	pseudonymMap string
+
+	// recipient is an age public key, or a file of them, to encrypt the bundle to
+	recipient string
+
+	// signingKey is the path to an ed25519 private key to sign the manifest with
+	signingKey string
+
+	// archiveFormat is the format to write the bundle in
+	archiveFormat string
+
+	// compressionLevel is how hard to compress the bundle, where 0 is the format's default
+	compressionLevel int
+
+	// maxBundleSize is the largest part to split the bundle into, such as '500MB'
+	maxBundleSize string
+
+	// htmlReport writes report.html into the bundle
+	htmlReport bool
 }
 
 func (c *RunCommand) init() {
 	const (
-		consulUsageText       = "Run Consul diagnostics"
-		nomadUsageText        = "Run Nomad diagnostics"
-		terraformEntUsageText = "Run Terraform Enterprise diagnostics"
-		vaultUsageText        = "Run Vault diagnostics"
-		autodetectUsageText   = "Auto-Detect installed products; any provided product flags will override this setting"
-		dryrunUsageText       = "Displays all runners that would be executed during a normal run without actually executing them."
-		includeSinceUsageText = "Alias for -since, will be overridden if -since is also provided, usage examples: '72h', '25m', '45s', '120h1m90s'"
-		sinceUsageText        = "Collect information within this time. Takes a 'go-formatted' duration, usage examples: '72h', '25m', '45s', '120h1m90s'"
-		osUsageText           = "Override operating system detection"
-		destinationUsageText  = "Path to the directory the bundle should be written in"
-		destUsageText         = "Shorthand for -destination"
-		configUsageText       = "Path to HCL configuration file"
+		boundaryUsageText       = "Run Boundary diagnostics"
+		consulUsageText         = "Run Consul diagnostics"
+		nomadUsageText          = "Run Nomad diagnostics"
+		terraformEntUsageText   = "Run Terraform Enterprise diagnostics"
+		vaultUsageText          = "Run Vault diagnostics"
+		productsUsageText       = "Comma-separated names of products to run diagnostics for, in addition to those enabled by product flags, including products defined in HCL; e.g. 'vault,acme-gateway'"
+		autodetectUsageText     = "Auto-Detect installed products, and enable every product defined in HCL; any provided product flags will override this setting"
+		dryrunUsageText         = "Displays all runners that would be executed during a normal run without actually executing them."
+		includeSinceUsageText   = "Alias for -since, will be overridden if -since is also provided, usage examples: '72h', '25m', '45s', '120h1m90s'"
+		sinceUsageText          = "Collect information within this time. Takes a 'go-formatted' duration, usage examples: '72h', '25m', '45s', '120h1m90s'"
+		osUsageText             = "Override operating system detection"
+		destinationUsageText    = "Path to the directory the bundle should be written in"
+		destUsageText           = "Shorthand for -destination"
+		configUsageText         = "Path to HCL configuration file"
+		maxConcurrencyUsageText = "Maximum number of runners that may execute at once, across all products. Overrides max_concurrency in the HCL agent block. Defaults to no limit."
+		pseudonymMapUsageText   = "Path to write a JSON file mapping each pseudonym in the bundle back to the value it replaced. It must be outside the bundle; keep it private, and don't send it with the bundle. Defaults to not keeping the mapping."
+		recipientUsageText      = "An age public key, such as 'age1...', or the path to a file of them, one per line, to encrypt the bundle to. The bundle is encrypted as it's written, and is named with a '.age' extension. Use 'hcdiag decrypt' with the matching private key to decrypt it. Defaults to not encrypting the bundle."
+		signingKeyUsageText     = "Path to a PEM-encoded ed25519 private key to sign the bundle's manifest with, such as one written by 'openssl genpkey -algorithm ed25519'. The signature is written to manifest.json.sig, and can be checked with 'hcdiag verify'. Overrides signing_key in the HCL agent block. Defaults to not signing the bundle."
+		archiveFormatUsageText  = "Format to write the bundle in: 'tar.gz', 'tar.zst', 'zip', or 'dir', which writes an uncompressed directory for inspecting locally. 'tar.zst' compresses much faster than 'tar.gz'. Defaults to 'tar.gz'."
+		compressionUsageText    = "Compression level of the bundle: 1-9 for 'tar.gz' and 'zip', or 1-22 for 'tar.zst'. Lower levels are faster, and higher levels are smaller. Defaults to the format's default level."
+		maxBundleSizeUsageText  = "Split the bundle into numbered parts of at most this size, such as '500MB' or '2GiB', along with an index of the parts, named after the bundle with a '.parts.json' extension. The bundle is split as it's written, so the whole bundle is never written to disk. Give the index to 'hcdiag inspect', 'verify', 'redact', or 'decrypt', or use 'hcdiag join' to reassemble the bundle. Defaults to not splitting the bundle."
+		htmlReportUsageText     = "Write report.html into the bundle, a self-contained HTML page with each product's ops, their statuses, durations, and errors, any findings, a summary of the host, and each op's redacted result. It can be opened in a browser straight from the extracted bundle."
+		timeoutUsageText        = "Maximum duration of the whole run. When it is reached, unfinished runners are recorded as timed out and a bundle is written with whatever has been gathered. Takes a 'go-formatted' duration, usage examples: '5m', '90s'. Defaults to no limit."
 
 		// Deprecated options
 		includesUsageText      = "DEPRECATED: Files or directories to include (comma-separated, file-*-globbing available if 'wrapped-*-in-single-quotes'); e.g. '/var/log/consul-*,/var/log/nomad-*'. NOTE: This option will be removed in an upcoming version of hcdiag. Please use HCL copy blocks instead."
@@ -91,10 +138,12 @@ func (c *RunCommand) init() {
 	c.flags = flag.NewFlagSet("run", flag.ContinueOnError)
 
 	c.flags.BoolVar(&c.dryrun, "dryrun", false, dryrunUsageText)
+	c.flags.BoolVar(&c.boundary, "boundary", false, boundaryUsageText)
 	c.flags.BoolVar(&c.consul, "consul", false, consulUsageText)
 	c.flags.BoolVar(&c.nomad, "nomad", false, nomadUsageText)
 	c.flags.BoolVar(&c.tfe, "terraform-ent", false, terraformEntUsageText)
 	c.flags.BoolVar(&c.vault, "vault", false, vaultUsageText)
+	c.flags.StringVar(&c.products, "products", "", productsUsageText)
 	c.flags.BoolVar(&c.autoDetectProducts, "autodetect", true, autodetectUsageText)
 	c.flags.DurationVar(&c.includeSince, "include-since", seventyTwoHours, includeSinceUsageText)
 	c.flags.DurationVar(&c.since, "since", seventyTwoHours, sinceUsageText)
@@ -104,6 +153,15 @@ func (c *RunCommand) init() {
 	c.flags.StringVar(&c.destination, "destination", ".", destinationUsageText)
 	c.flags.StringVar(&c.destination, "dest", ".", destUsageText)
 	c.flags.StringVar(&c.config, "config", "", configUsageText)
+	c.flags.DurationVar(&c.timeout, "timeout", 0, timeoutUsageText)
+	c.flags.IntVar(&c.maxConcurrency, "max-concurrency", 0, maxConcurrencyUsageText)
+	c.flags.StringVar(&c.pseudonymMap, "pseudonym-map", "", pseudonymMapUsageText)
+	c.flags.StringVar(&c.recipient, "recipient", "", recipientUsageText)
+	c.flags.StringVar(&c.signingKey, "signing-key", "", signingKeyUsageText)
+	c.flags.StringVar(&c.archiveFormat, "archive-format", string(util.FormatTarGz), archiveFormatUsageText)
+	c.flags.IntVar(&c.compressionLevel, "compression-level", util.DefaultCompressionLevel, compressionUsageText)
+	c.flags.StringVar(&c.maxBundleSize, "max-bundle-size", "", maxBundleSizeUsageText)
+	c.flags.BoolVar(&c.htmlReport, "html-report", false, htmlReportUsageText)
 
 	// Ensure f.Destination points to some kind of directory by its notation
 	// FIXME(mkcp): trailing slashes should be trimmed in path.Dir... why does a double slash end in a slash?
@@ -145,29 +203,38 @@ func (c *RunCommand) Synopsis() string {
 
 // Run executes the command.
 func (c *RunCommand) Run(args []string) int {
-	// a randomly-named temporary directory
-	var tmp string
+	// a randomly-named temporary directory, which holds the bundle's directory, tmp, and a private directory, rawDir, for
+	// outputs that are held unredacted until they're redacted into the bundle. Only tmp is archived.
+	var root, tmp, rawDir string
 	var cleanup func(hclog.Logger)
 	var err error
 
 	// Default teeWriter just prints to stdout
 	var teeWriter io.Writer = os.Stdout
+	var logfile *logWriter
 	l := configureLogging("hcdiag", teeWriter)
 
 	// Create a temporary directory and logfile
 	if !c.dryrun {
-		if tmp, cleanup, err = util.CreateTemp(c.destination); err != nil {
+		if root, cleanup, err = util.CreateTemp(c.destination); err != nil {
 			fmt.Println("Failed to create temp directory. error:", err)
 			return SetupError
 		}
 		// remove the temporary directory and its contents
 		defer cleanup(l)
 
+		tmp, rawDir = filepath.Join(root, "bundle"), filepath.Join(root, "raw")
+		if err = errors.Join(os.Mkdir(tmp, 0755), os.Mkdir(rawDir, 0700)); err != nil {
+			fmt.Println("Failed to create temp directory. error:", err)
+			return SetupError
+		}
+
 		// Set up stdout/logfile output
-		logfile, err := os.Create(filepath.Join(tmp, "hcdiag.log"))
+		f, err := os.Create(filepath.Join(tmp, "hcdiag.log"))
 		if err != nil {
 			fmt.Println(err)
 		} else {
+			logfile = &logWriter{file: f}
 			defer func() {
 				if closeErr := logfile.Close(); closeErr != nil {
 					l.Warn("failed to close logfile", "error", closeErr)
@@ -193,6 +260,31 @@ func (c *RunCommand) Run(args []string) int {
 		return FlagParseError
 	}
 
+	// Parse the recipients up front, so that a bad key is reported before anything is gathered.
+	var recipients []age.Recipient
+	if c.recipient != "" {
+		recipients, err = util.ParseRecipients(c.recipient)
+		if err != nil {
+			c.ui.Warn(err.Error())
+			return FlagParseError
+		}
+	}
+	// The archive format and the maximum size are checked up front too, for the same reason.
+	format, err := c.parseArchiveFormat(len(recipients) != 0)
+	if err != nil {
+		c.ui.Warn(err.Error())
+		return FlagParseError
+	}
+	maxSize, err := c.parseMaxBundleSize(format)
+	if err != nil {
+		c.ui.Warn(err.Error())
+		return FlagParseError
+	}
+	if err := validateMaxConcurrency("-max-concurrency", c.maxConcurrency); err != nil {
+		c.ui.Warn(err.Error())
+		return FlagParseError
+	}
+
 	// Build agent configuration from flags, HCL, and system time
 	var config agent.Config
 	// Parse and store HCL struct on agent.
@@ -203,11 +295,26 @@ func (c *RunCommand) Run(args []string) int {
 			return ConfigError
 		}
 		l.Debug("HCL config is", "hcl", hclCfg)
+		if hclCfg.Agent != nil {
+			if err := validateMaxConcurrency("max_concurrency", hclCfg.Agent.MaxConcurrency); err != nil {
+				l.Error("Invalid configuration", "config", c.config, "error", err)
+				return ConfigError
+			}
+		}
 		config.HCL = hclCfg
 	}
+	// The uploader is set up before anything is gathered too, so that missing credentials are reported right away.
+	var uploader *upload.Uploader
+	if config.HCL.Agent != nil && config.HCL.Agent.Upload != nil && !c.dryrun {
+		if uploader, err = newUploader(config.HCL.Agent.Upload, format); err != nil {
+			l.Error("Invalid upload configuration", "config", c.config, "error", err)
+			return ConfigError
+		}
+	}
 
 	// add tempdir to the CLI-generated Agent.Config
 	config.TmpDir = tmp
+	config.RawDir = rawDir
 
 	// Assign flag values to our agent.Config
 	cfg := c.mergeAgentConfig(config)
@@ -233,16 +340,25 @@ func (c *RunCommand) Run(args []string) int {
 	}
 	cfg.Environment = environment
 
+	// The agent's context is canceled on SIGINT/SIGTERM, so that in-flight runners wind down and we still write a
+	// partial bundle instead of losing everything gathered so far.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopSignals := notifySignals(l, cancel, rawDir)
+	defer stopSignals()
+
 	// Create agent
-	a, err := agent.NewAgent(cfg, l)
+	a, err := agent.NewAgentWithContext(ctx, cfg, l)
 	if err != nil {
 		l.Error("problem creating agent", err)
 		return AgentSetupError
 	}
 
 	// Run the agent
+	// An interrupted run still writes the partial bundle; its errors are already logged, and it exits with
+	// AgentInterruptedError once the bundle is archived.
 	errs := a.Run()
-	if 0 < len(errs) {
+	if 0 < len(errs) && !a.Interrupted {
 		return AgentExecutionError
 	}
 
@@ -254,37 +370,209 @@ func (c *RunCommand) Run(args []string) int {
 	// Include a timestamp based on agent start time
 	// specifically excluding colons ":" since they are anathema to some filesystems and programs.
 	ts := a.Start.UTC().Format("2006-01-02T150405Z")
-	err = compressOutputDir(tmp, "hcdiag"+ts, a.Config.Destination)
+	// hcdiag.log is part of the bundle, so it's closed before its checksum is recorded. Anything written afterwards,
+	// such as a warning about a signal, only goes to stdout.
+	if err = logfile.Close(); err != nil {
+		l.Warn("failed to close logfile", "error", err)
+	}
+	l.Info("Recording checksums of the bundle's files")
+	if err = a.WriteChecksums(); err != nil {
+		l.Warn("failed to record checksums; please review output files", "tmpdir", tmp, "err", err)
+		return OutputError
+	}
+	resultsDest, err := compressOutputDir(tmp, "hcdiag"+ts, a.Config.Destination, format, c.compressionLevel, recipients, maxSize)
 	if err != nil {
 		l.Warn("failed to compress output directory; please review output files", "tmpdir", tmp, "err", err)
 		return OutputError
 	}
 
-	absDest, _ := filepath.Abs(a.Config.Destination)
-	if err = writeSummary(teeWriter, filepath.Join(absDest, ("hcdiag"+ts+".tar.gz")), a.ManifestOps); err != nil {
+	absDest, _ := filepath.Abs(resultsDest)
+	var uploaded []upload.Object
+	var uploadErr error
+	switch {
+	case uploader == nil:
+	case a.Interrupted:
+		l.Warn("The run was interrupted, so the partial bundle was not uploaded", "bundle", absDest)
+	default:
+		uploaded, uploadErr = uploadBundle(ctx, l, uploader, absDest)
+	}
+
+	if err = writeSummary(teeWriter, absDest, uploaded, a.ManifestOps); err != nil {
 		l.Warn("failed to generate report summary; please review output files to ensure everything expected is present", "err", err)
 		return OutputError
 	}
+	if uploadErr != nil {
+		l.Error("Failed to upload the bundle; it has been kept, so that it can be copied by hand", "bundle", absDest, "error", uploadErr)
+		return UploadError
+	}
+
+	if a.Interrupted {
+		l.Warn("The run was interrupted; the bundle only includes results gathered before the interruption")
+		return AgentInterruptedError
+	}
+	if a.TimedOut {
+		l.Warn("The run reached its timeout; the bundle only includes results gathered before the deadline", "timeout", c.timeout)
+	}
 
 	return Success
 }
 
-func compressOutputDir(tmpDir, filename, destination string) error {
+// logWriter writes to the bundle's hcdiag.log until it's closed, and discards anything written after that, so that
+// hcdiag.log can't change once its checksum is recorded, while the output it's multiplexed with carries on.
+type logWriter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func (w *logWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return len(p), nil
+	}
+	return w.file.Write(p)
+}
+
+// Close closes hcdiag.log. Closing a nil or already closed logWriter has no effect.
+func (w *logWriter) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// notifySignals cancels the run when the first SIGINT or SIGTERM arrives, and exits immediately on a second one, after
+// removing rawDir. The returned function stops listening for signals.
+func notifySignals(l hclog.Logger, cancel context.CancelFunc, rawDir string) func() {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go watchSignals(l, sigs, done, cancel, removeAndExit(l, rawDir, os.Exit))
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
+
+// watchSignals calls cancel when the first signal is received from sigs, then calls exit if a second one arrives. It
+// returns once done is closed or exit has been called.
+func watchSignals(l hclog.Logger, sigs <-chan os.Signal, done <-chan struct{}, cancel context.CancelFunc, exit func(int)) {
+	select {
+	case sig := <-sigs:
+		l.Warn("Received signal, canceling remaining runners and writing a partial bundle; send it again to exit immediately", "signal", sig)
+		cancel()
+	case <-done:
+		return
+	}
+
+	select {
+	case sig := <-sigs:
+		// Deferred cleanup does not run on exit, so the bundle's directory is left behind for the user to inspect.
+		l.Warn("Received second signal, exiting without writing a bundle", "signal", sig)
+		exit(AgentInterruptedError)
+	case <-done:
+	}
+}
+
+// removeAndExit returns a function that removes dir, if it's set, before calling exit. Deferred cleanup does not run on
+// exit, so this keeps unredacted outputs from being left behind when hcdiag exits immediately.
+func removeAndExit(l hclog.Logger, dir string, exit func(int)) func(int) {
+	return func(code int) {
+		if dir != "" {
+			if err := os.RemoveAll(dir); err != nil {
+				l.Error("Failed to remove unredacted outputs", "dir", dir, "error", err)
+			}
+		}
+		exit(code)
+	}
+}
+
+// compressOutputDir archives and compresses tmpDir into a bundle in destination, in format at the given compression
+// level, and returns the bundle's path. If there are any recipients, the bundle is encrypted to them as it's written, so
+// the unencrypted bundle never reaches the destination. If maxSize is positive, the bundle is split into parts of at
+// most maxSize bytes as it's written, and the path to the index of the parts is returned.
+func compressOutputDir(tmpDir, filename, destination string, format util.ArchiveFormat, level int, recipients []age.Recipient, maxSize int64) (string, error) {
 	err := util.EnsureDirectory(destination)
 	if err != nil {
-		return fmt.Errorf("failed to ensure destination directory exists: dir=%s, error=%w", destination, err)
+		return "", fmt.Errorf("failed to ensure destination directory exists: dir=%s, error=%w", destination, err)
 	}
 
 	// Build bundle destination path from config
-	resultsFile := fmt.Sprintf("%s.tar.gz", filename)
+	resultsFile := filename + format.Ext()
+	if 0 < len(recipients) {
+		resultsFile += util.EncryptedExt
+	}
 	resultsDest := filepath.Join(destination, resultsFile)
 
 	// Archive and compress outputs
-	err = util.TarGz(tmpDir, resultsDest, filename)
+	if 0 < maxSize {
+		return util.ArchiveSplit(tmpDir, resultsDest, filename, format, level, recipients, maxSize)
+	}
+	if 0 < len(recipients) {
+		err = util.ArchiveEncrypted(tmpDir, resultsDest, filename, format, level, recipients)
+	} else {
+		err = util.Archive(tmpDir, resultsDest, filename, format, level)
+	}
 	if err != nil {
-		return err
+		return "", err
 	}
-	return nil
+	return resultsDest, nil
+}
+
+// newUploader returns an Uploader for an upload block. Directories can't be uploaded, so format must be an archive.
+func newUploader(cfg *hcl.Upload, format util.ArchiveFormat) (*upload.Uploader, error) {
+	if format == util.FormatDir {
+		return nil, errors.New("bundles can't be uploaded in the dir format; use an archive format instead")
+	}
+	uploadCfg, err := hcl.MapUpload(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return upload.NewUploader(uploadCfg)
+}
+
+// uploadBundle uploads the bundle at path, and returns the objects it was uploaded to. A bundle that was split into
+// parts is uploaded part by part, followed by the index of its parts, so that it can be joined after it's downloaded.
+func uploadBundle(ctx context.Context, l hclog.Logger, uploader *upload.Uploader, path string) ([]upload.Object, error) {
+	paths := []string{path}
+	if util.IsPartsIndex(path) {
+		index, err := util.ReadPartsIndex(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = make([]string, 0, len(index.Parts)+1)
+		for _, part := range index.Parts {
+			paths = append(paths, filepath.Join(filepath.Dir(path), part.Name))
+		}
+		paths = append(paths, path)
+	}
+
+	progress := func(name string, sent, total int64) {
+		percent := 100
+		if 0 < total {
+			percent = int(sent * 100 / total)
+		}
+		l.Info("Uploading", "file", name, "progress", fmt.Sprintf("%d%%", percent), "bytes", sent)
+	}
+	objects := make([]upload.Object, 0, len(paths))
+	for _, p := range paths {
+		l.Info("Uploading bundle", "file", p, "key", uploader.Key(p))
+		obj, err := uploader.Upload(ctx, p, progress)
+		if err != nil {
+			return objects, err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
 }
 
 // configureLogging takes a logger name, sets the default configuration, grabs the LOG_LEVEL from our ENV vars, and
@@ -327,30 +615,90 @@ func (c *RunCommand) parseFlags(args []string) error {
 	return c.flags.Parse(args)
 }
 
+// parseArchiveFormat returns the archive format from -archive-format, and checks that it accepts -compression-level,
+// and that it can be encrypted if the bundle is to be.
+func (c *RunCommand) parseArchiveFormat(encrypted bool) (util.ArchiveFormat, error) {
+	format, err := util.ParseArchiveFormat(c.archiveFormat)
+	if err != nil {
+		return "", err
+	}
+	if err := format.ValidateCompressionLevel(c.compressionLevel); err != nil {
+		return "", err
+	}
+	if encrypted && format == util.FormatDir {
+		return "", fmt.Errorf("archive format %s can't be encrypted, so it can't be used with -recipient", format)
+	}
+	return format, nil
+}
+
+// parseMaxBundleSize returns the maximum size of each part of the bundle from -max-bundle-size, or 0 if the bundle
+// isn't to be split.
+func (c *RunCommand) parseMaxBundleSize(format util.ArchiveFormat) (int64, error) {
+	if c.maxBundleSize == "" {
+		return 0, nil
+	}
+	maxSize, err := util.ParseSize(c.maxBundleSize)
+	if err != nil {
+		return 0, err
+	}
+	if maxSize <= 0 {
+		return 0, fmt.Errorf("-max-bundle-size must be positive, got %q", c.maxBundleSize)
+	}
+	if format == util.FormatDir {
+		return 0, fmt.Errorf("archive format %s can't be split into parts, so it can't be used with -max-bundle-size", format)
+	}
+	return maxSize, nil
+}
+
+// validateMaxConcurrency returns an error if n, the concurrency limit from the flag or setting named name, is negative.
+func validateMaxConcurrency(name string, n int) error {
+	if n < 0 {
+		return fmt.Errorf("%s must be zero, for no limit, or a positive number, got %d", name, n)
+	}
+	return nil
+}
+
 // mergeAgentConfig merges flags into the agent.Config, prioritizing flags over HCL config.
 func (c *RunCommand) mergeAgentConfig(config agent.Config) agent.Config {
 	config.OS = c.os
 	config.Dryrun = c.dryrun
 
-	config.Consul = c.consul
-	config.Nomad = c.nomad
-	config.TFE = c.tfe
-	config.Vault = c.vault
+	config.Products = nil
+	config.Autodetect = false
+	for _, p := range []struct {
+		name    product.Name
+		enabled bool
+	}{
+		{product.Boundary, c.boundary},
+		{product.Consul, c.consul},
+		{product.Nomad, c.nomad},
+		{product.TFE, c.tfe},
+		{product.Vault, c.vault},
+	} {
+		if p.enabled {
+			config.Products = append(config.Products, p.name)
+		}
+	}
+	// Products named in -products are checked against the registry by the agent, once products defined in HCL are in it.
+	for _, s := range strings.Split(c.products, ",") {
+		if name := product.Name(strings.TrimSpace(s)); name != "" && !config.Enabled(name) {
+			config.Products = append(config.Products, name)
+		}
+	}
 
 	// If any products have been set manually, then we do not care about product auto-detection
 	if c.autoDetectProducts && !checkProductsSet(config) {
-		config.Consul, _ = util.HostCommandExists("consul")
-		config.Nomad, _ = util.HostCommandExists("nomad")
-		config.TFE, _ = util.HostCommandExists("terraform")
-		config.Vault, _ = util.HostCommandExists("vault")
+		registry := config.Registry
+		if registry == nil {
+			registry = product.DefaultRegistry()
+		}
+		config.Products = registry.Detect()
+		config.Autodetect = true
 
 		if checkProductsSet(config) {
 			hclog.L().Info(
-				"Auto-detected products; if you wish to limit hcdiag, please use the appropriate -<product> flag and run again",
-				"consul", config.Consul,
-				"nomad", config.Nomad,
-				"terraform", config.TFE,
-				"vault", config.Vault,
+				"Auto-detected products; if you wish to limit hcdiag, please use the appropriate -<product> flag, or -products, and run again",
+				"products", config.Products,
 			)
 		}
 	}
@@ -362,12 +710,25 @@ func (c *RunCommand) mergeAgentConfig(config agent.Config) agent.Config {
 	config.DebugDuration = c.debugDuration
 	config.DebugInterval = c.debugInterval
 
+	// Run-level deadline
+	config.Timeout = c.timeout
+
+	// Runner concurrency limit
+	config.MaxConcurrency = c.maxConcurrency
+
+	// Where to keep the pseudonym mapping, if anywhere
+	config.PseudonymMap = c.pseudonymMap
+
+	// The key to sign the manifest with, if any
+	config.SigningKey = c.signingKey
+	config.HTMLReport = c.htmlReport
+
 	return config
 }
 
-// checkProductsSet returns true if any of the individual products are true in the provided config
+// checkProductsSet returns true if any products are enabled in the provided config
 func checkProductsSet(config agent.Config) bool {
-	return config.Consul || config.Nomad || config.TFE || config.Vault
+	return 0 < len(config.Products)
 }
 
 // pickSinceVsIncludeSince if Since is default and IncludeSince is NOT default, use IncludeSince
@@ -389,16 +750,24 @@ func setTime(cfg agent.Config, now time.Time, since time.Duration) agent.Config
 	return cfg
 }
 
-func writeSummary(writer io.Writer, resultsFile string, manifestOps map[string][]agent.ManifestOp) error {
+func writeSummary(writer io.Writer, resultsFile string, uploaded []upload.Object, manifestOps map[string][]agent.ManifestOp) error {
 	if resultsFile == "" {
 		resultsFile = "<unknown>"
 	}
 	helpText := fmt.Sprintf("The diagnostic run has completed. The results bundle can be found at %s.\n", resultsFile)
+	for _, obj := range uploaded {
+		helpText += fmt.Sprintf("It was uploaded to %s.\n", obj)
+	}
 	_, err := writer.Write([]byte(helpText))
 	if err != nil {
 		return err
 	}
 
+	return writeStatusTable(writer, manifestOps)
+}
+
+// writeStatusTable writes a table with a row per product, counting its ops by status.
+func writeStatusTable(writer io.Writer, manifestOps map[string][]agent.ManifestOp) error {
 	t := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
 	headers := []string{
 		"product",
@@ -411,7 +780,7 @@ func writeSummary(writer io.Writer, resultsFile string, manifestOps map[string][
 		"total",
 	}
 
-	_, err = fmt.Fprint(t, formatReportLine(headers...))
+	_, err := fmt.Fprint(t, formatReportLine(headers...))
 	if err != nil {
 		return err
 	}
@@ -5,15 +5,30 @@ package command
 
 import (
 	"bytes"
+	"context"
 	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/hcdiag/agent"
+	"github.com/hashicorp/hcdiag/bundle"
+	"github.com/hashicorp/hcdiag/hcl"
 	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/product"
+	"github.com/hashicorp/hcdiag/upload"
+	"github.com/hashicorp/hcdiag/util"
+	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var update = flag.Bool("update", false, "update golden files")
@@ -45,6 +60,7 @@ func Test_writeSummary(t *testing.T) {
 	testCases := []struct {
 		name        string
 		resultsFile string
+		uploaded    []upload.Object
 		manifestOps map[string][]agent.ManifestOp
 	}{
 		{
@@ -93,13 +109,27 @@ func Test_writeSummary(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "Test with Upload",
+			resultsFile: "/this/is/a/test/path/bundle.tar.gz",
+			uploaded: []upload.Object{
+				{Bucket: "support-bundles", Key: "vault/bundle.tar.gz"},
+			},
+			manifestOps: map[string][]agent.ManifestOp{
+				"vault": {
+					{
+						Status: op.Success,
+					},
+				},
+			},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			b := new(bytes.Buffer)
 
-			err := writeSummary(b, tc.resultsFile, tc.manifestOps)
+			err := writeSummary(b, tc.resultsFile, tc.uploaded, tc.manifestOps)
 
 			assert.NoError(t, err)
 			golden := filepath.Join("testdata/writeSummary", tc.name+".golden")
@@ -150,3 +180,279 @@ func Test_formatReportLine(t *testing.T) {
 		})
 	}
 }
+
+func Test_watchSignals(t *testing.T) {
+	t.Run("first signal cancels and second signal exits", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sigs := make(chan os.Signal)
+		done := make(chan struct{})
+		exitCode := make(chan int, 1)
+
+		go watchSignals(hclog.NewNullLogger(), sigs, done, cancel, func(code int) { exitCode <- code })
+
+		sigs <- syscall.SIGINT
+		<-ctx.Done()
+		assert.ErrorIs(t, ctx.Err(), context.Canceled)
+
+		sigs <- syscall.SIGTERM
+		select {
+		case code := <-exitCode:
+			assert.Equal(t, AgentInterruptedError, code)
+		case <-time.After(5 * time.Second):
+			t.Fatal("exit was not called after the second signal")
+		}
+	})
+
+	t.Run("done stops watching without canceling", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sigs := make(chan os.Signal)
+		done := make(chan struct{})
+		returned := make(chan struct{})
+
+		go func() {
+			watchSignals(hclog.NewNullLogger(), sigs, done, cancel, func(int) { t.Error("exit should not be called") })
+			close(returned)
+		}()
+
+		close(done)
+		<-returned
+		assert.NoError(t, ctx.Err())
+	})
+}
+
+func Test_logWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hcdiag.log")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w := &logWriter{file: f}
+
+	_, err = w.Write([]byte("before checksums\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	n, err := w.Write([]byte("after checksums\n"))
+	require.NoError(t, err, "writes after Close are discarded rather than failing the writers they're multiplexed with")
+	assert.Equal(t, len("after checksums\n"), n)
+	assert.NoError(t, w.Close())
+
+	bts, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "before checksums\n", string(bts))
+
+	var nilWriter *logWriter
+	assert.NoError(t, nilWriter.Close())
+}
+
+func Test_removeAndExit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "raw")
+	require.NoError(t, os.Mkdir(dir, 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "output.json"), []byte(`{"password": "hunter2"}`), 0600))
+
+	var exitCode int
+	removeAndExit(hclog.NewNullLogger(), dir, func(code int) { exitCode = code })(AgentInterruptedError)
+	assert.Equal(t, AgentInterruptedError, exitCode)
+	assert.NoDirExists(t, dir)
+}
+
+func Test_parseArchiveFormat(t *testing.T) {
+	testCases := []struct {
+		name      string
+		args      []string
+		encrypted bool
+		expect    util.ArchiveFormat
+		wantErr   bool
+	}{
+		{name: "default", expect: util.FormatTarGz},
+		{name: "tar.zst with a level", args: []string{"-archive-format", "tar.zst", "-compression-level", "19"}, expect: util.FormatTarZst},
+		{name: "encrypted zip", args: []string{"-archive-format", "zip"}, encrypted: true, expect: util.FormatZip},
+		{name: "dir", args: []string{"-archive-format", "dir"}, expect: util.FormatDir},
+		{name: "unknown format", args: []string{"-archive-format", "rar"}, wantErr: true},
+		{name: "level out of range", args: []string{"-compression-level", "19"}, wantErr: true},
+		{name: "compressed dir", args: []string{"-archive-format", "dir", "-compression-level", "1"}, wantErr: true},
+		{name: "encrypted dir", args: []string{"-archive-format", "dir"}, encrypted: true, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewRunCommand(cli.NewMockUi())
+			require.NoError(t, c.parseFlags(tc.args))
+			format, err := c.parseArchiveFormat(tc.encrypted)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, format)
+		})
+	}
+}
+
+func Test_compressOutputDir(t *testing.T) {
+	testCases := []struct {
+		format util.ArchiveFormat
+		expect string
+	}{
+		{format: util.FormatTarZst, expect: "hcdiag2022-01-01T000000Z.tar.zst"},
+		{format: util.FormatZip, expect: "hcdiag2022-01-01T000000Z.zip"},
+		{format: util.FormatDir, expect: "hcdiag2022-01-01T000000Z"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.format), func(t *testing.T) {
+			destination := t.TempDir()
+			dest, err := compressOutputDir("testdata/bundles/before", "hcdiag2022-01-01T000000Z", destination, tc.format, util.DefaultCompressionLevel, nil, 0)
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(destination, tc.expect), dest)
+
+			b, err := bundle.Open(dest)
+			require.NoError(t, err)
+			assert.Equal(t, "0.6.0", b.Manifest.Version.Version)
+		})
+	}
+}
+
+func Test_parseMaxBundleSize(t *testing.T) {
+	testCases := []struct {
+		name    string
+		args    []string
+		format  util.ArchiveFormat
+		expect  int64
+		wantErr bool
+	}{
+		{name: "default", format: util.FormatTarGz, expect: 0},
+		{name: "megabytes", args: []string{"-max-bundle-size", "500MB"}, format: util.FormatTarZst, expect: 500 * 1000 * 1000},
+		{name: "invalid", args: []string{"-max-bundle-size", "big"}, format: util.FormatTarGz, wantErr: true},
+		{name: "zero", args: []string{"-max-bundle-size", "0"}, format: util.FormatTarGz, wantErr: true},
+		{name: "dir", args: []string{"-max-bundle-size", "1GiB"}, format: util.FormatDir, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewRunCommand(cli.NewMockUi())
+			require.NoError(t, c.parseFlags(tc.args))
+			maxSize, err := c.parseMaxBundleSize(tc.format)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, maxSize)
+		})
+	}
+}
+
+func TestRunCommand_negativeMaxConcurrency(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "hcdiag.hcl")
+	require.NoError(t, os.WriteFile(config, []byte("agent {\n  max_concurrency = -1\n}\n"), 0600))
+
+	testCases := []struct {
+		name string
+		args []string
+		rc   int
+	}{
+		{name: "flag", args: []string{"-max-concurrency", "-1"}, rc: FlagParseError},
+		{name: "HCL", args: []string{"-config", config}, rc: ConfigError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewRunCommand(cli.NewMockUi())
+			assert.Equal(t, tc.rc, c.Run(append([]string{"-dest", t.TempDir()}, tc.args...)))
+		})
+	}
+}
+
+func Test_mergeAgentConfigProducts(t *testing.T) {
+	registry := product.NewRegistry()
+	require.NoError(t, registry.Register(product.Registration{
+		Name:   "acme",
+		Detect: func() bool { return true },
+		New: func(ctx context.Context, logger hclog.Logger, cfg product.Config) (*product.Product, error) {
+			return &product.Product{Name: "acme"}, nil
+		},
+	}))
+
+	testCases := []struct {
+		name             string
+		args             []string
+		expect           []product.Name
+		expectAutodetect bool
+	}{
+		{name: "flags", args: []string{"-vault", "-consul"}, expect: []product.Name{product.Consul, product.Vault}},
+		{
+			name:   "named products",
+			args:   []string{"-vault", "-products", "acme-gateway, vault,acme"},
+			expect: []product.Name{product.Vault, "acme-gateway", "acme"},
+		},
+		{name: "autodetected from the registry", expect: []product.Name{"acme"}, expectAutodetect: true},
+		{name: "autodetection disabled", args: []string{"-autodetect=false"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewRunCommand(cli.NewMockUi())
+			require.NoError(t, c.parseFlags(tc.args))
+			config := c.mergeAgentConfig(agent.Config{Registry: registry})
+			assert.Equal(t, tc.expect, config.Products)
+			assert.Equal(t, tc.expectAutodetect, config.Autodetect)
+		})
+	}
+}
+
+func Test_newUploader(t *testing.T) {
+	cfg := &hcl.Upload{Endpoint: "http://localhost:9000", Bucket: "support-bundles", AccessKeyID: "key", SecretAccessKey: "secret"}
+	_, err := newUploader(cfg, util.FormatTarGz)
+	assert.NoError(t, err)
+	_, err = newUploader(cfg, util.FormatDir)
+	assert.Error(t, err)
+	_, err = newUploader(&hcl.Upload{Endpoint: "http://localhost:9000", Bucket: "support-bundles", PartSize: "1KiB"}, util.FormatTarGz)
+	assert.Error(t, err)
+}
+
+func Test_uploadBundle(t *testing.T) {
+	// A stand-in for S3 that accepts every multipart upload, recording the keys of the completed ones.
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		q := r.URL.Query()
+		switch {
+		case q.Has("uploads"):
+			fmt.Fprint(w, "<InitiateMultipartUploadResult><UploadId>1</UploadId></InitiateMultipartUploadResult>")
+		case q.Has("partNumber"):
+			w.Header().Set("ETag", `"etag"`)
+		default:
+			keys = append(keys, strings.TrimPrefix(r.URL.Path, "/support-bundles/"))
+			fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"etag"</ETag></CompleteMultipartUploadResult>`)
+		}
+	}))
+	defer srv.Close()
+	uploader, err := newUploader(&hcl.Upload{Endpoint: srv.URL, Bucket: "support-bundles", Prefix: "vault", AccessKeyID: "key", SecretAccessKey: "secret"}, util.FormatTarGz)
+	require.NoError(t, err)
+
+	t.Run("bundle", func(t *testing.T) {
+		keys = nil
+		dest, err := compressOutputDir("testdata/bundles/before", "hcdiag2022-01-01T000000Z", t.TempDir(), util.FormatTarGz, util.DefaultCompressionLevel, nil, 0)
+		require.NoError(t, err)
+		objects, err := uploadBundle(context.Background(), hclog.NewNullLogger(), uploader, dest)
+		require.NoError(t, err)
+		require.Len(t, objects, 1)
+		assert.Equal(t, "s3://support-bundles/vault/hcdiag2022-01-01T000000Z.tar.gz", objects[0].String())
+		assert.Equal(t, []string{"vault/hcdiag2022-01-01T000000Z.tar.gz"}, keys)
+	})
+
+	t.Run("split bundle", func(t *testing.T) {
+		keys = nil
+		index, err := compressOutputDir("testdata/bundles/before", "hcdiag2022-01-01T000000Z", t.TempDir(), util.FormatTarGz, util.DefaultCompressionLevel, nil, 256)
+		require.NoError(t, err)
+		parts, err := util.ReadPartsIndex(index)
+		require.NoError(t, err)
+
+		objects, err := uploadBundle(context.Background(), hclog.NewNullLogger(), uploader, index)
+		require.NoError(t, err)
+		// Every part is uploaded, followed by the index.
+		assert.Len(t, objects, len(parts.Parts)+1)
+		assert.Equal(t, "vault/hcdiag2022-01-01T000000Z.tar.gz.part001", keys[0])
+		assert.Equal(t, "vault/hcdiag2022-01-01T000000Z.tar.gz.parts.json", keys[len(keys)-1])
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	hcl2 "github.com/hashicorp/hcl/v2"
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/hcdiag/hcl"
+	"github.com/hashicorp/hcdiag/product"
+)
+
+// diagnosticWidth is the width that diagnostics are wrapped to in text output.
+const diagnosticWidth = 120
+
+var _ cli.Command = &ValidateCommand{}
+
+type ValidateCommand struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	// format is the output format, either text or json
+	format string
+}
+
+func (c *ValidateCommand) init() {
+	const formatUsageText = "Output format, either 'text' or 'json'"
+
+	c.flags = flag.NewFlagSet("validate", flag.ContinueOnError)
+	c.flags.StringVar(&c.format, "format", formatText, formatUsageText)
+	c.flags.SetOutput(io.Discard)
+}
+
+// NewValidateCommand produces a new *ValidateCommand, initialized for use in a CLI application.
+func NewValidateCommand(ui cli.Ui) *ValidateCommand {
+	c := &ValidateCommand{ui: ui}
+	c.init()
+	return c
+}
+
+// ValidateCommandFactory provides a cli.CommandFactory that will produce an appropriately-initiated *ValidateCommand.
+func ValidateCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return NewValidateCommand(ui), nil
+	}
+}
+
+// Help provides the full help output for the command.
+func (c *ValidateCommand) Help() string {
+	helpText := `Usage: hcdiag validate [options] <config file>
+
+Checks an hcdiag configuration file without running anything. Along with HCL syntax, it checks durations, command
+formats, redactions, product names, selects and excludes patterns, runner dependencies, and analysis rules, and builds
+every runner. Every problem is reported at once, with the file, line, and column it was found at. The exit code is
+non-zero if the configuration is invalid, for use in CI.
+`
+	return Usage(helpText, c.flags)
+}
+
+// Synopsis provides a brief description of the command, for inclusion in the application's primary --help.
+func (c *ValidateCommand) Synopsis() string {
+	return "Check an hcdiag configuration file for errors"
+}
+
+// validateOutput is the JSON rendering of the result of validating a config.
+type validateOutput struct {
+	Valid       bool                 `json:"valid"`
+	ErrorCount  int                  `json:"error_count"`
+	Diagnostics []validateDiagnostic `json:"diagnostics"`
+}
+
+type validateDiagnostic struct {
+	Severity string         `json:"severity"`
+	Summary  string         `json:"summary"`
+	Detail   string         `json:"detail,omitempty"`
+	Range    *validateRange `json:"range,omitempty"`
+}
+
+type validateRange struct {
+	Filename string `json:"filename"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	EndLine  int    `json:"end_line"`
+	EndCol   int    `json:"end_column"`
+}
+
+// Run executes the command.
+func (c *ValidateCommand) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Error parsing flags: %s", err))
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.flags.NArg() != 1 {
+		c.ui.Error("Expected exactly one config file to validate")
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.format != formatText && c.format != formatJSON {
+		c.ui.Error(fmt.Sprintf("Unsupported format %q; expected %q or %q", c.format, formatText, formatJSON))
+		return FlagParseError
+	}
+
+	names := make([]string, 0)
+	for _, n := range product.ConfigurableNames() {
+		names = append(names, string(n))
+	}
+	files, diags := hcl.Validate(c.flags.Arg(0), names)
+
+	if c.format == formatJSON {
+		j, err := json.MarshalIndent(newValidateOutput(diags), "", "  ")
+		if err != nil {
+			c.ui.Error(err.Error())
+			return OutputError
+		}
+		c.ui.Output(string(j))
+	} else {
+		if 0 < len(diags) {
+			var buf bytes.Buffer
+			wr := hcl2.NewDiagnosticTextWriter(&buf, files, diagnosticWidth, false)
+			if err := wr.WriteDiagnostics(diags); err != nil {
+				c.ui.Error(err.Error())
+				return OutputError
+			}
+			c.ui.Error(strings.TrimRight(buf.String(), "\n"))
+		}
+		if !diags.HasErrors() {
+			c.ui.Output(fmt.Sprintf("The configuration in %s is valid.", c.flags.Arg(0)))
+		}
+	}
+
+	if diags.HasErrors() {
+		return ConfigError
+	}
+	return Success
+}
+
+func newValidateOutput(diags hcl2.Diagnostics) validateOutput {
+	out := validateOutput{
+		Valid:       !diags.HasErrors(),
+		ErrorCount:  len(diags.Errs()),
+		Diagnostics: make([]validateDiagnostic, len(diags)),
+	}
+	for i, d := range diags {
+		severity := "error"
+		if d.Severity == hcl2.DiagWarning {
+			severity = "warning"
+		}
+		out.Diagnostics[i] = validateDiagnostic{Severity: severity, Summary: d.Summary, Detail: d.Detail}
+		if d.Subject != nil {
+			out.Diagnostics[i].Range = &validateRange{
+				Filename: d.Subject.Filename,
+				Line:     d.Subject.Start.Line,
+				Column:   d.Subject.Start.Column,
+				EndLine:  d.Subject.End.Line,
+				EndCol:   d.Subject.End.Column,
+			}
+		}
+	}
+	return out
+}
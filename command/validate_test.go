@@ -0,0 +1,69 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateCommand_Run(t *testing.T) {
+	const (
+		valid   = "../tests/resources/config/config.hcl"
+		invalid = "../tests/resources/config/invalid.hcl"
+	)
+
+	t.Run("valid", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewValidateCommand(ui).Run([]string{valid})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "is valid")
+	})
+
+	t.Run("text", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewValidateCommand(ui).Run([]string{invalid})
+		require.Equal(t, ConfigError, rc)
+
+		out := ui.ErrorWriter.String()
+		assert.Contains(t, out, "Error: Invalid duration")
+		assert.Contains(t, out, "invalid.hcl line 15")
+		assert.Contains(t, out, `timeout = "5 minutes"`)
+		assert.Contains(t, out, "Error: Unknown product")
+		assert.Empty(t, ui.OutputWriter.String())
+	})
+
+	t.Run("json", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewValidateCommand(ui).Run([]string{"-format=json", invalid})
+		require.Equal(t, ConfigError, rc)
+
+		var out validateOutput
+		require.NoError(t, json.Unmarshal(ui.OutputWriter.Bytes(), &out))
+		assert.False(t, out.Valid)
+		assert.Equal(t, 8, out.ErrorCount)
+		require.NotNil(t, out.Diagnostics[0].Range)
+		assert.Equal(t, 5, out.Diagnostics[0].Range.Line)
+	})
+
+	testCases := []struct {
+		name string
+		args []string
+		rc   int
+	}{
+		{name: "no config", args: []string{}, rc: FlagParseError},
+		{name: "unsupported format", args: []string{"-format=xml", valid}, rc: FlagParseError},
+		{name: "missing config", args: []string{"testdata/missing.hcl"}, rc: ConfigError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			assert.Equal(t, tc.rc, NewValidateCommand(ui).Run(tc.args))
+		})
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mitchellh/cli"
+
+	"github.com/hashicorp/hcdiag/bundle"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+var _ cli.Command = &VerifyCommand{}
+
+type VerifyCommand struct {
+	ui    cli.Ui
+	flags *flag.FlagSet
+
+	// publicKey is the path to the ed25519 public key to check the signature with
+	publicKey string
+	// format is the output format, either text or json
+	format string
+}
+
+func (c *VerifyCommand) init() {
+	const (
+		publicKeyUsageText = "Path to a PEM-encoded ed25519 public key to check the manifest's signature with, such as one written by 'openssl pkey -pubout'. Defaults to not checking the signature"
+		formatUsageText    = "Output format, either 'text' or 'json'"
+	)
+
+	c.flags = flag.NewFlagSet("verify", flag.ContinueOnError)
+	c.flags.StringVar(&c.publicKey, "public-key", "", publicKeyUsageText)
+	c.flags.StringVar(&c.format, "format", formatText, formatUsageText)
+	c.flags.SetOutput(io.Discard)
+}
+
+// NewVerifyCommand produces a new *VerifyCommand, initialized for use in a CLI application.
+func NewVerifyCommand(ui cli.Ui) *VerifyCommand {
+	c := &VerifyCommand{ui: ui}
+	c.init()
+	return c
+}
+
+// VerifyCommandFactory provides a cli.CommandFactory that will produce an appropriately-initiated *VerifyCommand.
+func VerifyCommandFactory(ui cli.Ui) cli.CommandFactory {
+	return func() (cli.Command, error) {
+		return NewVerifyCommand(ui), nil
+	}
+}
+
+// Help provides the full help output for the command.
+func (c *VerifyCommand) Help() string {
+	helpText := `Usage: hcdiag verify [options] <bundle>
+
+Checks that an archived bundle written by 'hcdiag run' hasn't been modified or truncated since it was written. Every
+file in the bundle is checked against the checksums in its manifest.json, and with -public-key, the manifest is
+checked against its signature. The bundle is read without extracting it. The exit code is non-zero if any file was
+modified, missing, or unexpected, or if the signature is invalid, or missing when a public key was given.
+`
+	return Usage(helpText, c.flags)
+}
+
+// Synopsis provides a brief description of the command, for inclusion in the application's primary --help.
+func (c *VerifyCommand) Synopsis() string {
+	return "Check the checksums and signature of an hcdiag bundle"
+}
+
+// verifyOutput is the JSON rendering of a verified bundle.
+type verifyOutput struct {
+	Bundle string `json:"bundle"`
+	OK     bool   `json:"ok"`
+	bundle.Verification
+}
+
+// Run executes the command.
+func (c *VerifyCommand) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		c.ui.Error(fmt.Sprintf("Error parsing flags: %s", err))
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.flags.NArg() != 1 {
+		c.ui.Error("Expected exactly one bundle to verify")
+		c.ui.Warn(c.Help())
+		return FlagParseError
+	}
+	if c.format != formatText && c.format != formatJSON {
+		c.ui.Error(fmt.Sprintf("Unsupported format %q; expected %q or %q", c.format, formatText, formatJSON))
+		return FlagParseError
+	}
+
+	var key ed25519.PublicKey
+	if c.publicKey != "" {
+		var err error
+		if key, err = util.ParseVerifyingKey(c.publicKey); err != nil {
+			c.ui.Error(err.Error())
+			return ConfigError
+		}
+	}
+
+	src := c.flags.Arg(0)
+	if info, err := os.Stat(src); err != nil {
+		c.ui.Error(err.Error())
+		return BundleReadError
+	} else if info.IsDir() {
+		c.ui.Error(fmt.Sprintf("Expected an archived bundle to verify, but %s is a directory", src))
+		return BundleReadError
+	}
+
+	v, err := bundle.Verify(src, key)
+	if err != nil {
+		c.ui.Error(err.Error())
+		return BundleReadError
+	}
+
+	out := verifyOutput{Bundle: src, OK: v.OK(), Verification: v}
+	var rendered string
+	if c.format == formatJSON {
+		j, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			c.ui.Error(err.Error())
+			return OutputError
+		}
+		rendered = string(j)
+	} else {
+		var buf bytes.Buffer
+		if err := writeVerification(&buf, out); err != nil {
+			c.ui.Error(err.Error())
+			return OutputError
+		}
+		rendered = strings.TrimRight(buf.String(), "\n")
+	}
+
+	if !out.OK {
+		c.ui.Error(rendered)
+		return BundleVerifyError
+	}
+	c.ui.Output(rendered)
+	return Success
+}
+
+// writeVerification renders out as human-readable text.
+func writeVerification(w io.Writer, out verifyOutput) error {
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	rows := [][]string{
+		{"bundle", out.Bundle},
+		{"verified", fmt.Sprintf("%d files", out.Verified)},
+		{"signature", string(out.Signature)},
+	}
+	for _, name := range out.Modified {
+		rows = append(rows, []string{"modified", name})
+	}
+	for _, name := range out.Missing {
+		rows = append(rows, []string{"missing", name})
+	}
+	for _, name := range out.Unexpected {
+		rows = append(rows, []string{"unexpected", name})
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprint(t, formatReportLine(row...)); err != nil {
+			return err
+		}
+	}
+	if err := t.Flush(); err != nil {
+		return err
+	}
+
+	var summary string
+	switch {
+	case !out.OK:
+		summary = "The bundle failed verification."
+	case out.Signature == bundle.SignatureUnchecked:
+		summary = "The bundle's files match its manifest. It is signed, but the signature wasn't checked; use -public-key to check it."
+	case out.Signature == bundle.SignatureValid:
+		summary = "The bundle's files match its manifest, and its signature is valid."
+	default:
+		summary = "The bundle's files match its manifest."
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n", summary)
+	return err
+}
@@ -0,0 +1,113 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package command
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/agent"
+	"github.com/hashicorp/hcdiag/bundle"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+const (
+	testSigningKey = "../tests/resources/keys/signing.pem"
+	testPublicKey  = "../tests/resources/keys/signing.pub.pem"
+	testOtherKey   = "../tests/resources/keys/other.pub.pem"
+)
+
+// writeSignedBundle copies the bundle in testdata/bundles/before, records checksums in its manifest, signs it, and
+// archives it. tamper, if set, is called on the copy before it's archived. It returns the path to the .tar.gz.
+func writeSignedBundle(t *testing.T, tamper func(dir string)) string {
+	t.Helper()
+	dir := t.TempDir()
+	require.NoError(t, os.CopyFS(dir, os.DirFS("testdata/bundles/before")))
+
+	checksums, err := agent.Checksums(dir)
+	require.NoError(t, err)
+	bts, err := os.ReadFile(filepath.Join(dir, agent.ManifestFile))
+	require.NoError(t, err)
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(bts, &m))
+	m["checksums"] = checksums
+	manifest, err := json.Marshal(m)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, agent.ManifestFile), manifest, 0600))
+
+	key, err := util.ParseSigningKey(testSigningKey)
+	require.NoError(t, err)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, manifest))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, agent.SignatureFile), []byte(sig), 0600))
+
+	if tamper != nil {
+		tamper(dir)
+	}
+	tarGz := filepath.Join(t.TempDir(), "hcdiag.tar.gz")
+	require.NoError(t, util.TarGz(dir, tarGz, "hcdiag"))
+	return tarGz
+}
+
+func TestVerifyCommand_Run(t *testing.T) {
+	signed := writeSignedBundle(t, nil)
+	modified := writeSignedBundle(t, func(dir string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "host", "hosts"), []byte("10.0.0.1 localhost"), 0600))
+	})
+
+	t.Run("text", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewVerifyCommand(ui).Run([]string{"-public-key", testPublicKey, signed})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+		out := ui.OutputWriter.String()
+		assert.Regexp(t, `verified\s+2 files`, out)
+		assert.Regexp(t, `signature\s+valid`, out)
+		assert.Contains(t, out, "its signature is valid")
+	})
+
+	t.Run("unchecked signature", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewVerifyCommand(ui).Run([]string{signed})
+		require.Equal(t, Success, rc, ui.ErrorWriter.String())
+		assert.Contains(t, ui.OutputWriter.String(), "use -public-key to check it")
+	})
+
+	t.Run("modified", func(t *testing.T) {
+		ui := cli.NewMockUi()
+		rc := NewVerifyCommand(ui).Run([]string{"-format=json", "-public-key", testPublicKey, modified})
+		require.Equal(t, BundleVerifyError, rc)
+
+		var out verifyOutput
+		require.NoError(t, json.Unmarshal(ui.ErrorWriter.Bytes(), &out))
+		assert.False(t, out.OK)
+		assert.Equal(t, []string{"host/hosts"}, out.Modified)
+		assert.Equal(t, bundle.SignatureValid, out.Signature, "the manifest itself wasn't modified")
+	})
+
+	testCases := []struct {
+		name string
+		args []string
+		rc   int
+	}{
+		{name: "no bundle", args: []string{}, rc: FlagParseError},
+		{name: "unsupported format", args: []string{"-format=xml", signed}, rc: FlagParseError},
+		{name: "invalid public key", args: []string{"-public-key", testSigningKey, signed}, rc: ConfigError},
+		{name: "wrong public key", args: []string{"-public-key", testOtherKey, signed}, rc: BundleVerifyError},
+		{name: "missing bundle", args: []string{"testdata/bundles/missing.tar.gz"}, rc: BundleReadError},
+		{name: "directory", args: []string{"testdata/bundles/before"}, rc: BundleReadError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			assert.Equal(t, tc.rc, NewVerifyCommand(ui).Run(tc.args))
+		})
+	}
+}
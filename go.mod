@@ -3,10 +3,12 @@ module github.com/hashicorp/hcdiag
 go 1.26.3
 
 require (
+	filippo.io/age v1.2.1
 	github.com/cosiner/argv v0.1.0
 	github.com/hashicorp/go-hclog v1.3.1
 	github.com/hashicorp/go-rootcerts v1.0.2
 	github.com/hashicorp/hcl/v2 v2.14.1
+	github.com/klauspost/compress v1.18.0
 	github.com/kr/text v0.2.0
 	github.com/mitchellh/cli v1.1.4
 	github.com/mitchellh/go-homedir v1.1.0
@@ -59,7 +61,7 @@ require (
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/tklauser/go-sysconf v0.3.10 // indirect
 	github.com/tklauser/numcpus v0.5.0 // indirect
-	github.com/zclconf/go-cty v1.11.1 // indirect
+	github.com/zclconf/go-cty v1.11.1
 	golang.org/x/sys v0.39.0 // indirect
 	golang.org/x/text v0.32.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
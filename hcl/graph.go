@@ -0,0 +1,201 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-hclog"
+	hcl2 "github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/hcdiag/runner"
+	"github.com/hashicorp/hcdiag/runner/do"
+)
+
+// dependent is implemented by runner blocks, which may set an `id` and a list of other ids they `depends_on`.
+type dependent interface {
+	dependencies() (*hcl2.Attribute, *hcl2.Attribute)
+}
+
+func (c Command) dependencies() (*hcl2.Attribute, *hcl2.Attribute)     { return c.ID, c.DependsOn }
+func (c Shell) dependencies() (*hcl2.Attribute, *hcl2.Attribute)       { return c.ID, c.DependsOn }
+func (c GET) dependencies() (*hcl2.Attribute, *hcl2.Attribute)         { return c.ID, c.DependsOn }
+func (c Copy) dependencies() (*hcl2.Attribute, *hcl2.Attribute)        { return c.ID, c.DependsOn }
+func (c DockerLog) dependencies() (*hcl2.Attribute, *hcl2.Attribute)   { return c.ID, c.DependsOn }
+func (c JournaldLog) dependencies() (*hcl2.Attribute, *hcl2.Attribute) { return c.ID, c.DependsOn }
+func (c VaultDebug) dependencies() (*hcl2.Attribute, *hcl2.Attribute)  { return c.ID, c.DependsOn }
+func (c ConsulDebug) dependencies() (*hcl2.Attribute, *hcl2.Attribute) { return c.ID, c.DependsOn }
+func (c NomadDebug) dependencies() (*hcl2.Attribute, *hcl2.Attribute)  { return c.ID, c.DependsOn }
+
+// graphNode pairs a runner with the dependency settings of the block it was built from. id holds the value of idAttr,
+// once dependencies has decoded it.
+type graphNode struct {
+	id        string
+	idAttr    *hcl2.Attribute
+	dependsOn *hcl2.Attribute
+	runner    runner.Runner
+}
+
+// dependency is one entry of a block's `depends_on`, along with where it appears in the config.
+type dependency struct {
+	id  string
+	rng hcl2.Range
+}
+
+// graphNodes zips runner blocks with the runners that were built from them, in the same order.
+func graphNodes[T dependent](cfgs []T, runners []runner.Runner) []graphNode {
+	nodes := make([]graphNode, len(runners))
+	for i, r := range runners {
+		id, dependsOn := cfgs[i].dependencies()
+		nodes[i] = graphNode{idAttr: id, dependsOn: dependsOn, runner: r}
+	}
+	return nodes
+}
+
+// graphRunners returns the runners for nodes. If any of them set an `id` or `depends_on`, those are wrapped together in
+// a do.Graph, which is returned after the remaining runners. The returned error is hcl.Diagnostics, with source ranges,
+// if any dependency is invalid, unknown, or part of a cycle.
+func graphRunners(ctx context.Context, label string, nodes []graphNode, l hclog.Logger) ([]runner.Runner, error) {
+	runners := make([]runner.Runner, 0, len(nodes))
+	graphed := make([]graphNode, 0)
+	for _, n := range nodes {
+		if n.idAttr == nil && n.dependsOn == nil {
+			runners = append(runners, n.runner)
+			continue
+		}
+		graphed = append(graphed, n)
+	}
+	if len(graphed) == 0 {
+		return runners, nil
+	}
+
+	deps, diags := dependencies(graphed)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	gNodes := make([]do.GraphNode, len(graphed))
+	for i, n := range graphed {
+		ids := make([]string, len(deps[i]))
+		for j, d := range deps[i] {
+			ids[j] = d.id
+		}
+		gNodes[i] = do.GraphNode{Name: n.id, DependsOn: ids, Runner: n.runner}
+	}
+	g, err := do.NewGraphWithContext(ctx, do.GraphConfig{
+		Label:  label,
+		Nodes:  gNodes,
+		Logger: l,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return append(runners, g), nil
+}
+
+// dependencies decodes the `id` and `depends_on` of each node, and checks that every id is unique, that every reference
+// is to a known id, and that there are no cycles. The returned slice is parallel to nodes.
+func dependencies(nodes []graphNode) ([][]dependency, hcl2.Diagnostics) {
+	var diags hcl2.Diagnostics
+
+	known := make(map[string]bool, len(nodes))
+	for i, n := range nodes {
+		if n.idAttr == nil {
+			continue
+		}
+		val, valDiags := n.idAttr.Expr.Value(nil)
+		if valDiags.HasErrors() || val.IsNull() || !val.IsKnown() || !val.Type().Equals(cty.String) {
+			diags = append(diags, &hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Invalid runner id",
+				Detail:   "A runner's id must be a string.",
+				Subject:  n.idAttr.Range.Ptr(),
+			})
+			continue
+		}
+		id := val.AsString()
+		if known[id] {
+			diags = append(diags, &hcl2.Diagnostic{
+				Severity: hcl2.DiagError,
+				Summary:  "Duplicate runner id",
+				Detail:   fmt.Sprintf("The id %q is used by more than one runner in the same block; each id must be unique.", id),
+				Subject:  n.idAttr.Range.Ptr(),
+			})
+		}
+		known[id] = true
+		nodes[i].id = id
+	}
+
+	deps := make([][]dependency, len(nodes))
+	for i, n := range nodes {
+		if n.dependsOn == nil {
+			continue
+		}
+		exprs, listDiags := hcl2.ExprList(n.dependsOn.Expr)
+		if listDiags.HasErrors() {
+			diags = append(diags, listDiags...)
+			continue
+		}
+		for _, expr := range exprs {
+			val, valDiags := expr.Value(nil)
+			if valDiags.HasErrors() || val.IsNull() || !val.IsKnown() || !val.Type().Equals(cty.String) {
+				diags = append(diags, &hcl2.Diagnostic{
+					Severity: hcl2.DiagError,
+					Summary:  "Invalid depends_on reference",
+					Detail:   "Each element of depends_on must be the id of another runner, as a string.",
+					Subject:  expr.Range().Ptr(),
+				})
+				continue
+			}
+			id := val.AsString()
+			if !known[id] {
+				diags = append(diags, &hcl2.Diagnostic{
+					Severity: hcl2.DiagError,
+					Summary:  "Reference to unknown runner id",
+					Detail:   fmt.Sprintf("There is no runner with id %q in the same block.", id),
+					Subject:  expr.Range().Ptr(),
+				})
+				continue
+			}
+			deps[i] = append(deps[i], dependency{id: id, rng: expr.Range()})
+		}
+	}
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	// Look for cycles among runners that have ids; runners without one can't be depended on, so they can't be in one.
+	byID := make(map[string][]string, len(nodes))
+	ranges := make(map[string]map[string]hcl2.Range, len(nodes))
+	for i, n := range nodes {
+		if n.id == "" {
+			continue
+		}
+		ranges[n.id] = make(map[string]hcl2.Range, len(deps[i]))
+		for _, d := range deps[i] {
+			byID[n.id] = append(byID[n.id], d.id)
+			ranges[n.id][d.id] = d.rng
+		}
+		if _, ok := byID[n.id]; !ok {
+			byID[n.id] = nil
+		}
+	}
+	if cycle := do.FindCycle(byID); cycle != nil {
+		// Point at the reference that closes the cycle.
+		last := cycle[len(cycle)-2]
+		rng := ranges[last][cycle[len(cycle)-1]]
+		diags = append(diags, &hcl2.Diagnostic{
+			Severity: hcl2.DiagError,
+			Summary:  "Dependency cycle",
+			Detail:   fmt.Sprintf("Runners can't depend on each other in a cycle: %s.", strings.Join(cycle, " -> ")),
+			Subject:  &rng,
+		})
+		return nil, diags
+	}
+
+	return deps, diags
+}
@@ -10,12 +10,16 @@ import (
 	"time"
 
 	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/hcdiag/analyze"
 	"github.com/hashicorp/hcdiag/client"
 	"github.com/hashicorp/hcdiag/redact"
 	"github.com/hashicorp/hcdiag/runner"
 	"github.com/hashicorp/hcdiag/runner/debug"
 	"github.com/hashicorp/hcdiag/runner/host"
 	"github.com/hashicorp/hcdiag/runner/log"
+	"github.com/hashicorp/hcdiag/upload"
+	"github.com/hashicorp/hcdiag/util"
+	hcl2 "github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsimple"
 )
 
@@ -23,6 +27,8 @@ type HCL struct {
 	Host     *Host      `hcl:"host,block" json:"host,omitempty"`
 	Products []*Product `hcl:"product,block" json:"products,omitempty"`
 	Agent    *Agent     `hcl:"agent,block" json:"agent,omitempty"`
+	// Rules are evaluated against the results of the run, along with the built-in rules for each product.
+	Rules []analyze.Rule `hcl:"rule,block" json:"rules,omitempty"`
 }
 
 type Blocks interface {
@@ -32,6 +38,31 @@ type Blocks interface {
 type Agent struct {
 	// NOTE(dcohen) this is currently a separate config block, as opposed to a parent block of the others
 	Redactions []Redact `hcl:"redact,block" json:"redactions,omitempty"`
+	// MaxConcurrency limits how many runners may execute at once across all products. Zero means no limit.
+	MaxConcurrency int `hcl:"max_concurrency,optional" json:"max_concurrency,omitempty"`
+	// DisableRedactions lists the IDs of built-in redactions that should not be applied.
+	DisableRedactions []string `hcl:"disable_redactions,optional" json:"disable_redactions,omitempty"`
+	// SigningKey is the path to a PEM-encoded ed25519 private key to sign the bundle's manifest with.
+	SigningKey string `hcl:"signing_key,optional" json:"signing_key,omitempty"`
+	// Upload, if set, uploads the bundle to an S3-compatible object store after it's written.
+	Upload *Upload `hcl:"upload,block" json:"upload,omitempty"`
+}
+
+// Upload configures where bundles are uploaded to. Credentials may be left out, in favor of the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN environment variables; either way, they're never written to the manifest.
+type Upload struct {
+	Endpoint string `hcl:"endpoint" json:"endpoint"`
+	Bucket   string `hcl:"bucket" json:"bucket"`
+	Prefix   string `hcl:"prefix,optional" json:"prefix,omitempty"`
+	Region   string `hcl:"region,optional" json:"region,omitempty"`
+	// PartSize is the size of each part of the multipart upload, such as "16MiB". It must be at least 5MiB.
+	PartSize string `hcl:"part_size,optional" json:"part_size,omitempty"`
+	// Retries is how many times a failed request is retried. Zero means the default; a negative number disables retries.
+	Retries int `hcl:"retries,optional" json:"retries,omitempty"`
+
+	AccessKeyID     string `hcl:"access_key_id,optional" json:"-"`
+	SecretAccessKey string `hcl:"secret_access_key,optional" json:"-"`
+	SessionToken    string `hcl:"session_token,optional" json:"-"`
 }
 
 type Host struct {
@@ -58,6 +89,11 @@ type Host struct {
 type Product struct {
 	Name string `hcl:"name,label" json:"name"`
 
+	// API and HealthCheck define a product of the user's own, rather than configuring a built-in one; see IsDefinition.
+	API *API `hcl:"api,block" json:"api,omitempty"`
+	// HealthCheck is a command that must succeed for the product to be considered available, such as "acme version".
+	HealthCheck string `hcl:"healthcheck,optional" json:"healthcheck,omitempty"`
+
 	// Do
 	Do  []Do  `hcl:"do,block" json:"do,omitempty"`
 	Seq []Seq `hcl:"seq,block" json:"seq,omitempty"`
@@ -77,6 +113,31 @@ type Product struct {
 	Redactions   []Redact      `hcl:"redact,block" json:"redactions,omitempty"`
 }
 
+// API describes how to reach the API of a product that's defined in HCL. Each *_env attribute names an environment
+// variable to read a setting from, so that addresses, tokens, and certificates can be set per host, as they are for
+// built-in products.
+type API struct {
+	Address    string `hcl:"address,optional" json:"address,omitempty"`
+	AddressEnv string `hcl:"address_env,optional" json:"address_env,omitempty"`
+	// AuthEnv names the variable holding a token, which is sent in AuthHeader, prefixed with AuthScheme if set.
+	AuthEnv    string `hcl:"auth_env,optional" json:"auth_env,omitempty"`
+	AuthHeader string `hcl:"auth_header,optional" json:"auth_header,omitempty"`
+	AuthScheme string `hcl:"auth_scheme,optional" json:"auth_scheme,omitempty"`
+
+	CACertEnv        string `hcl:"ca_cert_env,optional" json:"ca_cert_env,omitempty"`
+	CAPathEnv        string `hcl:"ca_path_env,optional" json:"ca_path_env,omitempty"`
+	ClientCertEnv    string `hcl:"client_cert_env,optional" json:"client_cert_env,omitempty"`
+	ClientKeyEnv     string `hcl:"client_key_env,optional" json:"client_key_env,omitempty"`
+	TLSServerNameEnv string `hcl:"tls_server_name_env,optional" json:"tls_server_name_env,omitempty"`
+	TLSInsecureEnv   string `hcl:"tls_insecure_env,optional" json:"tls_insecure_env,omitempty"`
+}
+
+// IsDefinition reports whether p defines a product of its own, with an api block or a healthcheck, rather than
+// configuring one that's built into hcdiag.
+func (p *Product) IsDefinition() bool {
+	return p.API != nil || p.HealthCheck != ""
+}
+
 type Do struct {
 	Label       string `hcl:"name,label" json:"label"`
 	Description string `hcl:"description,optional" json:"since"`
@@ -132,10 +193,15 @@ type Seq struct {
 }
 
 type Redact struct {
-	Label   string `hcl:"name,label" json:"label"`
-	ID      string `hcl:"id,optional" json:"id"`
-	Match   string `hcl:"match" json:"-"`
+	Label string `hcl:"name,label" json:"label"`
+	ID    string `hcl:"id,optional" json:"id"`
+	Match string `hcl:"match,optional" json:"-"`
+	// Path is the JSON key path glob that a "path" redaction matches, such as "**.password".
+	Path    string `hcl:"path,optional" json:"path,omitempty"`
 	Replace string `hcl:"replace,optional" json:"replace"`
+	// Pseudonym, if set, replaces each distinct match with a stable pseudonym with this prefix, instead of Replace. If
+	// Match has capture groups, only the text matched by the first one is replaced.
+	Pseudonym string `hcl:"pseudonym,optional" json:"pseudonym,omitempty"`
 }
 
 type Command struct {
@@ -143,18 +209,30 @@ type Command struct {
 	Format     string   `hcl:"format" json:"format"`
 	Redactions []Redact `hcl:"redact,block" json:"redactions,omitempty"`
 	Timeout    string   `hcl:"timeout,optional" json:"timeout,omitempty"`
+
+	// Dependencies
+	ID        *hcl2.Attribute `hcl:"id,optional" json:"-"`
+	DependsOn *hcl2.Attribute `hcl:"depends_on,optional" json:"-"`
 }
 
 type Shell struct {
 	Run        string   `hcl:"run" json:"run"`
 	Timeout    string   `hcl:"timeout,optional" json:"timeout,omitempty"`
 	Redactions []Redact `hcl:"redact,block" json:"redactions,omitempty"`
+
+	// Dependencies
+	ID        *hcl2.Attribute `hcl:"id,optional" json:"-"`
+	DependsOn *hcl2.Attribute `hcl:"depends_on,optional" json:"-"`
 }
 
 type GET struct {
 	Path       string   `hcl:"path" json:"path"`
 	Redactions []Redact `hcl:"redact,block" json:"redactions,omitempty"`
 	Timeout    string   `hcl:"timeout,optional" json:"timeout,omitempty"`
+
+	// Dependencies
+	ID        *hcl2.Attribute `hcl:"id,optional" json:"-"`
+	DependsOn *hcl2.Attribute `hcl:"depends_on,optional" json:"-"`
 }
 
 type Copy struct {
@@ -162,6 +240,10 @@ type Copy struct {
 	Since      string   `hcl:"since,optional" json:"since"`
 	Redactions []Redact `hcl:"redact,block" json:"redactions,omitempty"`
 	Timeout    string   `hcl:"timeout,optional" json:"timeout,omitempty"`
+
+	// Dependencies
+	ID        *hcl2.Attribute `hcl:"id,optional" json:"-"`
+	DependsOn *hcl2.Attribute `hcl:"depends_on,optional" json:"-"`
 }
 
 type DockerLog struct {
@@ -169,6 +251,10 @@ type DockerLog struct {
 	Since      string   `hcl:"since,optional" json:"since"`
 	Redactions []Redact `hcl:"redact,block" json:"redactions,omitempty"`
 	Timeout    string   `hcl:"timeout,optional" json:"timeout,omitempty"`
+
+	// Dependencies
+	ID        *hcl2.Attribute `hcl:"id,optional" json:"-"`
+	DependsOn *hcl2.Attribute `hcl:"depends_on,optional" json:"-"`
 }
 
 type JournaldLog struct {
@@ -176,6 +262,10 @@ type JournaldLog struct {
 	Since      string   `hcl:"since,optional" json:"since"`
 	Redactions []Redact `hcl:"redact,block" json:"redactions,omitempty"`
 	Timeout    string   `hcl:"timeout,optional" json:"timeout,omitempty"`
+
+	// Dependencies
+	ID        *hcl2.Attribute `hcl:"id,optional" json:"-"`
+	DependsOn *hcl2.Attribute `hcl:"depends_on,optional" json:"-"`
 }
 
 type VaultDebug struct {
@@ -186,6 +276,10 @@ type VaultDebug struct {
 	MetricsInterval string   `hcl:"metrics-interval,optional" json:"metrics_interval"`
 	Targets         []string `hcl:"targets,optional" json:"targets"`
 	Redactions      []Redact `hcl:"redact,block" json:"redactions"`
+
+	// Dependencies
+	ID        *hcl2.Attribute `hcl:"id,optional" json:"-"`
+	DependsOn *hcl2.Attribute `hcl:"depends_on,optional" json:"-"`
 }
 
 type ConsulDebug struct {
@@ -194,6 +288,10 @@ type ConsulDebug struct {
 	Interval   string   `hcl:"interval,optional" json:"interval"`
 	Captures   []string `hcl:"captures,optional" json:"captures"`
 	Redactions []Redact `hcl:"redact,block" json:"redactions"`
+
+	// Dependencies
+	ID        *hcl2.Attribute `hcl:"id,optional" json:"-"`
+	DependsOn *hcl2.Attribute `hcl:"depends_on,optional" json:"-"`
 }
 
 type NomadDebug struct {
@@ -211,6 +309,10 @@ type NomadDebug struct {
 
 	EventTopic []string `hcl:"targets,optional" json:"targets"`
 	Redactions []Redact `hcl:"redact,block" json:"redactions"`
+
+	// Dependencies
+	ID        *hcl2.Attribute `hcl:"id,optional" json:"-"`
+	DependsOn *hcl2.Attribute `hcl:"depends_on,optional" json:"-"`
 }
 
 // Parse takes a file path and decodes the file from disk into HCL types.
@@ -223,141 +325,161 @@ func Parse(path string) (HCL, error) {
 	return h, nil
 }
 
+// RedactFile is a file of redact blocks, such as the one passed to `hcdiag redact`.
+type RedactFile struct {
+	Redactions []Redact `hcl:"redact,block" json:"redactions"`
+}
+
+// ParseRedactions takes a file path, decodes the redact blocks in the file from disk, and maps them to redactions.
+func ParseRedactions(path string) ([]*redact.Redact, error) {
+	var f RedactFile
+	err := hclsimple.DecodeFile(path, nil, &f)
+	if err != nil {
+		return nil, err
+	}
+	return MapRedacts(f.Redactions, redact.NewPseudonymizer())
+}
+
 // BuildRunners steps through the HCLConfig structs and maps each runner config type to the corresponding New<Runner> function.
-// All custom runners are reduced into a linear slice of runners and served back up to the product.
-// No runners are returned if any config is invalid.
+// All custom runners are reduced into a linear slice of runners and served back up to the product. Runners that set an
+// `id` or `depends_on` are wrapped together in a single do.Graph at the end of the slice.
+// No runners are returned if any config is invalid; invalid dependencies are reported as hcl.Diagnostics.
 func BuildRunners[T Blocks](config T, tmpDir string, debugDuration time.Duration, debugInterval time.Duration, c *client.APIClient, since, until time.Time, redactions []*redact.Redact) ([]runner.Runner, error) {
-	return BuildRunnersWithContext(context.Background(), config, tmpDir, debugDuration, debugInterval, c, since, until, redactions)
+	return BuildRunnersWithContext(context.Background(), config, tmpDir, debugDuration, debugInterval, c, since, until, redactions, redact.NewPseudonymizer(), hclog.NewNullLogger())
 }
 
-// BuildRunnersWithContext is similar to BuildRunners but accepts a context.Context that will be passed into the runners.
-func BuildRunnersWithContext[T Blocks](ctx context.Context, config T, tmpDir string, debugDuration time.Duration, debugInterval time.Duration, c *client.APIClient, since, until time.Time, redactions []*redact.Redact) ([]runner.Runner, error) {
-	var dest string
-	runners := make([]runner.Runner, 0)
+// BuildRunnersWithContext is similar to BuildRunners but accepts a context.Context that will be passed into the runners,
+// the Pseudonymizer for their redactions, and a logger for any do.Graph that wraps them.
+func BuildRunnersWithContext[T Blocks](ctx context.Context, config T, tmpDir string, debugDuration time.Duration, debugInterval time.Duration, c *client.APIClient, since, until time.Time, redactions []*redact.Redact, p *redact.Pseudonymizer, l hclog.Logger) ([]runner.Runner, error) {
+	var dest, label string
+	nodes := make([]graphNode, 0)
 
 	switch cfg := any(config).(type) {
 	case *Product:
 		// Set and validate the params that are different between Product and Host
 		dest = tmpDir + "/" + cfg.Name
+		label = cfg.Name
 		if c == nil {
 			return nil, fmt.Errorf("hcl.BuildRunners product received unexpected nil client, product=%s", cfg.Name)
 		}
 
 		// Build product's HTTPs
-		gets, err := mapProductGETs(ctx, cfg.GETs, redactions, c)
+		gets, err := mapProductGETs(ctx, cfg.GETs, redactions, p, c)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, gets...)
+		nodes = append(nodes, graphNodes(cfg.GETs, gets)...)
 
 		// Identical code between Product and Host, but cfg's type must be resolved via the switch to access the fields
 		// Build Copy runners
-		copies, err := mapCopies(ctx, cfg.Copies, redactions, dest)
+		copies, err := mapCopies(ctx, cfg.Copies, redactions, p, dest)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, copies...)
+		nodes = append(nodes, graphNodes(cfg.Copies, copies)...)
 
 		// Build docker and journald logs
-		dockerLogs, err := mapDockerLogs(ctx, cfg.DockerLogs, dest, since, redactions)
+		dockerLogs, err := mapDockerLogs(ctx, cfg.DockerLogs, dest, since, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, dockerLogs...)
+		nodes = append(nodes, graphNodes(cfg.DockerLogs, dockerLogs)...)
 
-		journaldLogs, err := mapJournaldLogs(ctx, cfg.JournaldLogs, dest, since, until, redactions)
+		journaldLogs, err := mapJournaldLogs(ctx, cfg.JournaldLogs, dest, since, until, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, journaldLogs...)
+		nodes = append(nodes, graphNodes(cfg.JournaldLogs, journaldLogs)...)
 
 		// Build debug runners
-		vaultDebugs, err := mapVaultDebugs(ctx, cfg.VaultDebugs, tmpDir, debugDuration, debugInterval, redactions)
+		vaultDebugs, err := mapVaultDebugs(ctx, cfg.VaultDebugs, tmpDir, debugDuration, debugInterval, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, vaultDebugs...)
+		nodes = append(nodes, graphNodes(cfg.VaultDebugs, vaultDebugs)...)
 
-		consulDebugs, err := mapConsulDebugs(ctx, cfg.ConsulDebugs, tmpDir, debugDuration, debugInterval, redactions)
+		consulDebugs, err := mapConsulDebugs(ctx, cfg.ConsulDebugs, tmpDir, debugDuration, debugInterval, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, consulDebugs...)
+		nodes = append(nodes, graphNodes(cfg.ConsulDebugs, consulDebugs)...)
 
-		nomadDebugs, err := mapNomadDebugs(ctx, cfg.NomadDebugs, tmpDir, debugDuration, debugInterval, redactions)
+		nomadDebugs, err := mapNomadDebugs(ctx, cfg.NomadDebugs, tmpDir, debugDuration, debugInterval, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, nomadDebugs...)
+		nodes = append(nodes, graphNodes(cfg.NomadDebugs, nomadDebugs)...)
 
 		// Build commands and shells
-		commands, err := mapCommands(ctx, cfg.Commands, redactions)
+		commands, err := mapCommands(ctx, cfg.Commands, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, commands...)
+		nodes = append(nodes, graphNodes(cfg.Commands, commands)...)
 
-		shells, err := mapShells(ctx, cfg.Shells, redactions)
+		shells, err := mapShells(ctx, cfg.Shells, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, shells...)
+		nodes = append(nodes, graphNodes(cfg.Shells, shells)...)
 
 	case *Host:
 		// Set and validate the params that are different between Product and Host
 		dest = tmpDir + "/host"
+		label = "host"
 		if c != nil {
 			return nil, fmt.Errorf("hcl.BuildRunners host received a client when nil expected, client=%v", c)
 		}
 
 		// Build host's HTTPs
-		gets, err := mapHostGets(ctx, cfg.GETs, redactions)
+		gets, err := mapHostGets(ctx, cfg.GETs, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, gets...)
+		nodes = append(nodes, graphNodes(cfg.GETs, gets)...)
 
 		// Identical code between Product and Host, but cfg's type must be resolved via the switch
 		// Build Copy runners
-		copies, err := mapCopies(ctx, cfg.Copies, redactions, dest)
+		copies, err := mapCopies(ctx, cfg.Copies, redactions, p, dest)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, copies...)
+		nodes = append(nodes, graphNodes(cfg.Copies, copies)...)
 
 		// Build docker and journald logs
-		dockerLogs, err := mapDockerLogs(ctx, cfg.DockerLogs, dest, since, redactions)
+		dockerLogs, err := mapDockerLogs(ctx, cfg.DockerLogs, dest, since, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, dockerLogs...)
+		nodes = append(nodes, graphNodes(cfg.DockerLogs, dockerLogs)...)
 
-		journaldLogs, err := mapJournaldLogs(ctx, cfg.JournaldLogs, dest, since, until, redactions)
+		journaldLogs, err := mapJournaldLogs(ctx, cfg.JournaldLogs, dest, since, until, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, journaldLogs...)
+		nodes = append(nodes, graphNodes(cfg.JournaldLogs, journaldLogs)...)
 
 		// Build commands and shells
-		commands, err := mapCommands(ctx, cfg.Commands, redactions)
+		commands, err := mapCommands(ctx, cfg.Commands, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, commands...)
+		nodes = append(nodes, graphNodes(cfg.Commands, commands)...)
 
-		shells, err := mapShells(ctx, cfg.Shells, redactions)
+		shells, err := mapShells(ctx, cfg.Shells, redactions, p)
 		if err != nil {
 			return nil, err
 		}
-		runners = append(runners, shells...)
+		nodes = append(nodes, graphNodes(cfg.Shells, shells)...)
 	}
-	return runners, nil
+	// Runners that set an `id` or `depends_on` are run according to their dependencies, rather than in order.
+	return graphRunners(ctx, label, nodes, l)
 }
 
-func mapCommands(ctx context.Context, cfgs []Command, redactions []*redact.Redact) ([]runner.Runner, error) {
+func mapCommands(ctx context.Context, cfgs []Command, redactions []*redact.Redact, p *redact.Pseudonymizer) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 	for i, c := range cfgs {
-		runnerRedacts, err := MapRedacts(c.Redactions)
+		runnerRedacts, err := MapRedacts(c.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -384,10 +506,10 @@ func mapCommands(ctx context.Context, cfgs []Command, redactions []*redact.Redac
 	return runners, nil
 }
 
-func mapShells(ctx context.Context, cfgs []Shell, redactions []*redact.Redact) ([]runner.Runner, error) {
+func mapShells(ctx context.Context, cfgs []Shell, redactions []*redact.Redact, p *redact.Pseudonymizer) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 	for i, c := range cfgs {
-		runnerRedacts, err := MapRedacts(c.Redactions)
+		runnerRedacts, err := MapRedacts(c.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -416,11 +538,11 @@ func mapShells(ctx context.Context, cfgs []Shell, redactions []*redact.Redact) (
 	return runners, nil
 }
 
-func mapCopies(ctx context.Context, cfgs []Copy, redactions []*redact.Redact, dest string) ([]runner.Runner, error) {
+func mapCopies(ctx context.Context, cfgs []Copy, redactions []*redact.Redact, p *redact.Pseudonymizer, dest string) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 	for i, c := range cfgs {
 		var since time.Time
-		runnerRedacts, err := MapRedacts(c.Redactions)
+		runnerRedacts, err := MapRedacts(c.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -460,10 +582,10 @@ func mapCopies(ctx context.Context, cfgs []Copy, redactions []*redact.Redact, de
 	return runners, nil
 }
 
-func mapProductGETs(ctx context.Context, cfgs []GET, redactions []*redact.Redact, c *client.APIClient) ([]runner.Runner, error) {
+func mapProductGETs(ctx context.Context, cfgs []GET, redactions []*redact.Redact, p *redact.Pseudonymizer, c *client.APIClient) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 	for i, g := range cfgs {
-		runnerRedacts, err := MapRedacts(g.Redactions)
+		runnerRedacts, err := MapRedacts(g.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -490,10 +612,10 @@ func mapProductGETs(ctx context.Context, cfgs []GET, redactions []*redact.Redact
 	return runners, nil
 }
 
-func mapHostGets(ctx context.Context, cfgs []GET, redactions []*redact.Redact) ([]runner.Runner, error) {
+func mapHostGets(ctx context.Context, cfgs []GET, redactions []*redact.Redact, p *redact.Pseudonymizer) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 	for i, g := range cfgs {
-		runnerRedacts, err := MapRedacts(g.Redactions)
+		runnerRedacts, err := MapRedacts(g.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -520,11 +642,11 @@ func mapHostGets(ctx context.Context, cfgs []GET, redactions []*redact.Redact) (
 	return runners, nil
 }
 
-func mapDockerLogs(ctx context.Context, cfgs []DockerLog, dest string, since time.Time, redactions []*redact.Redact) ([]runner.Runner, error) {
+func mapDockerLogs(ctx context.Context, cfgs []DockerLog, dest string, since time.Time, redactions []*redact.Redact, p *redact.Pseudonymizer) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 
 	for i, d := range cfgs {
-		runnerRedacts, err := MapRedacts(d.Redactions)
+		runnerRedacts, err := MapRedacts(d.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -558,11 +680,11 @@ func mapDockerLogs(ctx context.Context, cfgs []DockerLog, dest string, since tim
 	return runners, nil
 }
 
-func mapJournaldLogs(ctx context.Context, cfgs []JournaldLog, dest string, since, until time.Time, redactions []*redact.Redact) ([]runner.Runner, error) {
+func mapJournaldLogs(ctx context.Context, cfgs []JournaldLog, dest string, since, until time.Time, redactions []*redact.Redact, p *redact.Pseudonymizer) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 
 	for i, j := range cfgs {
-		runnerRedacts, err := MapRedacts(j.Redactions)
+		runnerRedacts, err := MapRedacts(j.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -599,11 +721,11 @@ func mapJournaldLogs(ctx context.Context, cfgs []JournaldLog, dest string, since
 	return runners, nil
 }
 
-func mapVaultDebugs(ctx context.Context, cfgs []VaultDebug, tmpDir string, debugDuration time.Duration, debugInterval time.Duration, redactions []*redact.Redact) ([]runner.Runner, error) {
+func mapVaultDebugs(ctx context.Context, cfgs []VaultDebug, tmpDir string, debugDuration time.Duration, debugInterval time.Duration, redactions []*redact.Redact, p *redact.Pseudonymizer) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 
 	for i, d := range cfgs {
-		runnerRedacts, err := MapRedacts(d.Redactions)
+		runnerRedacts, err := MapRedacts(d.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -621,7 +743,7 @@ func mapVaultDebugs(ctx context.Context, cfgs []VaultDebug, tmpDir string, debug
 			Redactions:      runnerRedacts,
 		}
 
-		dbg, err := debug.NewVaultDebug(cfg, tmpDir, debugDuration, debugInterval)
+		dbg, err := debug.NewVaultDebugWithContext(ctx, cfg, tmpDir, debugDuration, debugInterval)
 		if err != nil {
 			return nil, err
 		}
@@ -630,11 +752,11 @@ func mapVaultDebugs(ctx context.Context, cfgs []VaultDebug, tmpDir string, debug
 	return runners, nil
 }
 
-func mapConsulDebugs(ctx context.Context, cfgs []ConsulDebug, tmpDir string, debugDuration time.Duration, debugInterval time.Duration, redactions []*redact.Redact) ([]runner.Runner, error) {
+func mapConsulDebugs(ctx context.Context, cfgs []ConsulDebug, tmpDir string, debugDuration time.Duration, debugInterval time.Duration, redactions []*redact.Redact, p *redact.Pseudonymizer) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 
 	for i, d := range cfgs {
-		runnerRedacts, err := MapRedacts(d.Redactions)
+		runnerRedacts, err := MapRedacts(d.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -649,7 +771,7 @@ func mapConsulDebugs(ctx context.Context, cfgs []ConsulDebug, tmpDir string, deb
 			Captures:   d.Captures,
 			Redactions: runnerRedacts,
 		}
-		dbg, err := debug.NewConsulDebug(cfg, tmpDir, debugDuration, debugInterval)
+		dbg, err := debug.NewConsulDebugWithContext(ctx, cfg, tmpDir, debugDuration, debugInterval)
 		if err != nil {
 			return nil, err
 		}
@@ -658,11 +780,11 @@ func mapConsulDebugs(ctx context.Context, cfgs []ConsulDebug, tmpDir string, deb
 	return runners, nil
 }
 
-func mapNomadDebugs(ctx context.Context, cfgs []NomadDebug, tmpDir string, debugDuration time.Duration, debugInterval time.Duration, redactions []*redact.Redact) ([]runner.Runner, error) {
+func mapNomadDebugs(ctx context.Context, cfgs []NomadDebug, tmpDir string, debugDuration time.Duration, debugInterval time.Duration, redactions []*redact.Redact, p *redact.Pseudonymizer) ([]runner.Runner, error) {
 	runners := make([]runner.Runner, len(cfgs))
 
 	for i, d := range cfgs {
-		runnerRedacts, err := MapRedacts(d.Redactions)
+		runnerRedacts, err := MapRedacts(d.Redactions, p)
 		if err != nil {
 			return nil, err
 		}
@@ -685,7 +807,7 @@ func mapNomadDebugs(ctx context.Context, cfgs []NomadDebug, tmpDir string, debug
 			EventTopic:    d.EventTopic,
 			Redactions:    runnerRedacts,
 		}
-		dbg, err := debug.NewNomadDebug(cfg, tmpDir, debugDuration, debugInterval)
+		dbg, err := debug.NewNomadDebugWithContext(ctx, cfg, tmpDir, debugDuration, debugInterval)
 		if err != nil {
 			return nil, err
 		}
@@ -703,8 +825,9 @@ func ProductsMap(products []*Product) map[string]*Product {
 	return m
 }
 
-// MapRedacts maps HCL redactions to "real" `redact.Redact`s
-func MapRedacts(redactions []Redact) ([]*redact.Redact, error) {
+// MapRedacts maps HCL redactions to "real" `redact.Redact`s. Those with a pseudonym share p, so that they give a value
+// the same pseudonym as every other redaction in the run that uses p.
+func MapRedacts(redactions []Redact, p *redact.Pseudonymizer) ([]*redact.Redact, error) {
 	err := ValidateRedactions(redactions)
 	if err != nil {
 		return nil, err
@@ -712,13 +835,22 @@ func MapRedacts(redactions []Redact) ([]*redact.Redact, error) {
 
 	s := make([]*redact.Redact, len(redactions))
 	for i, r := range redactions {
-		// TODO(mkcp): Implement literals and `switch r.Label {}`
+		// TODO(mkcp): Implement literals
 		cfg := redact.Config{
-			Matcher: r.Match,
-			ID:      r.ID,
-			Replace: r.Replace,
+			Matcher:       r.Match,
+			ID:            r.ID,
+			Replace:       r.Replace,
+			Pseudonymizer: p,
+		}
+		var red *redact.Redact
+		switch {
+		case r.Label == "path":
+			red, err = redact.NewPath(cfg, r.Path)
+		case r.Pseudonym != "":
+			red, err = redact.NewPseudonym(cfg, r.Pseudonym)
+		default:
+			red, err = redact.New(cfg)
 		}
-		red, err := redact.New(cfg)
 		if err != nil {
 			return nil, err
 		}
@@ -727,16 +859,75 @@ func MapRedacts(redactions []Redact) ([]*redact.Redact, error) {
 	return s, nil
 }
 
+// MapAPI maps the api block of the product named product to a client.UserDefinedAPIConfig. A nil api maps to a config
+// with no address.
+func MapAPI(product string, api *API) client.UserDefinedAPIConfig {
+	if api == nil {
+		return client.UserDefinedAPIConfig{Product: product}
+	}
+	return client.UserDefinedAPIConfig{
+		Product:          product,
+		Address:          api.Address,
+		AddressEnv:       api.AddressEnv,
+		AuthEnv:          api.AuthEnv,
+		AuthHeader:       api.AuthHeader,
+		AuthScheme:       api.AuthScheme,
+		CACertEnv:        api.CACertEnv,
+		CAPathEnv:        api.CAPathEnv,
+		ClientCertEnv:    api.ClientCertEnv,
+		ClientKeyEnv:     api.ClientKeyEnv,
+		TLSServerNameEnv: api.TLSServerNameEnv,
+		TLSInsecureEnv:   api.TLSInsecureEnv,
+	}
+}
+
+// MapUpload maps an HCL upload block to an upload.Config.
+func MapUpload(u *Upload) (upload.Config, error) {
+	cfg := upload.Config{
+		Endpoint: u.Endpoint,
+		Bucket:   u.Bucket,
+		Prefix:   u.Prefix,
+		Region:   u.Region,
+		Retries:  u.Retries,
+		Credentials: upload.Credentials{
+			AccessKeyID:     u.AccessKeyID,
+			SecretAccessKey: u.SecretAccessKey,
+			SessionToken:    u.SessionToken,
+		},
+	}
+	if u.PartSize != "" {
+		size, err := util.ParseSize(u.PartSize)
+		if err != nil {
+			return upload.Config{}, fmt.Errorf("invalid part_size: %w", err)
+		}
+		if size < upload.MinPartSize {
+			return upload.Config{}, fmt.Errorf("part_size must be at least 5MiB, got %s", u.PartSize)
+		}
+		cfg.PartSize = size
+	}
+	return cfg, nil
+}
+
 // ValidateRedactions takes a slice of redactions and ensures they match valid names.
 func ValidateRedactions(redactions []Redact) error {
 	hclog.L().Trace("hcl.ValidateRedactions()", "redactions", redactions)
 	for _, r := range redactions {
 		switch r.Label {
 		case "regex":
+			if r.Match == "" || r.Path != "" {
+				return fmt.Errorf("regex redactions must have a match, and no path, match=%s, path=%s", r.Match, r.Path)
+			}
 			_, err := regexp.Compile(r.Match)
 			if err != nil {
 				return fmt.Errorf("could not compile regex, matcher=%s, err=%s", r.Match, err)
 			}
+		case "path":
+			if r.Match != "" || r.Pseudonym != "" {
+				return fmt.Errorf("path redactions can't have a match or a pseudonym, path=%s", r.Path)
+			}
+			if err := redact.ValidatePath(r.Path); err != nil {
+				return err
+			}
 		// TODO(mkcp): Validate literals when they are implemented
 		// case "literal":
 		// 	continue
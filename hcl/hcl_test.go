@@ -5,15 +5,22 @@ package hcl
 
 import (
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/hashicorp/hcdiag/analyze"
 	"github.com/hashicorp/hcdiag/redact"
 	"github.com/hashicorp/hcdiag/runner"
+	"github.com/hashicorp/hcdiag/runner/do"
+	"github.com/hashicorp/hcdiag/upload"
 
 	"github.com/hashicorp/hcdiag/client"
 
+	hcl2 "github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParse(t *testing.T) {
@@ -141,6 +148,18 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseRules(t *testing.T) {
+	res, err := Parse("../tests/resources/config/rules.hcl")
+	require.NoError(t, err)
+	require.Len(t, res.Rules, 1)
+
+	r := res.Rules[0]
+	assert.Equal(t, "host-recently-rebooted", r.Name)
+	assert.Equal(t, "host", r.Product)
+	assert.Equal(t, analyze.Info, r.Severity)
+	assert.NoError(t, r.Validate())
+}
+
 func TestBuildRunners(t *testing.T) {
 	testCases := []struct {
 		name   string
@@ -226,6 +245,66 @@ func TestBuildRunners(t *testing.T) {
 	}
 }
 
+func TestBuildRunnersDependsOn(t *testing.T) {
+	t.Run("wraps runners with dependencies in a graph", func(t *testing.T) {
+		h, err := Parse("../tests/resources/config/depends_on.hcl")
+		require.NoError(t, err)
+
+		runners, err := BuildRunners(h.Host, t.TempDir(), 2*time.Minute, 30*time.Second, nil, time.Time{}, time.Time{}, nil)
+		require.NoError(t, err)
+		require.Len(t, runners, 2)
+		assert.Equal(t, "echo independent", runners[0].ID())
+
+		g, ok := runners[1].(*do.Graph)
+		require.True(t, ok, "expected the last runner to be a graph, got %T", runners[1])
+		assert.Equal(t, "graph host", g.ID())
+		assert.Len(t, g.Runners, 2)
+	})
+
+	testCases := []struct {
+		name    string
+		path    string
+		summary string
+		line    int
+	}{
+		{
+			name:    "rejects cycles",
+			path:    "../tests/resources/config/depends_on_cycle.hcl",
+			summary: "Dependency cycle",
+			line:    15,
+		},
+		{
+			name:    "rejects unknown references",
+			path:    "../tests/resources/config/depends_on_unknown.hcl",
+			summary: "Reference to unknown runner id",
+			line:    8,
+		},
+		{
+			name:    "rejects duplicate ids",
+			path:    "../tests/resources/config/depends_on_duplicate.hcl",
+			summary: "Duplicate runner id",
+			line:    13,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			h, err := Parse(tc.path)
+			require.NoError(t, err)
+
+			_, err = BuildRunners(h.Host, t.TempDir(), 2*time.Minute, 30*time.Second, nil, time.Time{}, time.Time{}, nil)
+			require.Error(t, err)
+
+			var diags hcl2.Diagnostics
+			require.ErrorAs(t, err, &diags)
+			require.Len(t, diags, 1)
+			assert.Equal(t, tc.summary, diags[0].Summary)
+			require.NotNil(t, diags[0].Subject)
+			assert.Equal(t, tc.line, diags[0].Subject.Start.Line)
+		})
+	}
+}
+
 func TestMapDockerLogs(t *testing.T) {
 	defaultDest := "/some/path"
 	defaultSince := time.Now()
@@ -277,7 +356,7 @@ func TestMapDockerLogs(t *testing.T) {
 	}
 
 	for _, tc := range cases {
-		runners, err := mapDockerLogs(context.Background(), tc.config, defaultDest, defaultSince, nil)
+		runners, err := mapDockerLogs(context.Background(), tc.config, defaultDest, defaultSince, nil, nil)
 		assert.NoError(t, err)
 		assert.Len(t, runners, tc.expected)
 	}
@@ -341,7 +420,7 @@ func TestMapJournaldLogs(t *testing.T) {
 	}
 
 	for _, tc := range cases {
-		runners, err := mapJournaldLogs(context.Background(), tc.config, defaultDest, defaultSince, defaultUntil, tc.redactions)
+		runners, err := mapJournaldLogs(context.Background(), tc.config, defaultDest, defaultSince, defaultUntil, tc.redactions, nil)
 		assert.NoError(t, err)
 		assert.Len(t, runners, tc.expected)
 	}
@@ -471,3 +550,72 @@ func TestValidateRedactions(t *testing.T) {
 		assert.Error(t, ValidateRedactions(tc.redactions), tc)
 	}
 }
+
+func TestMapRedactsPseudonym(t *testing.T) {
+	p := redact.NewPseudonymizer()
+	redactions, err := MapRedacts([]Redact{{Label: "regex", ID: "ip", Match: `\d+\.\d+\.\d+\.\d+`, Pseudonym: "IP"}}, p)
+	require.NoError(t, err)
+	require.Len(t, redactions, 1)
+	assert.Equal(t, "IP", redactions[0].Pseudonym)
+
+	// The redaction uses the Pseudonymizer it was given.
+	s, err := redact.String("10.0.0.1", redactions)
+	require.NoError(t, err)
+	assert.Equal(t, p.Pseudonym("IP", "10.0.0.1"), s)
+
+	_, err = MapRedacts([]Redact{{Label: "regex", Match: "x", Replace: "y", Pseudonym: "IP"}}, nil)
+	assert.Error(t, err)
+}
+
+func TestMapRedactsPath(t *testing.T) {
+	redactions, err := MapRedacts([]Redact{{Label: "path", ID: "passwords", Path: "**.password", Replace: "<PASSWORD>"}}, nil)
+	require.NoError(t, err)
+	require.Len(t, redactions, 1)
+	assert.Equal(t, "**.password", redactions[0].Path)
+	assert.Equal(t, "<PASSWORD>", redactions[0].Replace)
+
+	_, err = MapRedacts([]Redact{{Label: "path", Path: "**.password", Match: "x"}}, nil)
+	assert.Error(t, err)
+	_, err = MapRedacts([]Redact{{Label: "regex", Path: "**.password"}}, nil)
+	assert.Error(t, err)
+}
+
+func TestParseRedactions(t *testing.T) {
+	redactions, err := ParseRedactions("../tests/resources/config/redactions.hcl")
+	require.NoError(t, err)
+	require.Len(t, redactions, 2)
+	assert.Equal(t, "hostname", redactions[0].ID)
+	assert.Equal(t, "<HOSTNAME>", redactions[0].Replace)
+	assert.Equal(t, "**.version", redactions[1].Path)
+
+	invalid := filepath.Join(t.TempDir(), "invalid.hcl")
+	require.NoError(t, os.WriteFile(invalid, []byte(`redact "path" { path = "data..secret_id" }`), 0600))
+	_, err = ParseRedactions(invalid)
+	assert.Error(t, err)
+}
+
+func TestMapUpload(t *testing.T) {
+	cfg, err := MapUpload(&Upload{
+		Endpoint:        "http://minio:9000",
+		Bucket:          "support-bundles",
+		Prefix:          "vault/",
+		PartSize:        "32MiB",
+		Retries:         5,
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, upload.Config{
+		Endpoint:    "http://minio:9000",
+		Bucket:      "support-bundles",
+		Prefix:      "vault/",
+		PartSize:    32 << 20,
+		Retries:     5,
+		Credentials: upload.Credentials{AccessKeyID: "key", SecretAccessKey: "secret"},
+	}, cfg)
+
+	_, err = MapUpload(&Upload{Endpoint: "http://minio:9000", Bucket: "b", PartSize: "1MiB"})
+	assert.Error(t, err)
+	_, err = MapUpload(&Upload{Endpoint: "http://minio:9000", Bucket: "b", PartSize: "lots"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,345 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	hcl2 "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/hashicorp/hcdiag/client"
+	"github.com/hashicorp/hcdiag/redact"
+	"github.com/hashicorp/hcdiag/upload"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+// durationAttributes are the attributes, in any block, whose values must parse with time.ParseDuration.
+var durationAttributes = map[string]bool{
+	"timeout":          true,
+	"since":            true,
+	"duration":         true,
+	"interval":         true,
+	"metrics-interval": true,
+	"pprof-duration":   true,
+	"pprof-interval":   true,
+}
+
+// Validate parses the config file at path and reports every problem with it at once, each with the range of source
+// that caused it. Along with HCL syntax and schema errors, it checks the values that a run would otherwise reject, such
+// as durations, redactions, runner dependencies, and rules, and product labels against productNames. It then builds the
+// runners for each valid block, without running them. The parsed files are returned so that the diagnostics can be
+// rendered with snippets of source.
+func Validate(path string, productNames []string) (map[string]*hcl2.File, hcl2.Diagnostics) {
+	parser := hclparse.NewParser()
+	var f *hcl2.File
+	var diags hcl2.Diagnostics
+	if strings.HasSuffix(path, ".json") {
+		f, diags = parser.ParseJSONFile(path)
+	} else {
+		f, diags = parser.ParseHCLFile(path)
+	}
+	if diags.HasErrors() {
+		return parser.Files(), diags
+	}
+
+	// Schema errors, such as unknown or missing attributes, leave the config in an unknown state, so stop here.
+	var h HCL
+	diags = append(diags, gohcl.DecodeBody(f.Body, nil, &h)...)
+	if diags.HasErrors() {
+		return parser.Files(), diags
+	}
+
+	v := validator{products: make(map[string]bool, len(productNames))}
+	for _, name := range productNames {
+		v.products[name] = true
+	}
+	content, _ := f.Body.Content(schemaFor(reflect.TypeOf(h)))
+	// Blocks of each type are decoded into h in the order they appear, so count them to find the decoded value.
+	seen := make(map[string]int)
+	for _, block := range content.Blocks {
+		i := seen[block.Type]
+		seen[block.Type]++
+
+		// Only build the runners for blocks that don't already have problems, to avoid reporting them twice.
+		before := len(v.diags)
+		v.block(block, reflect.TypeOf(h))
+		if len(v.diags) != before {
+			continue
+		}
+		v.build(block, h, i)
+	}
+
+	// Blocks' attributes are checked before their nested blocks, so put the diagnostics back in source order.
+	sort.SliceStable(v.diags, func(i, j int) bool {
+		si, sj := v.diags[i].Subject, v.diags[j].Subject
+		if si == nil || sj == nil {
+			return si != nil
+		}
+		return si.Start.Byte < sj.Start.Byte
+	})
+	return parser.Files(), append(diags, v.diags...)
+}
+
+// validator walks the blocks of a config, collecting diagnostics.
+type validator struct {
+	products map[string]bool
+	diags    hcl2.Diagnostics
+}
+
+// block validates a block, whose contents are decoded into a field of parent, and everything nested in it.
+func (v *validator) block(block *hcl2.Block, parent reflect.Type) {
+	t, ok := blockTypes(parent)[block.Type]
+	if !ok {
+		return
+	}
+
+	if block.Type == "redact" && block.Labels[0] != "regex" && block.Labels[0] != "path" {
+		v.errorf(block.LabelRanges[0], "Invalid redaction type", "%q is not a redaction type; expected \"regex\" or \"path\".", block.Labels[0])
+	}
+
+	content, _ := block.Body.Content(schemaFor(t))
+	switch block.Type {
+	case "product":
+		v.product(block, content)
+	case "api":
+		if content.Attributes["address"] == nil && content.Attributes["address_env"] == nil {
+			v.errorf(block.DefRange, "Missing API address", "An api block needs an address, an address_env, or both.")
+		}
+	case "redact":
+		v.redact(block, content)
+	}
+	// Check attributes in source order, so that diagnostics are too.
+	attrs := make([]*hcl2.Attribute, 0, len(content.Attributes))
+	for _, attr := range content.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool { return attrs[i].Range.Start.Byte < attrs[j].Range.Start.Byte })
+	for _, attr := range attrs {
+		v.attribute(block.Type, attr)
+	}
+	for _, nested := range content.Blocks {
+		v.block(nested, t)
+	}
+}
+
+// product checks that a product block either configures a built-in product, or defines a product of its own with an
+// api block or a healthcheck, which built-in products can't have.
+func (v *validator) product(block *hcl2.Block, content *hcl2.BodyContent) {
+	name := block.Labels[0]
+	var api *hcl2.Block
+	var gets []*hcl2.Block
+	for _, nested := range content.Blocks {
+		switch nested.Type {
+		case "api":
+			api = nested
+		case "GET":
+			gets = append(gets, nested)
+		}
+	}
+	healthcheck := content.Attributes["healthcheck"]
+
+	switch {
+	case v.products[name]:
+		if api != nil {
+			v.errorf(api.DefRange, "Unsupported block type", "%q is built into hcdiag, so it can't have an api block.", name)
+		}
+		if healthcheck != nil {
+			v.errorf(healthcheck.Range, "Unsupported argument", "%q is built into hcdiag, so it can't have a healthcheck.", name)
+		}
+	case name == "host":
+		v.errorf(block.LabelRanges[0], "Reserved product name", "The host is configured with a host block, rather than a product block.")
+	case api == nil && healthcheck == nil:
+		v.errorf(block.LabelRanges[0], "Unknown product", "%q is not a product that hcdiag supports; expected one of %s, or an api block or a healthcheck to define a product of your own.", name, v.productList())
+	case api == nil:
+		for _, get := range gets {
+			v.errorf(get.DefRange, "Missing api block", "GET runners in %q need an api block to tell hcdiag where its API is.", name)
+		}
+	}
+}
+
+// redact checks that a redact block has the attributes that its type needs, and no conflicting ones.
+func (v *validator) redact(block *hcl2.Block, content *hcl2.BodyContent) {
+	attrs := content.Attributes
+	if _, ok := attrs["replace"]; ok {
+		if pseudonym, ok := attrs["pseudonym"]; ok {
+			v.errorf(pseudonym.Range, "Conflicting redaction replacements", "A redaction can have a replace or a pseudonym, but not both.")
+		}
+	}
+
+	want, unwanted := "match", "path"
+	if block.Labels[0] == "path" {
+		want, unwanted = "path", "match"
+		if pseudonym, ok := attrs["pseudonym"]; ok {
+			v.errorf(pseudonym.Range, "Unsupported argument", "Path redactions can't have a pseudonym.")
+		}
+	}
+	if _, ok := attrs[want]; !ok {
+		v.errorf(block.DefRange, "Missing required argument", "A %q redaction needs a %s.", block.Labels[0], want)
+	}
+	if attr, ok := attrs[unwanted]; ok {
+		v.errorf(attr.Range, "Unsupported argument", "A %q redaction can't have a %s.", block.Labels[0], unwanted)
+	}
+}
+
+// attribute validates the value of attr, in a block of type blockType.
+func (v *validator) attribute(blockType string, attr *hcl2.Attribute) {
+	name := attr.Name
+	val, diags := attr.Expr.Value(nil)
+	if diags.HasErrors() || val.IsNull() || !val.IsKnown() {
+		// Expressions, such as those in rules, are evaluated later; only static values are checked here.
+		return
+	}
+	rng := attr.Expr.Range()
+
+	switch {
+	case durationAttributes[name] && val.Type() == cty.String:
+		if s := val.AsString(); s != "" {
+			if _, err := time.ParseDuration(s); err != nil {
+				v.errorf(rng, "Invalid duration", "The %s %q is not a valid duration, such as \"30s\" or \"2h45m\".", name, s)
+			}
+		}
+	case name == "format" && blockType == "command" && val.Type() == cty.String:
+		switch strings.ToLower(val.AsString()) {
+		case "", "string", "json":
+		default:
+			v.errorf(rng, "Invalid command format", "The format %q is not supported; expected \"string\" or \"json\".", val.AsString())
+		}
+	case name == "match" && blockType == "redact" && val.Type() == cty.String:
+		if _, err := regexp.Compile(val.AsString()); err != nil {
+			v.errorf(rng, "Invalid regular expression", "The match %q does not compile: %s.", val.AsString(), err)
+		}
+	case name == "path" && blockType == "redact" && val.Type() == cty.String:
+		if err := redact.ValidatePath(val.AsString()); err != nil {
+			v.errorf(rng, "Invalid path", "The redaction path is invalid: %s.", err)
+		}
+	case name == "pseudonym" && blockType == "redact" && val.Type() == cty.String:
+		if err := redact.ValidatePseudonym(val.AsString()); err != nil {
+			v.errorf(rng, "Invalid pseudonym prefix", "The pseudonym %q must start with a letter, and contain only letters, digits, and underscores.", val.AsString())
+		}
+	case name == "max_concurrency" && val.Type() == cty.Number:
+		if n, _ := val.AsBigFloat().Int64(); n < 0 {
+			v.errorf(rng, "Invalid max_concurrency", "max_concurrency must be zero, for no limit, or a positive number.")
+		}
+	case name == "disable_redactions" && (val.Type().IsListType() || val.Type().IsTupleType()):
+		known := make(map[string]bool)
+		for _, id := range redact.PatternIDs() {
+			known[id] = true
+		}
+		exprs, _ := hcl2.ExprList(attr.Expr)
+		for _, expr := range exprs {
+			idv, diags := expr.Value(nil)
+			if diags.HasErrors() || idv.IsNull() || !idv.IsKnown() || idv.Type() != cty.String {
+				continue
+			}
+			if !known[idv.AsString()] {
+				v.errorf(expr.Range(), "Unknown built-in redaction", "%q is not the ID of a built-in redaction; expected one of %s.", idv.AsString(), strings.Join(redact.PatternIDs(), ", "))
+			}
+		}
+	case name == "address" && blockType == "api" && val.Type() == cty.String:
+		if u, err := url.Parse(val.AsString()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.errorf(rng, "Invalid address", "The address %q is not a URL such as \"https://127.0.0.1:8443\".", val.AsString())
+		}
+	case name == "endpoint" && blockType == "upload" && val.Type() == cty.String:
+		if u, err := url.Parse(val.AsString()); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			v.errorf(rng, "Invalid endpoint", "The endpoint %q is not a URL such as \"https://s3.us-east-1.amazonaws.com\".", val.AsString())
+		}
+	case name == "part_size" && blockType == "upload" && val.Type() == cty.String:
+		size, err := util.ParseSize(val.AsString())
+		if err != nil {
+			v.errorf(rng, "Invalid part_size", "The part_size %q is not a size, such as \"16MiB\".", val.AsString())
+		} else if size < upload.MinPartSize {
+			v.errorf(rng, "Invalid part_size", "The part_size %q is too small; S3 needs parts of at least 5MiB.", val.AsString())
+		}
+	case (name == "selects" || name == "excludes") && (val.Type().IsListType() || val.Type().IsTupleType()):
+		exprs, _ := hcl2.ExprList(attr.Expr)
+		for _, expr := range exprs {
+			pv, diags := expr.Value(nil)
+			if diags.HasErrors() || pv.IsNull() || !pv.IsKnown() || pv.Type() != cty.String {
+				continue
+			}
+			if _, err := filepath.Match(pv.AsString(), ""); err != nil {
+				v.errorf(expr.Range(), "Invalid pattern", "The pattern %q in %s is malformed: %s.", pv.AsString(), name, err)
+			}
+		}
+	}
+}
+
+// build builds the runners for a top-level block, which is the i-th block of its type, or validates it if it is a
+// rule. Errors are reported at the block's range.
+func (v *validator) build(block *hcl2.Block, h HCL, i int) {
+	var err error
+	switch block.Type {
+	case "host":
+		_, err = BuildRunnersWithContext(context.Background(), h.Host, "", 0, 0, nil, time.Time{}, time.Time{}, nil, nil, hclog.NewNullLogger())
+	case "product":
+		_, err = BuildRunnersWithContext(context.Background(), h.Products[i], "", 0, 0, &client.APIClient{}, time.Time{}, time.Time{}, nil, nil, hclog.NewNullLogger())
+	case "rule":
+		err = h.Rules[i].Validate()
+	}
+	if err == nil {
+		return
+	}
+	if diags, ok := err.(hcl2.Diagnostics); ok {
+		v.diags = append(v.diags, diags...)
+		return
+	}
+	v.errorf(block.DefRange, fmt.Sprintf("Invalid %s block", block.Type), "%s.", err)
+}
+
+func (v *validator) errorf(rng hcl2.Range, summary, format string, args ...any) {
+	v.diags = append(v.diags, &hcl2.Diagnostic{
+		Severity: hcl2.DiagError,
+		Summary:  summary,
+		Detail:   fmt.Sprintf(format, args...),
+		Subject:  rng.Ptr(),
+	})
+}
+
+func (v *validator) productList() string {
+	names := make([]string, 0, len(v.products))
+	for name := range v.products {
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// schemaFor returns the schema of the HCL struct type t, or of the struct that t points to or holds a slice of.
+func schemaFor(t reflect.Type) *hcl2.BodySchema {
+	schema, _ := gohcl.ImpliedBodySchema(reflect.New(structType(t)).Interface())
+	return schema
+}
+
+// blockTypes maps the name of each type of block that may be nested in the HCL struct type t to its struct type.
+func blockTypes(t reflect.Type) map[string]reflect.Type {
+	t = structType(t)
+	types := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, kind, _ := strings.Cut(field.Tag.Get("hcl"), ",")
+		if kind == "block" {
+			types[name] = structType(field.Type)
+		}
+	}
+	return types
+}
+
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
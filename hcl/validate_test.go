@@ -0,0 +1,102 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testProducts = []string{"boundary", "consul", "nomad", "terraform-ent", "vault"}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		path   string
+		expect []string
+	}{
+		{
+			name: "Valid config has no diagnostics",
+			path: "../tests/resources/config/config.hcl",
+		},
+		{
+			name:   "Unknown dependency is reported",
+			path:   "../tests/resources/config/depends_on_unknown.hcl",
+			expect: []string{"Reference to unknown runner id"},
+		},
+		{
+			name:   "Unknown built-in redaction is reported",
+			path:   "../tests/resources/config/disable_redactions_unknown.hcl",
+			expect: []string{"Unknown built-in redaction"},
+		},
+		{
+			name:   "Invalid pseudonyms are reported",
+			path:   "../tests/resources/config/pseudonym_invalid.hcl",
+			expect: []string{"Conflicting redaction replacements", "Invalid pseudonym prefix"},
+		},
+		{
+			name:   "Invalid path redactions are reported",
+			path:   "../tests/resources/config/path_redactions_invalid.hcl",
+			expect: []string{"Invalid path", "Unsupported argument", "Missing required argument", "Unsupported argument"},
+		},
+		{
+			name:   "Invalid upload endpoint and part size are reported",
+			path:   "../tests/resources/config/upload_invalid.hcl",
+			expect: []string{"Invalid endpoint", "Invalid part_size"},
+		},
+		{
+			name:   "User-defined product has no diagnostics",
+			path:   "../tests/resources/config/user_defined_product.hcl",
+			expect: []string{},
+		},
+		{
+			name:   "Invalid user-defined products are reported",
+			path:   "../tests/resources/config/user_defined_product_invalid.hcl",
+			expect: []string{"Unsupported argument", "Missing api block", "Missing API address", "Invalid address"},
+		},
+		{
+			name:   "Missing file is reported",
+			path:   "../tests/resources/config/missing.hcl",
+			expect: []string{"Failed to read file"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, diags := Validate(tc.path, testProducts)
+			summaries := make([]string, 0)
+			for _, d := range diags {
+				summaries = append(summaries, d.Summary)
+			}
+			assert.ElementsMatch(t, tc.expect, summaries)
+		})
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	files, diags := Validate("../tests/resources/config/invalid.hcl", testProducts)
+	require.NotEmpty(t, files)
+
+	type problem struct {
+		summary string
+		line    int
+	}
+	problems := make([]problem, len(diags))
+	for i, d := range diags {
+		require.NotNil(t, d.Subject, d.Summary)
+		problems[i] = problem{d.Summary, d.Subject.Start.Line}
+	}
+	assert.Equal(t, []problem{
+		{"Invalid max_concurrency", 5},
+		{"Invalid regular expression", 7},
+		{"Invalid command format", 14},
+		{"Invalid duration", 15},
+		{"Invalid pattern", 17},
+		{"Unknown product", 20},
+		{"Invalid redaction type", 27},
+		{"Invalid rule block", 38},
+	}, problems)
+}
@@ -37,9 +37,16 @@ func realMain() (returnCode int) {
 		// within the various command packages seemed like a clean starting point.
 		Commands: map[string]cli.CommandFactory{
 			// The empty string key is what will happen when no subcommands are provided to hcdiag.
-			"":        command.RunCommandFactory(ui),
-			"run":     command.RunCommandFactory(ui),
-			"version": command.VersionCommandFactory(ui),
+			"":         command.RunCommandFactory(ui),
+			"decrypt":  command.DecryptCommandFactory(ui),
+			"diff":     command.DiffCommandFactory(ui),
+			"validate": command.ValidateCommandFactory(ui),
+			"verify":   command.VerifyCommandFactory(ui),
+			"inspect":  command.InspectCommandFactory(ui),
+			"join":     command.JoinCommandFactory(ui),
+			"redact":   command.RedactCommandFactory(ui),
+			"run":      command.RunCommandFactory(ui),
+			"version":  command.VersionCommandFactory(ui),
 		},
 
 		HiddenCommands: []string{
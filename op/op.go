@@ -43,6 +43,12 @@ type Op struct {
 	Params     map[string]interface{} `json:"params,omitempty"`
 	Start      time.Time              `json:"start"`
 	End        time.Time              `json:"end"`
+	// QueueWait is how long the op waited for a free slot before it started, when runners are scheduled with a
+	// concurrency limit. It is reported in manifest.json rather than results.json.
+	QueueWait time.Duration `json:"-"`
+	// Redactions counts the replacements made by each redaction while running the op, by redaction ID. It is
+	// summarized in manifest.json; the text that was replaced is never recorded.
+	Redactions map[string]int `json:"-"`
 }
 
 // New takes a runner its results, serializing it into an immutable Op struct.
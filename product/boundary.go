@@ -0,0 +1,171 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package product
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/hcdiag/client"
+	"github.com/hashicorp/hcdiag/hcl"
+	"github.com/hashicorp/hcdiag/redact"
+	"github.com/hashicorp/hcdiag/runner"
+	"github.com/hashicorp/hcdiag/runner/do"
+	logs "github.com/hashicorp/hcdiag/runner/log"
+)
+
+const (
+	BoundaryClientCheck = "boundary version"
+	// BoundaryConfigPath is where packaged installs of Boundary keep controller and worker configs.
+	BoundaryConfigPath = "/etc/boundary.d/*.hcl"
+)
+
+// NewBoundary takes a logger and product config, and it creates a Product with all of Boundary's default runners.
+func NewBoundary(logger hclog.Logger, cfg Config) (*Product, error) {
+	return NewBoundaryWithContext(context.Background(), logger, cfg)
+}
+
+// NewBoundaryWithContext takes a context, a logger, and product config, and it creates a Product with all of
+// Boundary's default runners.
+func NewBoundaryWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
+	return build(ctx, logger, boundaryRegistration(), cfg)
+}
+
+// boundaryRegistration returns the Registration of Boundary, which is autodetected by its CLI. Boundary's CLI has no
+// agent check, so only the client is checked.
+func boundaryRegistration() Registration {
+	return Registration{
+		Name:   Boundary,
+		Detect: detectCommand("boundary"),
+		CheckAvailable: func(ctx context.Context) error {
+			return CommandHealthCheckWithContext(ctx, BoundaryClientCheck, "")
+		},
+		Redactions: boundaryRedactions,
+		New:        newBoundary,
+	}
+}
+
+// newBoundary creates a Product with all of Boundary's default runners, from a cfg whose redactions already include
+// Boundary's defaults.
+func newBoundary(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
+	product := &Product{
+		l:      logger.Named("product"),
+		Name:   Boundary,
+		Config: cfg,
+	}
+	api, err := client.NewBoundaryAPI()
+	if err != nil {
+		return nil, err
+	}
+	opsAPI, err := client.NewBoundaryOpsAPI()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.HCL != nil {
+		// Map product-specific redactions from our config
+		hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions, cfg.Pseudonymizer)
+		if err != nil {
+			return nil, err
+		}
+		// Prepend product HCL redactions to our product defaults
+		cfg.Redactions = redact.Flatten(hclProductRedactions, cfg.Redactions)
+
+		hclRunners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, cfg.Redactions, cfg.Pseudonymizer, logger)
+		if err != nil {
+			return nil, err
+		}
+		product.Runners = append(product.Runners, hclRunners...)
+		product.Excludes = cfg.HCL.Excludes
+		product.Selects = cfg.HCL.Selects
+	}
+
+	// Add built-in runners
+	builtInRunners, err := boundaryRunners(ctx, cfg, opsAPI, product.l)
+	if err != nil {
+		return nil, err
+	}
+	product.Runners = append(product.Runners, builtInRunners...)
+
+	return product, nil
+}
+
+// boundaryRunners provides a list of default runners to inspect Boundary controllers and workers. opsAPI is a client
+// for the ops listener, which serves the health endpoint.
+func boundaryRunners(ctx context.Context, cfg Config, opsAPI *client.APIClient, l hclog.Logger) ([]runner.Runner, error) {
+	var r []runner.Runner
+
+	version, err := runner.NewCommandWithContext(ctx, runner.CommandConfig{Command: "boundary version", Redactions: cfg.Redactions})
+	if err != nil {
+		return nil, err
+	}
+	r = append(r, version)
+
+	health, err := runner.NewHTTPWithContext(ctx, runner.HttpConfig{Client: opsAPI, Path: "/health?worker_info=1", Redactions: cfg.Redactions})
+	if err != nil {
+		return nil, err
+	}
+	r = append(r, health)
+
+	// Controller and worker configs are copied whenever they were last modified, so they aren't filtered by time.
+	configCopy, err := runner.NewCopyWithContext(ctx, runner.CopyConfig{
+		Path:       BoundaryConfigPath,
+		DestDir:    filepath.Join(cfg.TmpDir, "config/boundary"),
+		Redactions: cfg.Redactions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r = append(r, configCopy)
+
+	r = append(r,
+		logs.NewDockerWithContext(ctx,
+			logs.DockerConfig{
+				Container:  "boundary",
+				DestDir:    cfg.TmpDir,
+				Since:      cfg.Since,
+				Redactions: cfg.Redactions,
+			}),
+		logs.NewJournaldWithContext(ctx,
+			logs.JournaldConfig{
+				Service:    "boundary",
+				DestDir:    cfg.TmpDir,
+				Since:      cfg.Since,
+				Until:      cfg.Until,
+				Redactions: cfg.Redactions}),
+	)
+
+	runners := []runner.Runner{
+		do.NewWithContext(ctx, l, "boundary", "boundary runners", r),
+	}
+	return runners, nil
+}
+
+// boundaryRedactions returns a slice of default redactions for this product. Controller and worker configs hold KMS
+// keys and worker activation tokens in plain text, and Boundary's own auth tokens may turn up in logs.
+func boundaryRedactions() ([]*redact.Redact, error) {
+	configs := []redact.Config{
+		{
+			ID:      "boundary-kms-key",
+			Matcher: `(?m)(^\s*key\s*=\s*)"[^"]*"`,
+			Replace: `${1}"` + redact.DefaultReplace + `"`,
+		},
+		{
+			ID:      "boundary-activation-token",
+			Matcher: `((?:controller_generated_activation_token|activation_token)\s*=\s*)"[^"]*"`,
+			Replace: `${1}"` + redact.DefaultReplace + `"`,
+		},
+		{
+			ID:      "boundary-auth-token",
+			Matcher: `\bat_[0-9A-Za-z]{10}_[0-9A-Za-z]{20,}`,
+		},
+	}
+	redactions, err := redact.MapNew(configs)
+	if err != nil {
+		return nil, err
+	}
+	return redactions, nil
+}
@@ -0,0 +1,61 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package product
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/redact"
+)
+
+func TestBoundaryRedactions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "kms key",
+			input:    "kms \"aead\" {\n  purpose = \"root\"\n  key = \"sP1fnF5Xz85RrXyELHFeZg9Ad2qt4Z4bgNHVGtD6ung=\"\n}",
+			expected: "kms \"aead\" {\n  purpose = \"root\"\n  key = \"<REDACTED>\"\n}",
+		},
+		{
+			name:     "worker activation token",
+			input:    `controller_generated_activation_token = "neslat_2KqFcXGmeN6DH3pLqkHZAFJ4R2FTcvQwJyrsKtHyTgrZ"`,
+			expected: `controller_generated_activation_token = "<REDACTED>"`,
+		},
+		{
+			name:     "auth token",
+			input:    "token: at_1234567890_s1HHcYdhRD1bJgqYqyNQNzNaMdMCwh3K6xVhQSYA2uKZ in request",
+			expected: "token: <REDACTED> in request",
+		},
+		{
+			name:     "other keys are untouched",
+			input:    `key_id = "global_root"`,
+			expected: `key_id = "global_root"`,
+		},
+	}
+
+	redactions, err := boundaryRedactions()
+	require.NoError(t, err)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := redact.String(tc.input, redactions)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestNewBoundary(t *testing.T) {
+	p, err := NewBoundary(hclog.NewNullLogger(), Config{TmpDir: t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, Boundary, p.Name)
+	require.Len(t, p.Runners, 1)
+	assert.Equal(t, "do boundary", p.Runners[0].ID())
+}
@@ -31,13 +31,25 @@ func NewConsul(logger hclog.Logger, cfg Config) (*Product, error) {
 
 // NewConsulWithContext takes a context, a logger, and product config, and it creates a Product with all of Consul's default runners.
 func NewConsulWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
-	// Prepend product-specific redactions to agent-level redactions from cfg
-	defaultRedactions, err := consulRedactions()
-	if err != nil {
-		return nil, err
+	return build(ctx, logger, consulRegistration(), cfg)
+}
+
+// consulRegistration returns the Registration of Consul, which is autodetected by its CLI.
+func consulRegistration() Registration {
+	return Registration{
+		Name:   Consul,
+		Detect: detectCommand("consul"),
+		CheckAvailable: func(ctx context.Context) error {
+			return CommandHealthCheckWithContext(ctx, ConsulClientCheck, ConsulAgentCheck)
+		},
+		Redactions: consulRedactions,
+		New:        newConsul,
 	}
-	cfg.Redactions = redact.Flatten(defaultRedactions, cfg.Redactions)
+}
 
+// newConsul creates a Product with all of Consul's default runners, from a cfg whose redactions already include
+// Consul's defaults.
+func newConsul(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
 	product := &Product{
 		l:      logger.Named("product"),
 		Name:   Consul,
@@ -51,14 +63,14 @@ func NewConsulWithContext(ctx context.Context, logger hclog.Logger, cfg Config)
 	// HCL handling goes first, because it could add redactions to our built-in runners
 	if cfg.HCL != nil {
 		// Map product-specific redactions from our config
-		hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions)
+		hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions, cfg.Pseudonymizer)
 		if err != nil {
 			return nil, err
 		}
 		// Prepend product HCL redactions to our product defaults
 		cfg.Redactions = redact.Flatten(hclProductRedactions, cfg.Redactions)
 
-		hclRunners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, cfg.Redactions)
+		hclRunners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, cfg.Redactions, cfg.Pseudonymizer, logger)
 		if err != nil {
 			return nil, err
 		}
@@ -93,7 +105,7 @@ func consulRunners(ctx context.Context, cfg Config, api *client.APIClient, l hcl
 		r = append(r, c)
 	}
 
-	dbg, err := debug.NewConsulDebug(
+	dbg, err := debug.NewConsulDebugWithContext(ctx,
 		debug.ConsulDebugConfig{
 			Redactions: cfg.Redactions,
 		},
@@ -158,7 +170,7 @@ func consulRunners(ctx context.Context, cfg Config, api *client.APIClient, l hcl
 	}
 
 	runners := []runner.Runner{
-		do.New(l, "consul", "consul runners", r),
+		do.NewWithContext(ctx, l, "consul", "consul runners", r),
 	}
 	return runners, nil
 }
@@ -50,7 +50,7 @@ func NewHostWithContext(ctx context.Context, logger hclog.Logger, cfg Config, hc
 
 	if hcl2 != nil {
 		// Map product-specific redactions from our config
-		hclProductRedactions, err := hcl.MapRedacts(hcl2.Redactions)
+		hclProductRedactions, err := hcl.MapRedacts(hcl2.Redactions, cfg.Pseudonymizer)
 		if err != nil {
 			return nil, err
 		}
@@ -58,7 +58,7 @@ func NewHostWithContext(ctx context.Context, logger hclog.Logger, cfg Config, hc
 		// Prepend product HCL redactions to our product defaults
 		cfg.Redactions = redact.Flatten(hclProductRedactions, cfg.Redactions)
 
-		hclRunners, err := hcl.BuildRunnersWithContext(ctx, hcl2, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, nil, cfg.Since, cfg.Until, cfg.Redactions)
+		hclRunners, err := hcl.BuildRunnersWithContext(ctx, hcl2, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, nil, cfg.Since, cfg.Until, cfg.Redactions, cfg.Pseudonymizer, logger)
 		if err != nil {
 			return nil, err
 		}
@@ -154,7 +154,7 @@ func hostRunners(ctx context.Context, os string, redactions []*redact.Redact, l
 	r = append(r, lSof)
 
 	runners := []runner.Runner{
-		do.New(l, "host", "host runners", r),
+		do.NewWithContext(ctx, l, "host", "host runners", r),
 	}
 	return runners, nil
 }
@@ -34,13 +34,25 @@ func NewNomad(logger hclog.Logger, cfg Config) (*Product, error) {
 
 // NewNomadWithContext takes a context, a logger, and product config, and it creates a Product with all of Nomad's default runners.
 func NewNomadWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
-	// Prepend product-specific redactions to agent-level redactions from cfg
-	defaultRedactions, err := nomadRedactions()
-	if err != nil {
-		return nil, err
+	return build(ctx, logger, nomadRegistration(), cfg)
+}
+
+// nomadRegistration returns the Registration of Nomad, which is autodetected by its CLI.
+func nomadRegistration() Registration {
+	return Registration{
+		Name:   Nomad,
+		Detect: detectCommand("nomad"),
+		CheckAvailable: func(ctx context.Context) error {
+			return CommandHealthCheckWithContext(ctx, NomadClientCheck, NomadAgentCheck)
+		},
+		Redactions: nomadRedactions,
+		New:        newNomad,
 	}
-	cfg.Redactions = redact.Flatten(defaultRedactions, cfg.Redactions)
+}
 
+// newNomad creates a Product with all of Nomad's default runners, from a cfg whose redactions already include
+// Nomad's defaults.
+func newNomad(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
 	product := &Product{
 		l:      logger.Named("product"),
 		Name:   Nomad,
@@ -64,14 +76,14 @@ func NewNomadWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (
 
 	if cfg.HCL != nil {
 		// Map product-specific redactions from our config
-		hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions)
+		hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions, cfg.Pseudonymizer)
 		if err != nil {
 			return nil, err
 		}
 		// Prepend product HCL redactions to our product defaults
 		cfg.Redactions = redact.Flatten(hclProductRedactions, cfg.Redactions)
 
-		hclRunners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, cfg.Redactions)
+		hclRunners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, cfg.Redactions, cfg.Pseudonymizer, logger)
 		if err != nil {
 			return nil, err
 		}
@@ -106,7 +118,7 @@ func nomadRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclo
 		}
 		r = append(r, c)
 	}
-	dbg, err := debug.NewNomadDebug(
+	dbg, err := debug.NewNomadDebugWithContext(ctx,
 		debug.NomadDebugConfig{
 			Redactions: cfg.Redactions,
 		},
@@ -166,7 +178,7 @@ func nomadRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclo
 	}
 
 	runners := []runner.Runner{
-		do.New(l, "nomad", "nomad runners", r),
+		do.NewWithContext(ctx, l, "nomad", "nomad runners", r),
 	}
 	return runners, nil
 }
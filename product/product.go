@@ -20,13 +20,20 @@ import (
 type Name string
 
 const (
-	Consul Name = "consul"
-	Host   Name = "host"
-	Nomad  Name = "nomad"
-	TFE    Name = "terraform-ent"
-	Vault  Name = "vault"
+	Boundary Name = "boundary"
+	Consul   Name = "consul"
+	Host     Name = "host"
+	Nomad    Name = "nomad"
+	TFE      Name = "terraform-ent"
+	Vault    Name = "vault"
 )
 
+// ConfigurableNames returns the built-in products, which may be configured with a `product` block in HCL. Host is
+// configured with its own `host` block instead.
+func ConfigurableNames() []Name {
+	return DefaultRegistry().Names()
+}
+
 const (
 	DefaultDuration = 10 * time.Second
 	DefaultInterval = 5 * time.Second
@@ -42,6 +49,10 @@ type Config struct {
 	DebugInterval time.Duration
 	HCL           *hcl.Product
 	Redactions    []*redact.Redact
+	// Pseudonymizer is shared by every redaction in the run that replaces matches with pseudonyms.
+	Pseudonymizer *redact.Pseudonymizer
+	// Scheduler limits how many runners execute at once. It is shared between products; nil means no limit.
+	Scheduler *runner.Scheduler
 }
 
 type Product struct {
@@ -59,7 +70,7 @@ func (p *Product) Run() map[string]op.Op {
 	results := make(map[string]op.Op)
 	for _, r := range p.Runners {
 		p.l.Info("running operation", "product", p.Name, "runner", r.ID())
-		o := r.Run()
+		o := p.Config.Scheduler.Run(r)
 		results[r.ID()] = o
 		// Note runner errors to users and keep going.
 		if o.Error != nil {
@@ -116,7 +127,8 @@ func CommandHealthCheck(client, agent string) error {
 	return CommandHealthCheckWithContext(context.Background(), client, agent)
 }
 
-// CommandHealthCheckWithContext employs the CLI to check if the client and then the agent are available.
+// CommandHealthCheckWithContext employs the CLI to check if the client and then the agent are available. Products
+// whose CLI has no separate agent check, such as Boundary, pass an empty agent to only check the client.
 func CommandHealthCheckWithContext(ctx context.Context, client, agent string) error {
 	clientCmd, err := runner.NewCommandWithContext(ctx, runner.CommandConfig{Command: client})
 	if err != nil {
@@ -126,6 +138,9 @@ func CommandHealthCheckWithContext(ctx context.Context, client, agent string) er
 	if checkClient.Error != nil {
 		return fmt.Errorf("client not available, healthcheck=%v, result=%v, error=%v", client, checkClient.Result, checkClient.Error)
 	}
+	if agent == "" {
+		return nil
+	}
 
 	agentCmd, err := runner.NewCommandWithContext(ctx, runner.CommandConfig{Command: agent})
 	if err != nil {
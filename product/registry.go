@@ -0,0 +1,142 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package product
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/hcdiag/redact"
+	"github.com/hashicorp/hcdiag/util"
+)
+
+// Registration describes how to detect, check for, and build a product. Products that are built into hcdiag are
+// registered in DefaultRegistry; programs that embed hcdiag may register their own alongside them.
+type Registration struct {
+	Name Name
+	// Detect returns true if the product appears to be installed on this host, such as when its CLI is on the PATH. It
+	// is used to autodetect products when none are enabled explicitly. If nil, the product is never autodetected.
+	Detect func() bool
+	// CheckAvailable returns an error if the product isn't available on this host. If nil, the product is always
+	// considered available.
+	CheckAvailable func(ctx context.Context) error
+	// Redactions returns the product's default redactions, which are applied ahead of the agent-level redactions in
+	// Config.Redactions. It may be nil if the product has none.
+	Redactions func() ([]*redact.Redact, error)
+	// New builds the product, with all of its runners, from cfg. By the time it's called, cfg.Redactions already
+	// starts with the product's default redactions.
+	New func(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error)
+}
+
+// Registry holds the products that may be built for a run, by name.
+type Registry struct {
+	registrations map[Name]Registration
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{registrations: make(map[Name]Registration)}
+}
+
+// DefaultRegistry returns a new Registry of the products that are built into hcdiag, other than the host, which is
+// always included in a run and has its own constructor, NewHost.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, reg := range []Registration{
+		boundaryRegistration(),
+		consulRegistration(),
+		nomadRegistration(),
+		tfeRegistration(),
+		vaultRegistration(),
+	} {
+		// Built-in registrations are complete and distinct, so this can't fail.
+		_ = r.Register(reg)
+	}
+	return r
+}
+
+// Clone returns a copy of the registry, which may be registered in without changing r.
+func (r *Registry) Clone() *Registry {
+	c := NewRegistry()
+	for name, reg := range r.registrations {
+		c.registrations[name] = reg
+	}
+	return c
+}
+
+// Register adds reg to the registry. It is an error to register the same name twice.
+func (r *Registry) Register(reg Registration) error {
+	if reg.Name == "" {
+		return errors.New("product registration has no name")
+	}
+	if reg.New == nil {
+		return fmt.Errorf("product registration has no constructor, product=%s", reg.Name)
+	}
+	if _, ok := r.registrations[reg.Name]; ok {
+		return fmt.Errorf("product is already registered, product=%s", reg.Name)
+	}
+	r.registrations[reg.Name] = reg
+	return nil
+}
+
+// Get returns the registration for name, and whether there is one.
+func (r *Registry) Get(name Name) (Registration, bool) {
+	reg, ok := r.registrations[name]
+	return reg, ok
+}
+
+// NewWithContext builds the registered product called name from cfg, prepending the product's default redactions to
+// cfg.Redactions.
+func (r *Registry) NewWithContext(ctx context.Context, logger hclog.Logger, name Name, cfg Config) (*Product, error) {
+	reg, ok := r.registrations[name]
+	if !ok {
+		return nil, fmt.Errorf("product is not registered, product=%s", name)
+	}
+	return build(ctx, logger, reg, cfg)
+}
+
+// Detect returns the names of the registered products that appear to be installed on this host, in order.
+func (r *Registry) Detect() []Name {
+	var names []Name
+	for _, name := range r.Names() {
+		if detect := r.registrations[name].Detect; detect != nil && detect() {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Names returns the names of every registered product, in order.
+func (r *Registry) Names() []Name {
+	names := make([]Name, 0, len(r.registrations))
+	for name := range r.registrations {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// build builds the product that reg describes from cfg, prepending the product's default redactions to cfg.Redactions.
+func build(ctx context.Context, logger hclog.Logger, reg Registration, cfg Config) (*Product, error) {
+	if reg.Redactions != nil {
+		defaultRedactions, err := reg.Redactions()
+		if err != nil {
+			return nil, err
+		}
+		cfg.Redactions = redact.Flatten(defaultRedactions, cfg.Redactions)
+	}
+	return reg.New(ctx, logger, cfg)
+}
+
+// detectCommand returns a Registration.Detect that detects a product by whether command is on the PATH.
+func detectCommand(command string) func() bool {
+	return func() bool {
+		exists, _ := util.HostCommandExists(command)
+		return exists
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package product
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/redact"
+)
+
+func TestRegistry(t *testing.T) {
+	newProduct := func(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) { return &Product{}, nil }
+
+	r := NewRegistry()
+	require.NoError(t, r.Register(Registration{Name: "zeta", New: newProduct}))
+	require.NoError(t, r.Register(Registration{Name: "alpha", New: newProduct}))
+	assert.Equal(t, []Name{"alpha", "zeta"}, r.Names())
+
+	_, ok := r.Get("alpha")
+	assert.True(t, ok)
+	_, ok = r.Get("beta")
+	assert.False(t, ok)
+
+	assert.Error(t, r.Register(Registration{Name: "alpha", New: newProduct}), "duplicate names are an error")
+	assert.Error(t, r.Register(Registration{Name: "beta"}), "a registration needs a constructor")
+	assert.Error(t, r.Register(Registration{New: newProduct}), "a registration needs a name")
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	r := DefaultRegistry()
+	assert.Equal(t, []Name{Boundary, Consul, Nomad, TFE, Vault}, r.Names())
+	for _, name := range r.Names() {
+		reg, _ := r.Get(name)
+		assert.NotNil(t, reg.Detect, "built-in products are autodetected, product=%s", name)
+		assert.NotNil(t, reg.Redactions, "built-in products have default redactions, product=%s", name)
+	}
+
+	// Registering in a clone leaves the original alone.
+	c := r.Clone()
+	require.NoError(t, c.Register(Registration{Name: "acme", New: NewUserDefinedWithContext}))
+	assert.Len(t, c.Names(), 6)
+	assert.Len(t, r.Names(), 5)
+}
+
+func TestRegistryNewWithContext(t *testing.T) {
+	var got Config
+	r := NewRegistry()
+	require.NoError(t, r.Register(Registration{
+		Name: "acme",
+		Redactions: func() ([]*redact.Redact, error) {
+			return redact.MapNew([]redact.Config{{ID: "acme-default", Matcher: "acme"}})
+		},
+		New: func(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
+			got = cfg
+			return &Product{Name: "acme"}, nil
+		},
+	}))
+
+	agentRedaction, err := redact.New(redact.Config{ID: "agent", Matcher: "agent"})
+	require.NoError(t, err)
+	p, err := r.NewWithContext(context.Background(), hclog.NewNullLogger(), "acme", Config{Redactions: []*redact.Redact{agentRedaction}})
+	require.NoError(t, err)
+	assert.Equal(t, Name("acme"), p.Name)
+	require.Len(t, got.Redactions, 2)
+	assert.Equal(t, "acme-default", got.Redactions[0].ID, "default redactions come before agent-level ones")
+	assert.Equal(t, "agent", got.Redactions[1].ID)
+
+	_, err = r.NewWithContext(context.Background(), hclog.NewNullLogger(), "widget", Config{})
+	assert.Error(t, err)
+}
@@ -5,6 +5,10 @@ package product
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/hcdiag/client"
@@ -12,8 +16,131 @@ import (
 	"github.com/hashicorp/hcdiag/redact"
 	"github.com/hashicorp/hcdiag/runner"
 	"github.com/hashicorp/hcdiag/runner/do"
+	logs "github.com/hashicorp/hcdiag/runner/log"
+	"github.com/hashicorp/hcdiag/util"
 )
 
+// TFEMode is the way that TFE is deployed on a host.
+type TFEMode string
+
+const (
+	// TFEReplicated is a TFE install that's managed by Replicated.
+	TFEReplicated TFEMode = "replicated"
+	// TFEDocker is a Flexible Deployment Options (FDO) install, running in Docker.
+	TFEDocker TFEMode = "docker"
+	// TFEKubernetes is a Flexible Deployment Options (FDO) install, running in Kubernetes.
+	TFEKubernetes TFEMode = "kubernetes"
+	// TFEUnknown is used when none of the supported deployments can be found on the host.
+	TFEUnknown TFEMode = "unknown"
+)
+
+const (
+	// TFEImage is the name of the image that FDO installs of TFE run, without its registry or tag.
+	TFEImage = "terraform-enterprise"
+	// DefaultTFEContainer and DefaultTFENamespace are the names that FDO installs use by default, which are used when the
+	// deployment can't be detected.
+	DefaultTFEContainer  = "terraform-enterprise"
+	DefaultTFENamespace  = "terraform-enterprise"
+	DefaultTFEDeployment = "terraform-enterprise"
+)
+
+// tfeInspectFormat limits `docker inspect` to the TFE container's name, image, state (which includes its health), and
+// mounts. Its config is left out, because FDO installs are configured with secrets in the container's environment.
+const tfeInspectFormat = `'{"Name":{{json .Name}},"Image":{{json .Config.Image}},"State":{{json .State}},"Mounts":{{json .Mounts}}}'`
+
+// tfeDetectTimeout bounds each of the commands that detect the TFE deployment. They run before any runners do, so an
+// unresponsive Docker daemon or an unreachable Kubernetes cluster would otherwise hold up the whole run.
+const tfeDetectTimeout = 10 * time.Second
+
+// tfeSupportBundles matches the support bundles that `tfectl support-bundle` writes within an FDO container.
+const tfeSupportBundles = "/var/lib/terraform-enterprise/*support-bundle*.tar.gz"
+
+// TFEDeployment describes how TFE is deployed on a host, and where to find it.
+type TFEDeployment struct {
+	Mode TFEMode `json:"mode"`
+	// Container is the name of the TFE container, for Docker deployments.
+	Container string `json:"container,omitempty"`
+	// Namespace and Deployment locate TFE in Kubernetes, for Kubernetes deployments.
+	Namespace  string `json:"namespace,omitempty"`
+	Deployment string `json:"deployment,omitempty"`
+}
+
+// DetectTFEDeploymentWithContext detects how TFE is deployed on this host. Replicated installs are detected by the
+// replicatedctl CLI, Docker installs by a running container of the TFE image, and Kubernetes installs by a deployment
+// whose name includes "terraform-enterprise", in any namespace that kubectl can see. Each command that it runs is given
+// up on after a short timeout, after which that kind of deployment is treated as not found.
+func DetectTFEDeploymentWithContext(ctx context.Context) TFEDeployment {
+	exists := func(command string) bool {
+		ok, _ := util.HostCommandExists(command)
+		return ok
+	}
+	output := func(command string) (string, error) {
+		cmd, err := runner.NewCommandWithContext(ctx, runner.CommandConfig{Command: command, Timeout: tfeDetectTimeout})
+		if err != nil {
+			return "", err
+		}
+		o := cmd.Run()
+		if o.Error != nil {
+			return "", o.Error
+		}
+		text, _ := o.Result["text"].(string)
+		return text, nil
+	}
+	return detectTFEDeployment(exists, output)
+}
+
+// detectTFEDeployment detects how TFE is deployed, using exists to check for CLIs and output to run commands.
+func detectTFEDeployment(exists func(command string) bool, output func(command string) (string, error)) TFEDeployment {
+	if exists("replicatedctl") {
+		return TFEDeployment{Mode: TFEReplicated}
+	}
+
+	if exists("docker") {
+		out, err := output("docker ps --format '{{.Names}} {{.Image}}'")
+		if err == nil {
+			for _, line := range strings.Split(out, "\n") {
+				fields := strings.Fields(line)
+				if len(fields) == 2 && isTFEImage(fields[1]) {
+					return TFEDeployment{Mode: TFEDocker, Container: fields[0]}
+				}
+			}
+		}
+	}
+
+	if exists("kubectl") {
+		out, err := output("kubectl get deployments --all-namespaces --no-headers -o custom-columns=NAMESPACE:.metadata.namespace,NAME:.metadata.name")
+		if err == nil {
+			for _, line := range strings.Split(out, "\n") {
+				fields := strings.Fields(line)
+				if len(fields) == 2 && strings.Contains(fields[1], TFEImage) {
+					return TFEDeployment{Mode: TFEKubernetes, Namespace: fields[0], Deployment: fields[1]}
+				}
+			}
+		}
+	}
+
+	return TFEDeployment{Mode: TFEUnknown}
+}
+
+// isTFEImage returns true if image, such as "images.releases.hashicorp.com/hashicorp/terraform-enterprise:v202401-1",
+// is the TFE image.
+func isTFEImage(image string) bool {
+	name := image[strings.LastIndex(image, "/")+1:]
+	name, _, _ = strings.Cut(name, ":")
+	name, _, _ = strings.Cut(name, "@")
+	return name == TFEImage
+}
+
+// only returns a condition for do.When that holds only if d is in mode. Otherwise, it explains why the runner is skipped.
+func (d TFEDeployment) only(mode TFEMode) func() error {
+	return func() error {
+		if d.Mode != mode {
+			return fmt.Errorf("TFE is not a %s install, deployment=%s", mode, d.Mode)
+		}
+		return nil
+	}
+}
+
 // NewTFE takes a logger and product config, and it creates a Product with all of TFE's default runners.
 func NewTFE(logger hclog.Logger, cfg Config) (*Product, error) {
 	return NewTFEWithContext(context.Background(), logger, cfg)
@@ -21,13 +148,23 @@ func NewTFE(logger hclog.Logger, cfg Config) (*Product, error) {
 
 // NewTFEWithContext takes a context, a logger, and product config, and it creates a Product with all of TFE's default runners.
 func NewTFEWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
-	// Prepend product-specific redactions to agent-level redactions from cfg
-	defaultRedactions, err := tfeRedactions()
-	if err != nil {
-		return nil, err
+	return build(ctx, logger, tfeRegistration(), cfg)
+}
+
+// tfeRegistration returns the Registration of TFE, which is autodetected by the Terraform CLI. It has no CheckAvailable,
+// because TFE doesn't have a CLI to check it with.
+func tfeRegistration() Registration {
+	return Registration{
+		Name:       TFE,
+		Detect:     detectCommand("terraform"),
+		Redactions: tfeRedactions,
+		New:        newTFE,
 	}
-	cfg.Redactions = redact.Flatten(defaultRedactions, cfg.Redactions)
+}
 
+// newTFE creates a Product with all of TFE's default runners, from a cfg whose redactions already include
+// TFE's defaults.
+func newTFE(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
 	product := &Product{
 		l:      logger.Named("product"),
 		Name:   TFE,
@@ -40,14 +177,14 @@ func NewTFEWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (*P
 
 	if cfg.HCL != nil {
 		// Map product-specific redactions from our config
-		hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions)
+		hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions, cfg.Pseudonymizer)
 		if err != nil {
 			return nil, err
 		}
 		// Prepend product HCL redactions to our product defaults
 		cfg.Redactions = redact.Flatten(hclProductRedactions, cfg.Redactions)
 
-		hclRunners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, nil)
+		hclRunners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, nil, cfg.Pseudonymizer, logger)
 		if err != nil {
 			return nil, err
 		}
@@ -56,8 +193,12 @@ func NewTFEWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (*P
 		product.Selects = cfg.HCL.Selects
 	}
 
+	deployment := DetectTFEDeploymentWithContext(ctx)
+	logger.Info("detected TFE deployment", "mode", deployment.Mode, "container", deployment.Container,
+		"namespace", deployment.Namespace, "deployment", deployment.Deployment)
+
 	// Add built-in runners
-	builtInRunners, err := tfeRunners(ctx, cfg, api, logger)
+	builtInRunners, err := tfeRunners(ctx, cfg, api, deployment, logger)
 	if err != nil {
 		return nil, err
 	}
@@ -66,8 +207,9 @@ func NewTFEWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (*P
 	return product, nil
 }
 
-// tfeRunners configures a set of default runners for TFE.
-func tfeRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclog.Logger) ([]runner.Runner, error) {
+// tfeRunners configures a set of default runners for TFE. Runners that only apply to one kind of deployment are always
+// included, but they're skipped unless TFE is deployed that way.
+func tfeRunners(ctx context.Context, cfg Config, api *client.APIClient, deployment TFEDeployment, l hclog.Logger) ([]runner.Runner, error) {
 	var r []runner.Runner
 
 	// Set up the Replicated Support Bundle runners
@@ -91,7 +233,7 @@ func tfeRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclog.
 
 	// The support bundle that we copy is built by the `replicatedctl support-bundle` command, so we need to ensure
 	// that these run in sequence.
-	replicatedSeq := do.NewSeq(do.SeqConfig{
+	replicatedSeq := do.NewSeqWithContext(ctx, do.SeqConfig{
 		Runners: []runner.Runner{
 			supportBundleCmd,
 			supportBundleCopy,
@@ -100,7 +242,7 @@ func tfeRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclog.
 		Description: "replicated support bundle",
 		Logger:      l,
 	})
-	r = append(r, replicatedSeq)
+	r = append(r, do.NewWhen(replicatedSeq, deployment.only(TFEReplicated)))
 
 	// Set up HTTP runners
 	for _, hc := range []runner.HttpConfig{
@@ -113,6 +255,8 @@ func tfeRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclog.
 		{Client: api, Path: "/api/v2/admin/workspaces?page[size]=1", Redactions: cfg.Redactions},
 		{Client: api, Path: "/api/v2/admin/users?page[size]=1", Redactions: cfg.Redactions},
 		{Client: api, Path: "/api/v2/admin/runs?page[size]=1", Redactions: cfg.Redactions},
+		{Client: api, Path: "/_health_check", Redactions: cfg.Redactions},
+		{Client: api, Path: "/_health_check?full=1", Redactions: cfg.Redactions},
 	} {
 		c, err := runner.NewHTTPWithContext(ctx, hc)
 		if err != nil {
@@ -124,8 +268,18 @@ func tfeRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclog.
 	// Set up Command runners
 	for _, cc := range []runner.CommandConfig{
 		{Command: "docker -v", Redactions: cfg.Redactions},
-		{Command: "replicatedctl app status --output json", Format: "json", Redactions: cfg.Redactions},
 		{Command: "lsblk --json", Format: "json", Redactions: cfg.Redactions},
+	} {
+		c, err := runner.NewCommandWithContext(ctx, cc)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, c)
+	}
+
+	// Set up Replicated Command runners
+	for _, cc := range []runner.CommandConfig{
+		{Command: "replicatedctl app status --output json", Format: "json", Redactions: cfg.Redactions},
 		{Command: "replicatedctl app-config view -o json --group capacity", Format: "json", Redactions: cfg.Redactions},
 		{Command: "replicatedctl app-config view -o json --group production_type", Format: "json", Redactions: cfg.Redactions},
 		{Command: "replicatedctl app-config view -o json --group log_forwarding", Format: "json", Redactions: cfg.Redactions},
@@ -138,7 +292,83 @@ func tfeRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclog.
 		if err != nil {
 			return nil, err
 		}
-		r = append(r, c)
+		r = append(r, do.NewWhen(c, deployment.only(TFEReplicated)))
+	}
+
+	// Set up Docker runners, for FDO installs in Docker
+	container := deployment.Container
+	if container == "" {
+		container = DefaultTFEContainer
+	}
+	for _, cc := range []runner.CommandConfig{
+		{Command: "docker compose ls --all --format json", Format: "json", Redactions: cfg.Redactions},
+		{Command: fmt.Sprintf("docker inspect --format %s %s", tfeInspectFormat, container), Format: "json", Redactions: cfg.Redactions},
+		{Command: fmt.Sprintf("docker exec %s tfectl app status", container), Redactions: cfg.Redactions},
+		{Command: fmt.Sprintf("docker exec %s tfectl app config", container), Redactions: cfg.Redactions},
+		{Command: fmt.Sprintf("docker exec %s tfectl node list", container), Redactions: cfg.Redactions},
+	} {
+		c, err := runner.NewCommandWithContext(ctx, cc)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, do.NewWhen(c, deployment.only(TFEDocker)))
+	}
+
+	// The support bundle is written within the container, so the newest one is copied out once it has been built.
+	bundleCmd, err := runner.NewCommandWithContext(ctx, runner.CommandConfig{
+		Command:    fmt.Sprintf("docker exec %s tfectl support-bundle", container),
+		Redactions: cfg.Redactions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	bundleCopy, err := runner.NewShellWithContext(ctx, runner.ShellConfig{
+		Command: fmt.Sprintf(`docker cp "%s:$(docker exec %s sh -c 'ls -t %s | head -n 1')" '%s'`,
+			container, container, tfeSupportBundles, filepath.Join(cfg.TmpDir, "tfe-support-bundle.tar.gz")),
+		Redactions: cfg.Redactions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	fdoSeq := do.NewSeqWithContext(ctx, do.SeqConfig{
+		Runners:     []runner.Runner{bundleCmd, bundleCopy},
+		Label:       "tfectl-support-bundle",
+		Description: "FDO support bundle",
+		Logger:      l,
+	})
+	r = append(r, do.NewWhen(fdoSeq, deployment.only(TFEDocker)))
+
+	dockerLogs := logs.NewDockerWithContext(ctx, logs.DockerConfig{
+		Container:  container,
+		DestDir:    cfg.TmpDir,
+		Since:      cfg.Since,
+		Redactions: cfg.Redactions,
+	})
+	r = append(r, do.NewWhen(dockerLogs, deployment.only(TFEDocker)))
+
+	// Set up Kubernetes runners, for FDO installs in Kubernetes
+	namespace, name := deployment.Namespace, deployment.Deployment
+	if namespace == "" {
+		namespace = DefaultTFENamespace
+	}
+	if name == "" {
+		name = DefaultTFEDeployment
+	}
+	kubectlLogs := fmt.Sprintf("kubectl logs deployment/%s -n %s --all-containers --timestamps", name, namespace)
+	if !cfg.Since.IsZero() {
+		kubectlLogs += " --since-time=" + cfg.Since.UTC().Format(time.RFC3339)
+	}
+	for _, cc := range []runner.CommandConfig{
+		{Command: fmt.Sprintf("kubectl get all -n %s -o json", namespace), Format: "json", Redactions: cfg.Redactions},
+		{Command: fmt.Sprintf("kubectl get events -n %s -o json", namespace), Format: "json", Redactions: cfg.Redactions},
+		{Command: fmt.Sprintf("kubectl describe deployment %s -n %s", name, namespace), Redactions: cfg.Redactions},
+		{Command: kubectlLogs, Redactions: cfg.Redactions},
+	} {
+		c, err := runner.NewCommandWithContext(ctx, cc)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, do.NewWhen(c, deployment.only(TFEKubernetes)))
 	}
 
 	// Set up Shell runners
@@ -154,7 +384,7 @@ func tfeRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclog.
 	}
 
 	runners := []runner.Runner{
-		do.New(l, "tfe", "tfe runners", r),
+		do.NewWithContext(ctx, l, "tfe", "tfe runners", r),
 	}
 	return runners, nil
 }
@@ -183,5 +413,22 @@ func tfeRedactions() ([]*redact.Redact, error) {
 	if err != nil {
 		return nil, err
 	}
-	return redactions, nil
+
+	// FDO installs are configured through the environment, which includes secrets such as TFE_ENCRYPTION_PASSWORD and
+	// TFE_LICENSE. Redact them where they're written as NAME=value or, as `kubectl describe` does, NAME: value.
+	secretEnv, err := redact.New(redact.Config{
+		ID:      "tfe-secret-env",
+		Matcher: `(TFE_[A-Z0-9_]*(?:PASSWORD|SECRET|TOKEN|LICENSE|KEY)[A-Z0-9_]*(?:=|:[ \t]+))\S+`,
+		Replace: "${1}" + redact.DefaultReplace,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Kubernetes resources hold each variable's name and value in separate fields, so a matcher can't tell which values
+	// are secrets. Redact every inline value instead.
+	envValues, err := redact.NewPath(redact.Config{ID: "tfe-kubernetes-env-value"}, "**.env.*.value")
+	if err != nil {
+		return nil, err
+	}
+	return append(redactions, secretEnv, envValues), nil
 }
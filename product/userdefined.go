@@ -0,0 +1,109 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package product
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/hcdiag/client"
+	"github.com/hashicorp/hcdiag/hcl"
+	"github.com/hashicorp/hcdiag/redact"
+)
+
+// IsBuiltIn returns true if name is a product that's built into hcdiag, including the host.
+func IsBuiltIn(name Name) bool {
+	if name == Host {
+		return true
+	}
+	for _, n := range ConfigurableNames() {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterUserDefined registers a product for each of products that defines a product of its own, rather than
+// configuring a built-in one. Product blocks that do neither are ignored, as the names of built-in products are.
+func RegisterUserDefined(r *Registry, products []*hcl.Product) error {
+	for _, p := range products {
+		if IsBuiltIn(Name(p.Name)) || !p.IsDefinition() {
+			continue
+		}
+		if err := r.Register(UserDefinedRegistration(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UserDefinedRegistration returns the Registration of the product that p defines. It's available if its healthcheck, if
+// any, succeeds, and it's built from p, no matter which product block Config.HCL holds.
+func UserDefinedRegistration(p *hcl.Product) Registration {
+	reg := Registration{
+		Name: Name(p.Name),
+		New: func(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
+			cfg.HCL = p
+			return NewUserDefinedWithContext(ctx, logger, cfg)
+		},
+	}
+	if p.HealthCheck != "" {
+		reg.CheckAvailable = func(ctx context.Context) error {
+			return CommandHealthCheckWithContext(ctx, p.HealthCheck, "")
+		}
+	}
+	return reg
+}
+
+// NewUserDefined takes a logger and product config, and it creates a Product from the product block in cfg.HCL.
+func NewUserDefined(logger hclog.Logger, cfg Config) (*Product, error) {
+	return NewUserDefinedWithContext(context.Background(), logger, cfg)
+}
+
+// NewUserDefinedWithContext takes a context, a logger, and product config, and it creates a Product from the product
+// block in cfg.HCL. Unlike built-in products, it has no default runners or redactions; its GET runners use a client
+// built from the block's api settings.
+func NewUserDefinedWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
+	if cfg.HCL == nil {
+		return nil, errors.New("user-defined product has no product block")
+	}
+	name := Name(cfg.HCL.Name)
+
+	var api *client.APIClient
+	var err error
+	if cfg.HCL.API != nil {
+		api, err = client.NewUserDefinedAPI(hcl.MapAPI(cfg.HCL.Name, cfg.HCL.API))
+	} else {
+		// Without an api block, there's nowhere for GET runners to go, which validation reports.
+		api, err = client.NewAPIClient(client.APIConfig{Product: cfg.HCL.Name})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Prepend product HCL redactions to agent-level redactions from cfg
+	hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions, cfg.Pseudonymizer)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Name = name
+	cfg.Redactions = redact.Flatten(hclProductRedactions, cfg.Redactions)
+
+	runners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, cfg.Redactions, cfg.Pseudonymizer, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Product{
+		l:        logger.Named("product"),
+		Name:     name,
+		Runners:  runners,
+		Excludes: cfg.HCL.Excludes,
+		Selects:  cfg.HCL.Selects,
+		Config:   cfg,
+	}, nil
+}
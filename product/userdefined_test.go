@@ -0,0 +1,57 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package product
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/hcl"
+)
+
+func TestRegisterUserDefined(t *testing.T) {
+	products := []*hcl.Product{
+		{Name: "vault", Commands: []hcl.Command{{Run: "vault version", Format: "string"}}},
+		{Name: "acme-gateway", HealthCheck: "acme-gateway version"},
+		{Name: "acme-worker", API: &hcl.API{Address: "http://127.0.0.1:8080"}},
+		{Name: "consol"},
+	}
+
+	r := NewRegistry()
+	require.NoError(t, RegisterUserDefined(r, products))
+	assert.Equal(t, []Name{"acme-gateway", "acme-worker"}, r.Names(), "only definitions of products that aren't built in are registered")
+
+	gateway, _ := r.Get("acme-gateway")
+	assert.NotNil(t, gateway.CheckAvailable)
+	worker, _ := r.Get("acme-worker")
+	assert.Nil(t, worker.CheckAvailable, "a product without a healthcheck is always available")
+
+	assert.Error(t, RegisterUserDefined(r, products[1:2]), "a product can't be defined twice")
+}
+
+func TestNewUserDefined(t *testing.T) {
+	t.Setenv("ACME_ADDR", "")
+	cfg := Config{
+		TmpDir: t.TempDir(),
+		HCL: &hcl.Product{
+			Name:     "acme-gateway",
+			API:      &hcl.API{Address: "http://127.0.0.1:8080", AddressEnv: "ACME_ADDR"},
+			Commands: []hcl.Command{{Run: "acme-gateway version", Format: "string"}},
+			GETs:     []hcl.GET{{Path: "/v1/status"}},
+			Excludes: []string{"GET /v1/status"},
+		},
+	}
+
+	p, err := NewUserDefined(hclog.NewNullLogger(), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, Name("acme-gateway"), p.Name)
+	assert.Len(t, p.Runners, 2)
+	assert.Equal(t, []string{"GET /v1/status"}, p.Excludes)
+
+	_, err = NewUserDefined(hclog.NewNullLogger(), Config{TmpDir: t.TempDir()})
+	assert.Error(t, err)
+}
@@ -4,13 +4,18 @@
 package product
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"net/http"
 	"path/filepath"
+	"sync"
 
 	"github.com/hashicorp/hcdiag/hcl"
 	"github.com/hashicorp/hcdiag/redact"
 	"github.com/hashicorp/hcdiag/runner/debug"
 	"github.com/hashicorp/hcdiag/runner/do"
+	"github.com/hashicorp/hcdiag/util"
 
 	"github.com/hashicorp/go-hclog"
 
@@ -25,6 +30,10 @@ const (
 	VaultAgentCheck  = "vault status"
 )
 
+// vaultPrometheusDisabled is how Vault explains a 400 Bad Request for metrics in the Prometheus format, when they
+// aren't enabled by telemetry's prometheus_retention_time.
+const vaultPrometheusDisabled = "prometheus is not enabled"
+
 // NewVault takes a product config and creates a Product containing all of Vault's runners.
 func NewVault(logger hclog.Logger, cfg Config) (*Product, error) {
 	return NewVaultWithContext(context.Background(), logger, cfg)
@@ -32,13 +41,25 @@ func NewVault(logger hclog.Logger, cfg Config) (*Product, error) {
 
 // NewVaultWithContext takes a context, a logger, and a config and creates a Product containing all of Vault's runners.
 func NewVaultWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
-	// Prepend product-specific redactions to agent-level redactions from cfg
-	defaultRedactions, err := vaultRedactions()
-	if err != nil {
-		return nil, err
+	return build(ctx, logger, vaultRegistration(), cfg)
+}
+
+// vaultRegistration returns the Registration of Vault, which is autodetected by its CLI.
+func vaultRegistration() Registration {
+	return Registration{
+		Name:   Vault,
+		Detect: detectCommand("vault"),
+		CheckAvailable: func(ctx context.Context) error {
+			return CommandHealthCheckWithContext(ctx, VaultClientCheck, VaultAgentCheck)
+		},
+		Redactions: vaultRedactions,
+		New:        newVault,
 	}
-	cfg.Redactions = redact.Flatten(defaultRedactions, cfg.Redactions)
+}
 
+// newVault creates a Product with all of Vault's default runners, from a cfg whose redactions already include
+// Vault's defaults.
+func newVault(ctx context.Context, logger hclog.Logger, cfg Config) (*Product, error) {
 	product := &Product{
 		l:      logger.Named("product"),
 		Name:   Vault,
@@ -51,14 +72,14 @@ func NewVaultWithContext(ctx context.Context, logger hclog.Logger, cfg Config) (
 
 	if cfg.HCL != nil {
 		// Map product-specific redactions from our config
-		hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions)
+		hclProductRedactions, err := hcl.MapRedacts(cfg.HCL.Redactions, cfg.Pseudonymizer)
 		if err != nil {
 			return nil, err
 		}
 		// Prepend product HCL redactions to our product defaults
 		cfg.Redactions = redact.Flatten(hclProductRedactions, cfg.Redactions)
 
-		hclRunners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, nil)
+		hclRunners, err := hcl.BuildRunnersWithContext(ctx, cfg.HCL, cfg.TmpDir, cfg.DebugDuration, cfg.DebugInterval, api, cfg.Since, cfg.Until, nil, cfg.Pseudonymizer, logger)
 		if err != nil {
 			return nil, err
 		}
@@ -96,12 +117,19 @@ func vaultRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclo
 		r = append(r, c)
 	}
 
-	// Set up HTTP runners
+	// Set up HTTP runners. Those that the token isn't allowed to read are skipped, as are Prometheus metrics if they
+	// aren't enabled.
 	for _, hc := range []runner.HttpConfig{
+		{Client: api, Path: "/v1/sys/seal-status", Redactions: cfg.Redactions},
 		{Client: api, Path: "/v1/sys/audit", Redactions: cfg.Redactions},
 		{Client: api, Path: "/v1/sys/version-history?list=true", Redactions: cfg.Redactions},
 		{Client: api, Path: "/v1/sys/license/status", Redactions: cfg.Redactions},
 		{Client: api, Path: "/v1/sys/replication/status", Redactions: cfg.Redactions},
+		{Client: api, Path: "/v1/sys/ha-status", Redactions: cfg.Redactions},
+		{Client: api, Path: "/v1/sys/leader", Redactions: cfg.Redactions},
+		{Client: api, Path: "/v1/sys/key-status", Redactions: cfg.Redactions},
+		{Client: api, Path: "/v1/sys/internal/counters/activity", Redactions: cfg.Redactions},
+		{Client: api, Path: "/v1/sys/metrics?format=prometheus", Format: "string", Redactions: cfg.Redactions, SkipResponse: vaultSkipPrometheusDisabled},
 	} {
 		h, err := runner.NewHTTPWithContext(ctx, hc)
 		if err != nil {
@@ -110,7 +138,20 @@ func vaultRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclo
 		r = append(r, h)
 	}
 
-	dbg, err := debug.NewVaultDebug(
+	// Set up HTTP runners for integrated storage, which are skipped if Vault uses another storage backend
+	raftOnly := vaultRaftCondition(ctx, api)
+	for _, hc := range []runner.HttpConfig{
+		{Client: api, Path: "/v1/sys/storage/raft/configuration", Redactions: cfg.Redactions},
+		{Client: api, Path: "/v1/sys/storage/raft/autopilot/state", Redactions: cfg.Redactions},
+	} {
+		h, err := runner.NewHTTPWithContext(ctx, hc)
+		if err != nil {
+			return nil, err
+		}
+		r = append(r, do.NewWhen(h, raftOnly))
+	}
+
+	dbg, err := debug.NewVaultDebugWithContext(ctx,
 		debug.VaultDebugConfig{
 			Redactions: cfg.Redactions,
 		},
@@ -157,11 +198,32 @@ func vaultRunners(ctx context.Context, cfg Config, api *client.APIClient, l hclo
 	}
 
 	runners := []runner.Runner{
-		do.New(l, "vault", "vault runners", r),
+		do.NewWithContext(ctx, l, "vault", "vault runners", r),
 	}
 	return runners, nil
 }
 
+// vaultRaftCondition returns a condition for do.When that holds if Vault's storage backend is raft, according to
+// sys/seal-status. Vault is only asked once, however many runners share the condition. If the storage type can't be
+// read, such as from versions of Vault that don't report it, the condition holds, and the runners report their own
+// errors.
+func vaultRaftCondition(ctx context.Context, api *client.APIClient) func() error {
+	return sync.OnceValue(func() error {
+		status, err := api.RedactGetWithContext(ctx, "/v1/sys/seal-status", nil)
+		if err != nil {
+			return nil
+		}
+		storageType, err := util.FindInInterface(status, "storage_type")
+		if err != nil {
+			return nil
+		}
+		if s, ok := storageType.(string); ok && s != "" && s != "raft" {
+			return fmt.Errorf("storage backend is not raft, storage_type=%s", s)
+		}
+		return nil
+	})
+}
+
 // vaultRedactions returns a slice of default redactions for this product
 func vaultRedactions() ([]*redact.Redact, error) {
 	configs := []redact.Config{}
@@ -171,3 +233,11 @@ func vaultRedactions() ([]*redact.Redact, error) {
 	}
 	return redactions, nil
 }
+
+// vaultSkipPrometheusDisabled skips the Prometheus metrics runner if Vault responds that they aren't enabled.
+func vaultSkipPrometheusDisabled(err client.StatusError, body []byte) error {
+	if err.StatusCode == http.StatusBadRequest && bytes.Contains(bytes.ToLower(body), []byte(vaultPrometheusDisabled)) {
+		return fmt.Errorf("%w: %s", err, vaultPrometheusDisabled)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package product
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/client"
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/runner"
+)
+
+func TestVaultRaftCondition(t *testing.T) {
+	testCases := []struct {
+		name       string
+		status     int
+		sealStatus string
+		expectErr  bool
+	}{
+		{
+			name:       "raft",
+			status:     http.StatusOK,
+			sealStatus: `{"sealed":false,"storage_type":"raft"}`,
+		},
+		{
+			name:       "another storage backend",
+			status:     http.StatusOK,
+			sealStatus: `{"sealed":false,"storage_type":"consul"}`,
+			expectErr:  true,
+		},
+		{
+			name:       "storage type is not reported",
+			status:     http.StatusOK,
+			sealStatus: `{"sealed":false}`,
+		},
+		{
+			name:       "seal status is unavailable",
+			status:     http.StatusServiceUnavailable,
+			sealStatus: `{"errors":["Vault is sealed"]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var requests int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				assert.Equal(t, "/v1/sys/seal-status", r.URL.Path)
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.sealStatus))
+			}))
+			defer srv.Close()
+			api, err := client.NewAPIClient(client.APIConfig{Product: "vault", BaseURL: srv.URL})
+			require.NoError(t, err)
+
+			condition := vaultRaftCondition(context.Background(), api)
+			for i := 0; i < 2; i++ {
+				if tc.expectErr {
+					assert.EqualError(t, condition(), "storage backend is not raft, storage_type=consul")
+				} else {
+					assert.NoError(t, condition())
+				}
+			}
+			assert.Equal(t, 1, requests)
+		})
+	}
+}
+
+func TestVaultSkipPrometheusDisabled(t *testing.T) {
+	testCases := []struct {
+		name   string
+		status int
+		body   string
+		expect op.Status
+		err    string
+	}{
+		{
+			name:   "enabled",
+			status: http.StatusOK,
+			body:   "# TYPE vault_core_unsealed gauge\nvault_core_unsealed 1\n",
+			expect: op.Success,
+		},
+		{
+			name:   "disabled",
+			status: http.StatusBadRequest,
+			body:   `{"errors":["prometheus is not enabled"]}`,
+			expect: op.Skip,
+			err:    "400 Bad Request: prometheus is not enabled",
+		},
+		{
+			name:   "another bad request",
+			status: http.StatusBadRequest,
+			body:   `{"errors":["unknown format"]}`,
+			expect: op.Unknown,
+			err:    "400 Bad Request",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+			api, err := client.NewAPIClient(client.APIConfig{Product: "vault", BaseURL: srv.URL})
+			require.NoError(t, err)
+
+			h, err := runner.NewHTTP(runner.HttpConfig{
+				Client:       api,
+				Path:         "/v1/sys/metrics?format=prometheus",
+				Format:       "string",
+				SkipResponse: vaultSkipPrometheusDisabled,
+			})
+			require.NoError(t, err)
+			o := h.Run()
+			assert.Equal(t, tc.expect, o.Status)
+			if tc.err != "" {
+				assert.EqualError(t, o.Error, tc.err)
+			} else {
+				assert.NoError(t, o.Error)
+			}
+		})
+	}
+}
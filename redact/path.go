@@ -0,0 +1,140 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import (
+	"crypto/md5"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// NewPath returns a redactor that matches values in JSON by their key path, rather than by a regular expression, and
+// replaces them whole, whatever their type. The path is a glob of dot-separated segments: each segment is a key, or an
+// array index, and may use the wildcards of path.Match, such as "secret_*"; a "**" segment matches any number of
+// segments. For example, "**.password" matches a password key at any depth, and "data.*.secret_id" matches the
+// secret_id of every element of data. A key that contains dots can be matched with a segment in double quotes, such as
+// `a."b.c".d`; see splitPath. cfg's Matcher must be empty, and ID and Replace are optional.
+func NewPath(cfg Config, p string) (*Redact, error) {
+	if cfg.Matcher != "" {
+		return nil, fmt.Errorf("a path redaction can't also have a matcher, matcher=%s", cfg.Matcher)
+	}
+	if err := ValidatePath(p); err != nil {
+		return nil, err
+	}
+	segments, _ := splitPath(p)
+
+	id := cfg.ID
+	if id == "" {
+		id = fmt.Sprintf("%x", md5.Sum([]byte(p)))
+	}
+	replace := cfg.Replace
+	if replace == "" {
+		replace = DefaultReplace
+	}
+	return &Redact{ID: id, Replace: replace, Path: p, segments: segments}, nil
+}
+
+// ValidatePath returns an error if p is not a valid key path glob for NewPath.
+func ValidatePath(p string) error {
+	if p == "" {
+		return fmt.Errorf("path must not be empty")
+	}
+	segments, err := splitPath(p)
+	if err != nil {
+		return err
+	}
+	for _, seg := range segments {
+		if seg == "**" {
+			continue
+		}
+		if strings.Contains(seg, "**") {
+			return fmt.Errorf("path %q may only use ** as a whole segment", p)
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("path %q has a malformed segment %q: %w", p, seg, err)
+		}
+	}
+	return nil
+}
+
+// splitPath splits p into its dot-separated segments. A segment in double quotes may contain dots, and matches its key
+// exactly: wildcards in it are escaped, so that they match themselves. Within the quotes, a backslash escapes a quote or
+// another backslash. Unquoted segments must not be empty.
+func splitPath(p string) ([]string, error) {
+	var segments []string
+	for i := 0; ; i++ {
+		var seg string
+		if i < len(p) && p[i] == '"' {
+			var b strings.Builder
+			j := i + 1
+			for ; j < len(p) && p[j] != '"'; j++ {
+				if p[j] == '\\' && j+1 < len(p) {
+					j++
+				}
+				if strings.IndexByte(`*?[\`, p[j]) >= 0 {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(p[j])
+			}
+			if j == len(p) {
+				return nil, fmt.Errorf("path %q has an unterminated quoted segment", p)
+			}
+			if j+1 < len(p) && p[j+1] != '.' {
+				return nil, fmt.Errorf("path %q has a quoted segment that isn't followed by a dot", p)
+			}
+			seg, i = b.String(), j+1
+		} else {
+			j := strings.IndexByte(p[i:], '.')
+			if j < 0 {
+				j = len(p) - i
+			}
+			if j == 0 {
+				return nil, fmt.Errorf("path %q has an empty segment", p)
+			}
+			seg, i = p[i:i+j], i+j
+		}
+		segments = append(segments, seg)
+		if i == len(p) {
+			return segments, nil
+		}
+	}
+}
+
+// matchesPath returns true if the redaction is a path redaction that matches keys, the path of a value in JSON.
+func (r *Redact) matchesPath(keys []string) bool {
+	if len(r.segments) == 0 {
+		return false
+	}
+	return matchSegments(r.segments, keys)
+}
+
+func matchSegments(segments, keys []string) bool {
+	for len(segments) > 0 {
+		if segments[0] == "**" {
+			// Try matching the rest of the pattern against every remaining suffix of keys, including none of them.
+			for i := 0; i <= len(keys); i++ {
+				if matchSegments(segments[1:], keys[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(keys) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(segments[0], keys[0]); !ok {
+			return false
+		}
+		segments, keys = segments[1:], keys[1:]
+	}
+	return len(keys) == 0
+}
+
+// replaceValue returns what a value matched by a path redaction is replaced with, and counts the replacement if the
+// redaction is tracked.
+func (r *Redact) replaceValue() any {
+	r.tally.add(r.ID, 1)
+	return r.Replace
+}
@@ -0,0 +1,137 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPath(t *testing.T) {
+	tcs := []struct {
+		name      string
+		cfg       Config
+		path      string
+		expectErr bool
+	}{
+		{name: "Test key anywhere", path: "**.password"},
+		{name: "Test wildcard segments", path: "data.*.secret_*"},
+		{name: "Test empty path", path: "", expectErr: true},
+		{name: "Test empty segment", path: "data..secret", expectErr: true},
+		{name: "Test partial double star", path: "data.**secret", expectErr: true},
+		{name: "Test malformed segment", path: "data.[", expectErr: true},
+		{name: "Test quoted segment", path: `data."b.c".value`},
+		{name: "Test quoted segment with escapes", path: `"say \"hi\"".*`},
+		{name: "Test unterminated quoted segment", path: `data."b.c`, expectErr: true},
+		{name: "Test quoted segment without a dot after it", path: `"b.c"d`, expectErr: true},
+		{name: "Test trailing dot", path: "data.", expectErr: true},
+		{name: "Test matcher and path", cfg: Config{Matcher: "x"}, path: "password", expectErr: true},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewPath(tc.cfg, tc.path)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.path, r.Path)
+			assert.Equal(t, DefaultReplace, r.Replace)
+			assert.NotEmpty(t, r.ID)
+		})
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	tcs := []struct {
+		path   string
+		keys   []string
+		expect bool
+	}{
+		{path: "password", keys: []string{"password"}, expect: true},
+		{path: "password", keys: []string{"db", "password"}, expect: false},
+		{path: "**.password", keys: []string{"password"}, expect: true},
+		{path: "**.password", keys: []string{"a", "b", "password"}, expect: true},
+		{path: "**.password", keys: []string{"password", "length"}, expect: false},
+		{path: "data.*.secret_id", keys: []string{"data", "0", "secret_id"}, expect: true},
+		{path: "data.*.secret_id", keys: []string{"data", "secret_id"}, expect: false},
+		{path: "config.seal.*", keys: []string{"config", "seal", "key"}, expect: true},
+		{path: "config.seal.*", keys: []string{"config", "seal"}, expect: false},
+		{path: "config.**", keys: []string{"config"}, expect: true},
+		{path: "**.token_*", keys: []string{"auth", "token_ttl"}, expect: true},
+		{path: "a.**.b.**.c", keys: []string{"a", "x", "b", "y", "z", "c"}, expect: true},
+		{path: `a."b.c".d`, keys: []string{"a", "b.c", "d"}, expect: true},
+		{path: `a."b.c".d`, keys: []string{"a", "b", "c", "d"}, expect: false},
+		{path: `**."vault.token"`, keys: []string{"env", "vault.token"}, expect: true},
+		{path: `"*"`, keys: []string{"*"}, expect: true},
+		{path: `"*"`, keys: []string{"password"}, expect: false},
+		{path: `"say \"hi\"".x`, keys: []string{`say "hi"`, "x"}, expect: true},
+		{path: `"a\\b"`, keys: []string{`a\b`}, expect: true},
+		{path: `""`, keys: []string{""}, expect: true},
+	}
+
+	for _, tc := range tcs {
+		r, err := NewPath(Config{}, tc.path)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expect, r.matchesPath(tc.keys), "%s against %v", tc.path, tc.keys)
+	}
+}
+
+func TestJSON_Path(t *testing.T) {
+	password, err := NewPath(Config{ID: "password"}, "**.password")
+	require.NoError(t, err)
+	secretID, err := NewPath(Config{ID: "secret-id", Replace: "<SECRET>"}, "data.*.secret_id")
+	require.NoError(t, err)
+	seal, err := NewPath(Config{ID: "seal"}, "config.seal")
+	require.NoError(t, err)
+	email := newTestRedact(t, EmailPattern, EmailReplace)
+
+	in := map[string]any{
+		"password": "top-level",
+		"db": map[string]any{
+			"password": 12345.0,
+			"user":     "admin@example.com",
+		},
+		"data": []any{
+			map[string]any{"secret_id": true, "name": "one"},
+			map[string]any{"secret_id": []any{"a", "b"}, "name": "two"},
+		},
+		"config": map[string]any{
+			"seal": map[string]any{"type": "awskms", "kms_key_id": "abc"},
+		},
+	}
+	expect := map[string]any{
+		"password": DefaultReplace,
+		"db": map[string]any{
+			"password": DefaultReplace,
+			"user":     EmailReplace,
+		},
+		"data": []any{
+			map[string]any{"secret_id": "<SECRET>", "name": "one"},
+			map[string]any{"secret_id": "<SECRET>", "name": "two"},
+		},
+		"config": map[string]any{
+			"seal": DefaultReplace,
+		},
+	}
+
+	tracked, tally := Track([]*Redact{password, secretID, seal, email})
+	result, err := JSON(in, tracked)
+	require.NoError(t, err)
+	assert.Equal(t, expect, result)
+	assert.Equal(t, 2, tally.Counts()["password"])
+	assert.Equal(t, 2, tally.Counts()["secret-id"])
+	assert.Equal(t, 1, tally.Counts()["seal"])
+}
+
+func TestString_IgnoresPaths(t *testing.T) {
+	r, err := NewPath(Config{}, "**")
+	require.NoError(t, err)
+	result, err := String("password: hunter2", []*Redact{r})
+	require.NoError(t, err)
+	assert.Equal(t, "password: hunter2", result)
+}
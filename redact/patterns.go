@@ -0,0 +1,214 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// PatternsVersion identifies the set of built-in patterns. It changes whenever a pattern is added, removed, or its
+// matcher changes, and is recorded in the manifest so that it's clear which patterns were applied to a bundle.
+const PatternsVersion = "2"
+
+// uuid matches a UUID, such as a Consul or Nomad ACL token's secret ID.
+const uuid = `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`
+
+// patterns are the built-in redactions, in the order they're applied. Their IDs are stable, so that they can be
+// disabled by ID in config. Patterns that match a secret by the key it's assigned to only redact the value, and also
+// have a keyPattern, so that they apply to JSON values too.
+var patterns = []Config{
+	{
+		ID:      "pem-private-key",
+		Matcher: `-----BEGIN ([A-Z0-9 ]*)PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`,
+		Replace: "-----BEGIN ${1}PRIVATE KEY-----" + DefaultReplace + "-----END ${1}PRIVATE KEY-----",
+	},
+	{
+		ID:      "vault-service-token",
+		Matcher: `\bhvs\.[A-Za-z0-9_-]{24,}`,
+	},
+	{
+		ID:      "vault-batch-token",
+		Matcher: `\bhvb\.[A-Za-z0-9_-]{24,}`,
+	},
+	{
+		ID:      "vault-legacy-token",
+		Matcher: `\bs\.[A-Za-z0-9]{24}\b`,
+	},
+	{
+		ID:      "consul-acl-secret-id",
+		Matcher: `(?i)((?:x-consul-token|consul_http_token|consul_token|secret_?id|acl_token|(?:acl_)?(?:master|initial_management|agent_recovery|agent_master|replication)_?token|initial_management|agent_recovery)\W{1,5})` + uuid,
+		Replace: "${1}" + DefaultReplace,
+	},
+	{
+		ID:      "nomad-secret-id",
+		Matcher: `(?i)((?:x-nomad-token|nomad_token|bootstrap_token)\W{1,5})` + uuid,
+		Replace: "${1}" + DefaultReplace,
+	},
+	{
+		ID:      "aws-access-key-id",
+		Matcher: `\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`,
+	},
+	{
+		ID:      "aws-secret-access-key",
+		Matcher: `(?i)(aws_?secret_?access_?key\W{1,5})[A-Za-z0-9/+=]{40}\b`,
+		Replace: "${1}" + DefaultReplace,
+	},
+	{
+		ID:      "gcp-api-key",
+		Matcher: `\bAIza[0-9A-Za-z_-]{35}`,
+	},
+	{
+		ID:      "azure-storage-account-key",
+		Matcher: `(?i)(AccountKey=)[A-Za-z0-9+/]{86}==`,
+		Replace: "${1}" + DefaultReplace,
+	},
+	{
+		ID:      "azure-client-secret",
+		Matcher: `(?i)((?:azure_client_secret|arm_client_secret|client_secret)\W{1,5})[A-Za-z0-9_.~-]{32,}`,
+		Replace: "${1}" + DefaultReplace,
+	},
+	{
+		ID:      "jwt",
+		Matcher: `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`,
+	},
+	{
+		ID:      "authorization-header",
+		Matcher: `(?i)(authorization\W{1,5}(?:bearer|basic|token)\s+)[^\s"',]+`,
+		Replace: "${1}" + DefaultReplace,
+	},
+	{
+		ID:      "url-basic-auth",
+		Matcher: `([a-zA-Z][a-zA-Z0-9+.-]*://[^:/@\s"']+:)[^@/\s"']+@`,
+		Replace: "${1}" + DefaultReplace + "@",
+	},
+	{
+		ID:      "email-address",
+		Matcher: EmailPattern,
+		Replace: EmailReplace,
+	},
+}
+
+// keyPattern redacts a secret in JSON by the key it's assigned to. In decoded JSON, a key and its value are separate
+// strings, so a pattern whose matcher needs both never matches there; its keyPattern applies to the value instead.
+type keyPattern struct {
+	// Key is matched against the key, in full.
+	Key string
+	// Value is matched against the value, and its matches are replaced with Replace, or DefaultReplace if it's empty.
+	Value   string
+	Replace string
+}
+
+// keyPatterns are the keyPatterns of the built-in redactions that match a secret by its key, by the pattern's ID.
+var keyPatterns = map[string]keyPattern{
+	"consul-acl-secret-id": {
+		Key:   `(?i)^(?:x-consul-token|consul_http_token|consul_token|secret_?id|acl_token|(?:acl_)?(?:master|initial_management|agent_recovery|agent_master|replication)_?token|initial_management|agent_recovery)$`,
+		Value: uuid,
+	},
+	"nomad-secret-id": {
+		Key:   `(?i)^(?:x-nomad-token|nomad_token|bootstrap_token)$`,
+		Value: uuid,
+	},
+	"aws-secret-access-key": {
+		Key:   `(?i)^aws_?secret_?access_?key$`,
+		Value: `^[A-Za-z0-9/+=]{40}$`,
+	},
+	"azure-client-secret": {
+		Key:   `(?i)^(?:azure_client_secret|arm_client_secret|client_secret)$`,
+		Value: `[A-Za-z0-9_.~-]{32,}`,
+	},
+	"authorization-header": {
+		Key:     `(?i)^authorization$`,
+		Value:   `(?i)^((?:bearer|basic|token)\s+)\S+`,
+		Replace: "${1}" + DefaultReplace,
+	},
+}
+
+// newPattern returns the redaction for the built-in pattern cfg, including its keyPattern, if it has one.
+func newPattern(cfg Config) (*Redact, error) {
+	r, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	kp, ok := keyPatterns[cfg.ID]
+	if !ok {
+		return r, nil
+	}
+	if r.key, err = regexp.Compile(kp.Key); err != nil {
+		return nil, err
+	}
+	if r.keyValue, err = regexp.Compile(kp.Value); err != nil {
+		return nil, err
+	}
+	r.keyReplace = kp.Replace
+	if r.keyReplace == "" {
+		r.keyReplace = DefaultReplace
+	}
+	return r, nil
+}
+
+// replaceKeyValue redacts value, a JSON string assigned to key, if the redaction has a keyPattern that matches key.
+func (r *Redact) replaceKeyValue(key string, value []byte) []byte {
+	if r.key == nil || !r.key.MatchString(key) {
+		return value
+	}
+	n := len(r.keyValue.FindAllIndex(value, -1))
+	if n == 0 {
+		return value
+	}
+	r.tally.add(r.ID, n)
+	return r.keyValue.ReplaceAll(value, []byte(r.keyReplace))
+}
+
+// Patterns returns the configs of the built-in redactions, in the order they're applied.
+func Patterns() []Config {
+	return append([]Config(nil), patterns...)
+}
+
+// PatternIDs returns the sorted IDs of the built-in redactions.
+func PatternIDs() []string {
+	ids := make([]string, len(patterns))
+	for i, p := range patterns {
+		ids[i] = p.ID
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Defaults returns the built-in redactions, except those whose IDs are in disabled. It is an error to disable a
+// pattern that doesn't exist, so that a typo doesn't leave a secret unredacted unnoticed.
+func Defaults(disabled []string) ([]*Redact, error) {
+	skip := make(map[string]bool, len(disabled))
+	for _, id := range disabled {
+		skip[id] = true
+	}
+
+	configs := make([]Config, 0, len(patterns))
+	for _, p := range patterns {
+		if skip[p.ID] {
+			delete(skip, p.ID)
+			continue
+		}
+		configs = append(configs, p)
+	}
+	if 0 < len(skip) {
+		unknown := make([]string, 0, len(skip))
+		for id := range skip {
+			unknown = append(unknown, id)
+		}
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown built-in redactions %s; expected any of %s", strings.Join(unknown, ", "), strings.Join(PatternIDs(), ", "))
+	}
+	redactions := make([]*Redact, len(configs))
+	for i, cfg := range configs {
+		r, err := newPattern(cfg)
+		if err != nil {
+			return nil, err
+		}
+		redactions[i] = r
+	}
+	return redactions, nil
+}
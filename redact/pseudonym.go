@@ -0,0 +1,161 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// pseudonymLength is the number of hex characters of the HMAC that are included in a pseudonym.
+const pseudonymLength = 8
+
+// pseudonymPrefix matches valid prefixes for pseudonyms, such as "IP" or "NODE".
+var pseudonymPrefix = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// NewPseudonym returns a redactor that replaces each distinct match with a stable pseudonym that starts with prefix,
+// such as "IP-7f3a9c01", using cfg.Pseudonymizer. ID is optional, but cfg must not have a Replace. Unlike a Replace,
+// which replaces the whole match, a pseudonym only replaces the text matched by the pattern's first capture group, if
+// it has any, and leaves the rest of the match as it is.
+func NewPseudonym(cfg Config, prefix string) (*Redact, error) {
+	if cfg.Replace != "" {
+		return nil, fmt.Errorf("a redaction can't have both a replace and a pseudonym, replace=%s", cfg.Replace)
+	}
+	if err := ValidatePseudonym(prefix); err != nil {
+		return nil, err
+	}
+	r, err := New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r.Replace = ""
+	r.Pseudonym = prefix
+	r.pseudonymizer = cfg.Pseudonymizer
+	if r.pseudonymizer == nil {
+		r.pseudonymizer = NewPseudonymizer()
+	}
+	return r, nil
+}
+
+// ValidatePseudonym returns an error if prefix can't be used as the prefix of pseudonyms.
+func ValidatePseudonym(prefix string) error {
+	if !pseudonymPrefix.MatchString(prefix) {
+		return fmt.Errorf("invalid pseudonym prefix %q; it must start with a letter, and contain only letters, digits, and underscores", prefix)
+	}
+	return nil
+}
+
+// Pseudonymizer maps each distinct value to a stable pseudonym, using an HMAC keyed with a random key. Each run creates
+// its own, and shares it between all of its redactions, so the same value always has the same pseudonym within a run,
+// and can be correlated across a bundle. The key is never written anywhere, so pseudonyms can't be reversed, nor
+// correlated between runs. It is safe for concurrent use.
+type Pseudonymizer struct {
+	key []byte
+
+	mu sync.Mutex
+	// mapping records the value behind each pseudonym, if KeepMapping has been called.
+	mapping map[string]string
+}
+
+// NewPseudonymizer returns a Pseudonymizer with a new random key.
+func NewPseudonymizer() *Pseudonymizer {
+	key := make([]byte, 32)
+	// crypto/rand.Read never returns an error; it crashes the program if the system's random source fails.
+	_, _ = rand.Read(key)
+	return &Pseudonymizer{key: key}
+}
+
+// Pseudonym returns the pseudonym for value, which is prefix, a hyphen, and the first characters of the hex-encoded
+// HMAC-SHA256 of value.
+func (p *Pseudonymizer) Pseudonym(prefix, value string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(value))
+	pseudonym := prefix + "-" + hex.EncodeToString(mac.Sum(nil))[:pseudonymLength]
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mapping != nil {
+		p.mapping[pseudonym] = value
+	}
+	return pseudonym
+}
+
+// KeepMapping makes the Pseudonymizer record the value behind each pseudonym from now on, so that it can be written
+// out with WriteMapping.
+func (p *Pseudonymizer) KeepMapping() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.mapping == nil {
+		p.mapping = make(map[string]string)
+	}
+}
+
+// pseudonymMapping is the format of a mapping file.
+type pseudonymMapping struct {
+	Pseudonym string `json:"pseudonym"`
+	Value     string `json:"value"`
+}
+
+// WriteMapping writes the value behind each pseudonym, as recorded since KeepMapping was called, to a JSON file at
+// path. The file is only readable by its owner, since it contains everything that was pseudonymized.
+func (p *Pseudonymizer) WriteMapping(path string) error {
+	p.mu.Lock()
+	if p.mapping == nil {
+		p.mu.Unlock()
+		return fmt.Errorf("pseudonym mapping was not kept")
+	}
+	mapping := make([]pseudonymMapping, 0, len(p.mapping))
+	for pseudonym, value := range p.mapping {
+		mapping = append(mapping, pseudonymMapping{Pseudonym: pseudonym, Value: value})
+	}
+	p.mu.Unlock()
+
+	sort.Slice(mapping, func(i, j int) bool { return mapping[i].Pseudonym < mapping[j].Pseudonym })
+	bts, err := json.MarshalIndent(mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bts, 0600)
+}
+
+// pseudonymize replaces each match in b with its pseudonym. If the redaction's pattern has capture groups, only the
+// text matched by the first group is replaced, so that a pattern can match a value by its surroundings; any other
+// groups are ignored, and a match in which the first group doesn't participate is left as it is.
+func (r *Redact) pseudonymize(b []byte) []byte {
+	matches := r.matcher.FindAllSubmatchIndex(b, -1)
+	if len(matches) == 0 {
+		return b
+	}
+
+	out := make([]byte, 0, len(b))
+	last := 0
+	n := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if 4 <= len(m) {
+			start, end = m[2], m[3]
+		}
+		if start < 0 {
+			// The first group didn't participate in the match, so there's nothing to replace.
+			continue
+		}
+		out = append(out, b[last:start]...)
+		out = append(out, r.pseudonymizer.Pseudonym(r.Pseudonym, string(b[start:end]))...)
+		last = end
+		n++
+	}
+	out = append(out, b[last:]...)
+	if 0 < n {
+		r.tally.add(r.ID, n)
+	}
+	return out
+}
@@ -0,0 +1,173 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ipPattern = `\b\d{1,3}(?:\.\d{1,3}){3}\b`
+
+var pseudonymToken = regexp.MustCompile(`^IP-[0-9a-f]{8}$`)
+
+func TestNewPseudonym(t *testing.T) {
+	tcs := []struct {
+		name      string
+		cfg       Config
+		prefix    string
+		expectErr bool
+	}{
+		{
+			name:   "Test valid pseudonym",
+			cfg:    Config{Matcher: ipPattern},
+			prefix: "IP",
+		},
+		{
+			name:      "Test replace and pseudonym conflict",
+			cfg:       Config{Matcher: ipPattern, Replace: "x"},
+			prefix:    "IP",
+			expectErr: true,
+		},
+		{
+			name:      "Test invalid prefix",
+			cfg:       Config{Matcher: ipPattern},
+			prefix:    "1-IP",
+			expectErr: true,
+		},
+		{
+			name:      "Test invalid matcher",
+			cfg:       Config{Matcher: "("},
+			prefix:    "IP",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := NewPseudonym(tc.cfg, tc.prefix)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.prefix, r.Pseudonym)
+			assert.Empty(t, r.Replace)
+		})
+	}
+}
+
+func TestPseudonymize(t *testing.T) {
+	r, err := NewPseudonym(Config{ID: "ip", Matcher: ipPattern}, "IP")
+	require.NoError(t, err)
+	redactions := []*Redact{r}
+
+	s, err := String("peers 10.0.0.1 10.0.0.2 10.0.0.1", redactions)
+	require.NoError(t, err)
+	var first, second, third string
+	_, err = fmt.Sscanf(s, "peers %s %s %s", &first, &second, &third)
+	require.NoError(t, err)
+	assert.Regexp(t, pseudonymToken, first)
+	assert.NotEqual(t, first, second, "distinct values have distinct pseudonyms")
+	assert.Equal(t, first, third, "the same value has the same pseudonym")
+
+	// Bytes, JSON, and Writer all give a value the same pseudonym.
+	b, err := Bytes([]byte("10.0.0.1"), redactions)
+	require.NoError(t, err)
+	assert.Equal(t, first, string(b))
+
+	j, err := JSON(map[string]any{"leader": "10.0.0.1:8300"}, redactions)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"leader": first + ":8300"}, j)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, redactions)
+	_, err = w.Write([]byte("10.0.0.1\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, first+"\n", buf.String())
+
+	// Tracked pseudonyms are counted, too.
+	tracked, tally := Track(redactions)
+	_, err = String("10.0.0.1 10.0.0.2", tracked)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"ip": 2}, tally.Counts())
+}
+
+func TestPseudonymize_Group(t *testing.T) {
+	r, err := NewPseudonym(Config{Matcher: `node_name = "([^"]+)"`}, "NODE")
+	require.NoError(t, err)
+
+	s, err := String(`node_name = "server-1"`, []*Redact{r})
+	require.NoError(t, err)
+	assert.Regexp(t, `^node_name = "NODE-[0-9a-f]{8}"$`, s)
+
+	// Only the first group is replaced; later groups are left as they are.
+	r, err = NewPseudonym(Config{Matcher: `(\w+)@(\w+)\.com`}, "USER")
+	require.NoError(t, err)
+	s, err = String("admin@example.com", []*Redact{r})
+	require.NoError(t, err)
+	assert.Regexp(t, `^USER-[0-9a-f]{8}@example\.com$`, s)
+
+	// A match in which the first group doesn't participate is left as it is.
+	r, err = NewPseudonym(Config{Matcher: `token=(\w+)|token=-`}, "TOKEN")
+	require.NoError(t, err)
+	s, err = String("token=-", []*Redact{r})
+	require.NoError(t, err)
+	assert.Equal(t, "token=-", s)
+}
+
+func TestNewPseudonym_SharedPseudonymizer(t *testing.T) {
+	p := NewPseudonymizer()
+	ip, err := NewPseudonym(Config{Matcher: ipPattern, Pseudonymizer: p}, "IP")
+	require.NoError(t, err)
+	leader, err := NewPseudonym(Config{Matcher: `leader (\S+)`, Pseudonymizer: p}, "IP")
+	require.NoError(t, err)
+	alone, err := NewPseudonym(Config{Matcher: ipPattern}, "IP")
+	require.NoError(t, err)
+
+	a, err := String("10.0.0.1", []*Redact{ip})
+	require.NoError(t, err)
+	b, err := String("leader 10.0.0.1", []*Redact{leader})
+	require.NoError(t, err)
+	c, err := String("10.0.0.1", []*Redact{alone})
+	require.NoError(t, err)
+	assert.Equal(t, "leader "+a, b, "redactions that share a Pseudonymizer give a value the same pseudonym")
+	assert.NotEqual(t, a, c, "a redaction without one gets its own")
+}
+
+func TestPseudonymizer(t *testing.T) {
+	p1 := NewPseudonymizer()
+	p2 := NewPseudonymizer()
+	assert.Equal(t, p1.Pseudonym("IP", "10.0.0.1"), p1.Pseudonym("IP", "10.0.0.1"))
+	assert.NotEqual(t, p1.Pseudonym("IP", "10.0.0.1"), p2.Pseudonym("IP", "10.0.0.1"), "keys differ between runs")
+
+	path := filepath.Join(t.TempDir(), "pseudonyms.json")
+	assert.Error(t, p1.WriteMapping(path), "mapping is not kept by default")
+
+	p1.KeepMapping()
+	token := p1.Pseudonym("IP", "10.0.0.1")
+	require.NoError(t, p1.WriteMapping(path))
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	bts, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var mapping []map[string]string
+	require.NoError(t, json.Unmarshal(bts, &mapping))
+	assert.Equal(t, []map[string]string{{"pseudonym": token, "value": "10.0.0.1"}}, mapping)
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io"
 	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -26,12 +27,27 @@ type Redact struct {
 	ID      string `json:"ID"`
 	matcher *regexp.Regexp
 	Replace string `json:"replace"`
+	// Pseudonym, if set, is the prefix of the pseudonyms that matches are replaced with, in place of Replace.
+	Pseudonym string `json:"pseudonym,omitempty"`
+	// Path, if set, is the JSON key path glob that this redaction matches, in place of a regular expression.
+	Path          string `json:"path,omitempty"`
+	segments      []string
+	pseudonymizer *Pseudonymizer
+	// key, keyValue, and keyReplace are set from the keyPattern of a built-in redaction; see replaceKeyValue.
+	key        *regexp.Regexp
+	keyValue   *regexp.Regexp
+	keyReplace string
+	// tally, if set, counts the replacements made by this redaction.
+	tally *Tally
 }
 
 type Config struct {
 	ID      string
 	Matcher string
 	Replace string
+	// Pseudonymizer gives NewPseudonym's matches their pseudonyms. Redactions that share a Pseudonymizer give the same
+	// value the same pseudonym. If nil, NewPseudonym creates one for the redaction alone.
+	Pseudonymizer *Pseudonymizer
 }
 
 // New takes the matcher as a string and returned a compiled and ready-to-use redactor. ID and Replace are
@@ -51,7 +67,7 @@ func New(cfg Config) (*Redact, error) {
 	if replace == "" {
 		replace = DefaultReplace
 	}
-	return &Redact{id, r, replace}, nil
+	return &Redact{ID: id, matcher: r, Replace: replace}, nil
 }
 
 // Apply takes a slice of redactions and a writer + reader, reading everything in and applying redactions in
@@ -74,7 +90,7 @@ func Apply(redactions []*Redact, w io.Writer, r io.Reader) error {
 		return nil
 	}
 	for _, redact := range redactions {
-		bts = redact.matcher.ReplaceAll(bts, []byte(redact.Replace))
+		bts = redact.replaceAll(bts)
 	}
 	_, err = w.Write(bts)
 	if err != nil {
@@ -119,7 +135,9 @@ func Bytes(b []byte, redactions []*Redact) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
-// JSON accepts a json map or array and traverses the collections and redacts any strings we find.
+// JSON accepts a json map or array and traverses the collections and redacts any strings we find. Values whose key
+// path matches a path redaction are replaced whole, whatever their type, and built-in redactions that find a secret by
+// its key also apply to the strings assigned to that key.
 func JSON(a any, redactions []*Redact) (any, error) {
 	if len(redactions) == 0 {
 		return a, nil
@@ -127,13 +145,13 @@ func JSON(a any, redactions []*Redact) (any, error) {
 
 	switch coll := a.(type) {
 	case map[string]any:
-		r, err := redactMap(coll, redactions)
+		r, err := redactMap(coll, nil, redactions)
 		if err != nil {
 			return nil, err
 		}
 		return r, nil
 	case []any:
-		r, err := redactSlice(coll, redactions)
+		r, err := redactSlice(coll, nil, redactions)
 		if err != nil {
 			return nil, err
 		}
@@ -143,62 +161,73 @@ func JSON(a any, redactions []*Redact) (any, error) {
 	}
 }
 
-func redactSlice(a []any, redactions []*Redact) ([]any, error) {
+func redactSlice(a []any, path []string, redactions []*Redact) ([]any, error) {
 	for i, v := range a {
-		switch val := v.(type) {
-		case map[string]any:
-			res, err := redactMap(val, redactions)
-			if err != nil {
-				return nil, err
-			}
-			a[i] = res
-		case []any:
-			res, err := redactSlice(val, redactions)
-			if err != nil {
-				return nil, err
-			}
-			a[i] = res
-		case string:
-			res, err := String(val, redactions)
-			if err != nil {
-				return nil, err
-			}
-			a[i] = res
-		default:
-			continue
+		res, err := redactValue(v, appendPath(path, strconv.Itoa(i)), redactions)
+		if err != nil {
+			return nil, err
 		}
+		a[i] = res
 	}
 	return a, nil
 }
 
-func redactMap(m map[string]any, redactions []*Redact) (map[string]any, error) {
+func redactMap(m map[string]any, path []string, redactions []*Redact) (map[string]any, error) {
 	for k, v := range m {
-		switch val := v.(type) {
-		case map[string]any:
-			res, err := redactMap(val, redactions)
-			if err != nil {
-				return nil, err
-			}
-			m[k] = res
-		case []any:
-			res, err := redactSlice(val, redactions)
-			if err != nil {
-				return nil, err
-			}
-			m[k] = res
-		case string:
-			res, err := String(val, redactions)
-			if err != nil {
-				return nil, err
-			}
-			m[k] = res
-		default:
-			continue
+		res, err := redactValue(v, appendPath(path, k), redactions)
+		if err != nil {
+			return nil, err
 		}
+		m[k] = res
 	}
 	return m, nil
 }
 
+// redactValue redacts v, which is at path in a JSON document. If a path redaction matches path, v is replaced
+// whole; otherwise, collections are traversed, and strings are redacted.
+func redactValue(v any, path []string, redactions []*Redact) (any, error) {
+	for _, r := range redactions {
+		if r.matchesPath(path) {
+			return r.replaceValue(), nil
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		return redactMap(val, path, redactions)
+	case []any:
+		return redactSlice(val, path, redactions)
+	case string:
+		return redactString(val, path, redactions), nil
+	default:
+		return v, nil
+	}
+}
+
+// redactString applies redactions, in order, to s, a string at path in a JSON document. Redactions with a keyPattern
+// also apply to s by its key, which is the last key in path other than array indexes, so that they apply to arrays of
+// values too, such as the values of headers.
+func redactString(s string, path []string, redactions []*Redact) string {
+	var key string
+	for i := len(path) - 1; 0 <= i; i-- {
+		if _, err := strconv.Atoi(path[i]); err != nil {
+			key = path[i]
+			break
+		}
+	}
+	b := []byte(s)
+	for _, r := range redactions {
+		b = r.replaceAll(b)
+		b = r.replaceKeyValue(key, b)
+	}
+	return string(b)
+}
+
+// appendPath returns a new path with key appended to path, without changing path.
+func appendPath(path []string, key string) []string {
+	return append(path[:len(path):len(path)], key)
+}
+
 // Flatten takes any number of slices of redacts and returns one slice containing all redacts in argument order
 func Flatten(redacts ...[]*Redact) []*Redact {
 	flattened := make([]*Redact, 0)
@@ -220,3 +249,167 @@ func MapNew(configs []Config) ([]*Redact, error) {
 	}
 	return redactions, nil
 }
+
+// MaxLineLength is the longest line that a Writer holds in memory. Longer lines are redacted in chunks of up to this
+// size, which overlap by MaxMatchLength.
+const MaxLineLength = 1 << 20
+
+// MaxMatchLength is the longest match that a Writer is sure to redact when it spans two chunks of a long line. Each
+// chunk ends before any match that crosses its last MaxMatchLength bytes, and the rest is carried into the next chunk.
+const MaxMatchLength = 64 << 10
+
+// MaxBlockLength is the most of a PEM private key that spans lines that a Writer holds in memory. If the key's END line
+// hasn't arrived by then, what has been held is dropped, rather than written out unredacted, and so is the rest of the
+// key's base64, up to and including its END line.
+const MaxBlockLength = MaxMatchLength
+
+// pemBegin and pemEnd match the lines that begin and end a PEM-encoded private key, and pemBody matches the base64
+// lines in between.
+var (
+	pemBegin = regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----`)
+	pemEnd   = regexp.MustCompile(`-----END [A-Z0-9 ]*PRIVATE KEY-----`)
+	pemBody  = regexp.MustCompile(`^\s*[A-Za-z0-9+/=]*\s*$`)
+)
+
+var _ io.WriteCloser = &Writer{}
+
+// Writer applies redactions to everything written to it, and writes the result to an underlying io.Writer. Unlike
+// Apply, it works a line at a time, so that only the current line is held in memory no matter how much is written.
+// As a consequence, a match can't span more than one line, and one that spans chunks of a line longer than
+// MaxLineLength is only redacted if it's no longer than MaxMatchLength. The exception is a PEM private key, which is
+// held from its BEGIN line to its END line, up to MaxBlockLength, and redacted whole. Close must be called to write any
+// final, unterminated line.
+type Writer struct {
+	w          io.Writer
+	redactions []*Redact
+	buf        []byte
+	// block holds the lines of a PEM private key, from its BEGIN line, until its END line arrives. It's nil otherwise.
+	block []byte
+	// dropping is true while the rest of a key that was longer than MaxBlockLength is dropped.
+	dropping bool
+}
+
+// NewWriter returns a Writer that applies redactions, in order, to each line written to it before writing it to w.
+func NewWriter(w io.Writer, redactions []*Redact) *Writer {
+	return &Writer{w: w, redactions: redactions}
+}
+
+// Write buffers p, and redacts and writes out every complete line.
+func (rw *Writer) Write(p []byte) (int, error) {
+	if len(rw.redactions) == 0 {
+		return rw.w.Write(p)
+	}
+
+	rw.buf = append(rw.buf, p...)
+	start := 0
+	for {
+		i := bytes.IndexByte(rw.buf[start:], '\n')
+		if i == -1 {
+			break
+		}
+		if err := rw.line(rw.buf[start:start+i], true); err != nil {
+			return 0, err
+		}
+		start += i + 1
+	}
+	for len(rw.buf)-start >= MaxLineLength {
+		chunk := rw.buf[start : start+MaxLineLength]
+		cut := rw.chunkEnd(chunk)
+		if err := rw.line(chunk[:cut], false); err != nil {
+			return 0, err
+		}
+		start += cut
+	}
+	n := copy(rw.buf, rw.buf[start:])
+	rw.buf = rw.buf[:n]
+	return len(p), nil
+}
+
+// Close redacts and writes out any remaining partial line. A PEM private key whose END line never arrived is dropped.
+// It does not close the underlying io.Writer.
+func (rw *Writer) Close() error {
+	if rw.block != nil || rw.dropping {
+		rw.block, rw.dropping = nil, false
+		rw.buf = rw.buf[:0]
+		return nil
+	}
+	if len(rw.buf) == 0 {
+		return nil
+	}
+	err := rw.writeLine(rw.buf, false)
+	rw.buf = rw.buf[:0]
+	return err
+}
+
+// line redacts and writes out line, like writeLine, unless it begins a PEM private key or is part of one. The lines of
+// a key are held in rw.block, and redacted together once its END line arrives, so that the key is redacted whole.
+func (rw *Writer) line(line []byte, newline bool) error {
+	if rw.dropping {
+		if pemEnd.Match(line) {
+			rw.dropping = false
+			return nil
+		}
+		if pemBody.Match(line) {
+			return nil
+		}
+		rw.dropping = false
+	}
+
+	if rw.block == nil {
+		// A key that ends on the line it begins on is redacted like any other match.
+		loc := pemBegin.FindIndex(line)
+		if loc == nil || pemEnd.Match(line[loc[1]:]) {
+			return rw.writeLine(line, newline)
+		}
+		rw.block = make([]byte, 0, len(line)+1)
+	} else if pemEnd.Match(line) {
+		block := append(rw.block, line...)
+		rw.block = nil
+		return rw.writeLine(block, newline)
+	}
+
+	rw.block = append(rw.block, line...)
+	if newline {
+		rw.block = append(rw.block, '\n')
+	}
+	if MaxBlockLength < len(rw.block) {
+		rw.block, rw.dropping = nil, true
+	}
+	return nil
+}
+
+// chunkEnd returns how much of chunk, a part of a long line, can be redacted and written out without splitting a
+// match: everything up to its last MaxMatchLength bytes, or up to the start of a match that crosses into them.
+func (rw *Writer) chunkEnd(chunk []byte) int {
+	var matches [][]int
+	for _, redact := range rw.redactions {
+		if redact.matcher != nil {
+			matches = append(matches, redact.matcher.FindAllIndex(chunk, -1)...)
+		}
+	}
+	end := len(chunk) - MaxMatchLength
+	// Moving the end back to the start of one match may make it cross another, so repeat until none do. A match that
+	// starts the chunk can't be kept whole, so it's left to be split.
+	for moved := true; moved; {
+		moved = false
+		for _, m := range matches {
+			if 0 < m[0] && m[0] < end && end < m[1] {
+				end, moved = m[0], true
+			}
+		}
+	}
+	return end
+}
+
+// writeLine redacts line, which excludes its line ending, and writes it out along with a newline if newline is true.
+func (rw *Writer) writeLine(line []byte, newline bool) error {
+	for _, redact := range rw.redactions {
+		line = redact.replaceAll(line)
+	}
+	if newline {
+		// line may still be a slice of rw.buf, so make sure appending to it copies it.
+		line = append(line[:len(line):len(line)], '\n')
+	}
+	_, err := rw.w.Write(line)
+	return err
+}
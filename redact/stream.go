@@ -0,0 +1,154 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// StreamJSON reads JSON values from r, redacts them as JSON does, and writes them to w, one per line. Unlike JSON, it
+// holds only one token in memory at a time, so that it can redact documents of any size. The values are written
+// compactly, whatever their original formatting. If r is not valid JSON, an error is returned, and w may already
+// have been written to.
+func StreamJSON(w io.Writer, r io.Reader, redactions []*Redact) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	bw := bufio.NewWriter(w)
+	s := jsonStream{dec: dec, w: bw, redactions: redactions}
+
+	for first := true; dec.More(); first = false {
+		if !first {
+			if err := bw.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+		if err := s.value(nil); err != nil {
+			return err
+		}
+	}
+	// More is also false for a stray closing delimiter, which only Token reports.
+	if _, err := dec.Token(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected delimiter")
+		}
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return bw.Flush()
+}
+
+// jsonStream redacts one JSON value at a time from dec, and writes it to w.
+type jsonStream struct {
+	dec        *json.Decoder
+	w          *bufio.Writer
+	redactions []*Redact
+}
+
+// value redacts the next value, which is at path, and writes it out. As in JSON, path redactions replace a value whole,
+// and only strings are redacted by regular expressions.
+func (s jsonStream) value(path []string) error {
+	if 0 < len(path) {
+		for _, r := range s.redactions {
+			if r.matchesPath(path) {
+				if err := s.skip(); err != nil {
+					return err
+				}
+				return s.encode(r.replaceValue())
+			}
+		}
+	}
+
+	tok, err := s.dec.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		return s.collection(t, path)
+	case string:
+		return s.encode(redactString(t, path, s.redactions))
+	default:
+		// json.Number, bool, or nil
+		return s.encode(t)
+	}
+}
+
+// collection redacts and writes out the object or array that starts with open, up to and including its closing
+// delimiter.
+func (s jsonStream) collection(open json.Delim, path []string) error {
+	if err := s.w.WriteByte(byte(open)); err != nil {
+		return err
+	}
+	for i := 0; s.dec.More(); i++ {
+		if 0 < i {
+			if err := s.w.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		key := strconv.Itoa(i)
+		if open == '{' {
+			tok, err := s.dec.Token()
+			if err != nil {
+				return fmt.Errorf("invalid JSON: %w", err)
+			}
+			key = tok.(string)
+			if err := s.encode(key); err != nil {
+				return err
+			}
+			if err := s.w.WriteByte(':'); err != nil {
+				return err
+			}
+		}
+		if err := s.value(appendPath(path, key)); err != nil {
+			return err
+		}
+	}
+	tok, err := s.dec.Token()
+	if err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	closing, ok := tok.(json.Delim)
+	if !ok {
+		return fmt.Errorf("invalid JSON: unexpected token %v", tok)
+	}
+	return s.w.WriteByte(byte(closing))
+}
+
+// skip consumes the next value, whatever its type, without writing it out.
+func (s jsonStream) skip() error {
+	for depth := 0; ; {
+		tok, err := s.dec.Token()
+		if err != nil {
+			return fmt.Errorf("invalid JSON: %w", err)
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// encode writes v out as JSON, without escaping HTML characters, so that replacements such as "<REDACTED>" are
+// written as they are.
+func (s jsonStream) encode(v any) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := s.w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return err
+}
@@ -0,0 +1,70 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamJSON(t *testing.T) {
+	path, err := NewPath(Config{}, "**.password")
+	require.NoError(t, err)
+	redactions := []*Redact{newTestRedact(t, "secret", "<REDACTED>"), path}
+
+	tcs := []struct {
+		name      string
+		in        string
+		expect    string
+		expectErr bool
+	}{
+		{
+			name:   "strings are redacted, and other values are kept as they are",
+			in:     `{"a": "a secret", "b": [1, 2.50, true, null, "secret"]}`,
+			expect: `{"a":"a <REDACTED>","b":[1,2.50,true,null,"<REDACTED>"]}`,
+		},
+		{
+			name:   "values at redacted paths are replaced whole",
+			in:     `{"db": {"password": {"nested": ["x", {"y": 1}]}, "user": "admin"}, "password": 1234}`,
+			expect: `{"db":{"password":"<REDACTED>","user":"admin"},"password":"<REDACTED>"}`,
+		},
+		{
+			name:   "keys are kept",
+			in:     `[{"secret": "value"}]`,
+			expect: `[{"secret":"value"}]`,
+		},
+		{
+			name:   "multiple values",
+			in:     "{\"a\": \"secret\"}\n{\"a\": \"public\"}\n",
+			expect: "{\"a\":\"<REDACTED>\"}\n{\"a\":\"public\"}",
+		},
+		{
+			name:      "truncated JSON",
+			in:        `{"a": ["secret"`,
+			expectErr: true,
+		},
+		{
+			name:      "stray delimiter",
+			in:        `{"a": 1}]`,
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := StreamJSON(&buf, strings.NewReader(tc.in), redactions)
+			if tc.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expect, buf.String())
+		})
+	}
+}
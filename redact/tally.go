@@ -0,0 +1,93 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import "sync"
+
+// Tally counts the replacements made by redactions, by redaction ID. Only the counts are kept, never the text that
+// was replaced. It is safe for concurrent use, and a nil *Tally counts nothing.
+type Tally struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// Track returns copies of redactions that count the replacements they make in the returned Tally. The copies apply
+// the same patterns as the originals, which are left as they are.
+func Track(redactions []*Redact) ([]*Redact, *Tally) {
+	t := &Tally{}
+	if len(redactions) == 0 {
+		return redactions, t
+	}
+	tracked := make([]*Redact, len(redactions))
+	for i, r := range redactions {
+		c := *r
+		c.tally = t
+		tracked[i] = &c
+	}
+	return tracked, t
+}
+
+// Counts returns the number of replacements made by each redaction, by ID. Redactions that haven't replaced anything
+// are left out, and the result is nil if none have.
+func (t *Tally) Counts() map[string]int {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.counts) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(t.counts))
+	for id, n := range t.counts {
+		counts[id] = n
+	}
+	return counts
+}
+
+func (t *Tally) add(id string, n int) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.counts == nil {
+		t.counts = make(map[string]int)
+	}
+	t.counts[id] += n
+}
+
+// replaceAll applies the redaction to b, and counts the replacements if the redaction is tracked.
+func (r *Redact) replaceAll(b []byte) []byte {
+	if r.matcher == nil {
+		// Path redactions only apply to JSON values, in JSON.
+		return b
+	}
+	if r.Pseudonym != "" {
+		return r.pseudonymize(b)
+	}
+	if r.tally == nil {
+		return r.matcher.ReplaceAll(b, []byte(r.Replace))
+	}
+	n := len(r.matcher.FindAllIndex(b, -1))
+	if n == 0 {
+		return b
+	}
+	r.tally.add(r.ID, n)
+	return r.matcher.ReplaceAll(b, []byte(r.Replace))
+}
+
+// MergeCounts adds the counts in each of counts together, by redaction ID. The result is nil if they're all empty.
+func MergeCounts(counts ...map[string]int) map[string]int {
+	var merged map[string]int
+	for _, c := range counts {
+		for id, n := range c {
+			if merged == nil {
+				merged = make(map[string]int)
+			}
+			merged[id] += n
+		}
+	}
+	return merged
+}
@@ -0,0 +1,57 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package redact
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrack(t *testing.T) {
+	secret := newTestRedact(t, "secret", "REDACTED")
+	secret.ID = "secret"
+	email, err := New(Config{ID: "email", Matcher: EmailPattern, Replace: EmailReplace})
+	require.NoError(t, err)
+	redactions := []*Redact{secret, email}
+
+	tracked, tally := Track(redactions)
+	require.Len(t, tracked, 2)
+	assert.Nil(t, tally.Counts(), "nothing has been replaced yet")
+
+	result, err := String("a secret, another secret", tracked)
+	require.NoError(t, err)
+	assert.Equal(t, "a REDACTED, another REDACTED", result)
+
+	_, err = JSON(map[string]any{"user": "me@example.com", "nested": []any{"secret"}}, tracked)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, tracked)
+	_, err = w.Write([]byte("secret\nsecret"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	assert.Equal(t, "REDACTED\nREDACTED", buf.String())
+
+	assert.Equal(t, map[string]int{"secret": 5, "email": 1}, tally.Counts())
+
+	// The originals don't count anything.
+	_, err = String("secret", redactions)
+	require.NoError(t, err)
+	assert.Nil(t, secret.tally)
+	assert.Equal(t, 5, tally.Counts()["secret"])
+}
+
+func TestTally_Nil(t *testing.T) {
+	var tally *Tally
+	assert.Nil(t, tally.Counts())
+	tally.add("id", 1)
+}
+
+func TestMergeCounts(t *testing.T) {
+	assert.Nil(t, MergeCounts(nil, map[string]int{}))
+	assert.Equal(t, map[string]int{"a": 3, "b": 1}, MergeCounts(map[string]int{"a": 1}, nil, map[string]int{"a": 2, "b": 1}))
+}
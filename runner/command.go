@@ -18,7 +18,7 @@ import (
 	"github.com/hashicorp/hcdiag/util"
 )
 
-var _ Runner = Command{}
+var _ Redactor = Command{}
 
 // Command runs shell commands.
 type Command struct {
@@ -135,8 +135,30 @@ func (c Command) Run() op.Op {
 			results <- op.New(c.ID(), nil, op.Skip, err, Params(c), startTime, time.Now())
 		}
 
-		// Execute command
-		bts, err := exec.Command(p.cmd, p.args...).CombinedOutput()
+		// Execute command, collecting its combined output. Large outputs are streamed to a file in the bundle.
+		ext := ".txt"
+		if c.Format == "json" {
+			ext = ".json"
+		}
+		out := NewOutput(c.ctx, c.ID(), ext, c.Redactions)
+		cmd := exec.Command(p.cmd, p.args...)
+		cmd.Stdout, cmd.Stderr = out, out
+		err = cmd.Run()
+		if closeErr := out.Close(); closeErr != nil {
+			results <- op.New(c.ID(), nil, op.Fail, closeErr, Params(c), startTime, time.Now())
+			return
+		}
+		if out.InFile() {
+			// The output is too large to parse, so it's referred to by its file, whatever the format.
+			if err != nil {
+				err1 := CommandExecError{command: c.Command, format: c.Format, err: err}
+				results <- op.New(c.ID(), out.FileResult(), op.Unknown, err1, Params(c), startTime, time.Now())
+				return
+			}
+			results <- op.New(c.ID(), out.FileResult(), op.Success, nil, Params(c), startTime, time.Now())
+			return
+		}
+		bts := out.Bytes()
 		if err != nil {
 			err1 := CommandExecError{command: c.Command, format: c.Format, err: err}
 			result := map[string]any{"text": string(bts)}
@@ -207,6 +229,13 @@ func (c Command) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Command runner whose redactions count the replacements they make.
+func (c Command) TrackRedactions() (Runner, *redact.Tally) {
+	var tally *redact.Tally
+	c.Redactions, tally = redact.Track(c.Redactions)
+	return c, tally
+}
+
 type parsedCommand struct {
 	cmd  string
 	args []string
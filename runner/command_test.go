@@ -10,10 +10,12 @@ import (
 	"time"
 
 	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/redact"
 
 	"github.com/hashicorp/go-hclog"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewCommand(t *testing.T) {
@@ -112,6 +114,20 @@ func TestCommand_Run(t *testing.T) {
 	}
 }
 
+func TestCommand_RunJSONPathRedaction(t *testing.T) {
+	r, err := redact.NewPath(redact.Config{}, "**.token")
+	require.NoError(t, err)
+	c, err := NewCommand(CommandConfig{
+		Command:    `echo '{"auth":{"token":42,"ttl":"1h"}}'`,
+		Format:     "json",
+		Redactions: []*redact.Redact{r},
+	})
+	require.NoError(t, err)
+	o := c.Run()
+	require.NoError(t, o.Error)
+	assert.Equal(t, map[string]any{"json": map[string]any{"auth": map[string]any{"token": redact.DefaultReplace, "ttl": "1h"}}}, o.Result)
+}
+
 func TestCommand_RunError(t *testing.T) {
 	tt := []struct {
 		desc    string
@@ -22,7 +22,7 @@ import (
 	"github.com/hashicorp/go-hclog"
 )
 
-var _ Runner = Copy{}
+var _ Redactor = Copy{}
 
 type CopyConfig struct {
 	// Path is the file path to the directory or file to copy to the DestDir.
@@ -119,6 +119,13 @@ func (c Copy) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Copy runner whose redactions count the replacements they make.
+func (c Copy) TrackRedactions() (Runner, *redact.Tally) {
+	var tally *redact.Tally
+	c.Redactions, tally = redact.Track(c.Redactions)
+	return c, tally
+}
+
 func (c Copy) run() op.Op {
 	// Ensure destination directory exists
 	err := os.MkdirAll(c.DestDir, 0755)
@@ -4,6 +4,7 @@
 package debug
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -14,7 +15,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = ConsulDebug{}
+var _ runner.Redactor = ConsulDebug{}
 
 // ConsulDebugConfig is a config struct for ConsulDebug runners
 type ConsulDebugConfig struct {
@@ -34,14 +35,29 @@ type ConsulDebug struct {
 	Redactions []*redact.Redact `json:"redactions"`
 
 	output string
+	ctx    context.Context
 }
 
 func (ConsulDebug) ID() string {
 	return "ConsulDebug"
 }
 
+// NewConsulDebug returns a ConsulDebug runner configured from cfg, falling back to the product-wide debugDuration and
+// debugInterval when cfg does not set them.
 func NewConsulDebug(cfg ConsulDebugConfig, tmpDir string, debugDuration time.Duration, debugInterval time.Duration) (*ConsulDebug, error) {
+	return NewConsulDebugWithContext(context.Background(), cfg, tmpDir, debugDuration, debugInterval)
+}
+
+// NewConsulDebugWithContext is similar to NewConsulDebug, but it accepts a context.Context that is passed to the
+// underlying debug command, so that the command can be canceled or timed out along with the rest of a run.
+func NewConsulDebugWithContext(ctx context.Context, cfg ConsulDebugConfig, tmpDir string, debugDuration time.Duration, debugInterval time.Duration) (*ConsulDebug, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	dbg := ConsulDebug{
+		ctx: ctx,
+
 		// No compression because the hcdiag bundle will get compressed anyway
 		Archive: "true",
 		// Use debug duration and interval
@@ -81,13 +97,20 @@ func (dbg ConsulDebug) Run() op.Op {
 	cmdStr := consulCmdString(dbg, filterString, dir)
 
 	// Create and set the Command
-	cmd := runner.Command{
+	cmd, err := runner.NewCommandWithContext(dbg.ctx, runner.CommandConfig{
 		Command:    cmdStr,
 		Format:     "string",
 		Redactions: dbg.Redactions,
+	})
+	if err != nil {
+		return op.New(dbg.ID(), nil, op.Fail, err, runner.Params(dbg), startTime, time.Now())
 	}
 
 	o := cmd.Run()
+	// Cancellations and timeouts are passed through as-is, so they are not reported as failures of the debug command.
+	if o.Status == op.Canceled || o.Status == op.Timeout {
+		return op.New(dbg.ID(), o.Result, o.Status, o.Error, runner.Params(dbg), startTime, time.Now())
+	}
 	if o.Error != nil {
 		return op.New(dbg.ID(), o.Result, op.Fail, o.Error, runner.Params(dbg), startTime, time.Now())
 	}
@@ -95,6 +118,13 @@ func (dbg ConsulDebug) Run() op.Op {
 	return op.New(dbg.ID(), o.Result, op.Success, nil, runner.Params(dbg), startTime, time.Now())
 }
 
+// TrackRedactions returns a copy of the ConsulDebug runner whose redactions count the replacements they make.
+func (dbg ConsulDebug) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	dbg.Redactions, tally = redact.Track(dbg.Redactions)
+	return dbg, tally
+}
+
 // consulCmdString takes a ConsulDebug and a filterString, and creates a valid Consul debug command string
 func consulCmdString(dbg ConsulDebug, filterString, tmpDir string) string {
 	dbg.output = path.Join(tmpDir, "ConsulDebug")
@@ -4,6 +4,7 @@
 package debug
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -14,7 +15,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = NomadDebug{}
+var _ runner.Redactor = NomadDebug{}
 
 // NomadDebugConfig is a config struct for NomadDebug runners
 type NomadDebugConfig struct {
@@ -51,14 +52,29 @@ type NomadDebug struct {
 
 	Redactions []*redact.Redact `json:"redactions"`
 	output     string
+	ctx        context.Context
 }
 
 func (NomadDebug) ID() string {
 	return "NomadDebug"
 }
 
+// NewNomadDebug returns a NomadDebug runner configured from cfg, falling back to the product-wide debugDuration and
+// debugInterval when cfg does not set them.
 func NewNomadDebug(cfg NomadDebugConfig, tmpDir string, debugDuration time.Duration, debugInterval time.Duration) (*NomadDebug, error) {
+	return NewNomadDebugWithContext(context.Background(), cfg, tmpDir, debugDuration, debugInterval)
+}
+
+// NewNomadDebugWithContext is similar to NewNomadDebug, but it accepts a context.Context that is passed to the
+// underlying debug command, so that the command can be canceled or timed out along with the rest of a run.
+func NewNomadDebugWithContext(ctx context.Context, cfg NomadDebugConfig, tmpDir string, debugDuration time.Duration, debugInterval time.Duration) (*NomadDebug, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
 	dbg := NomadDebug{
+		ctx: ctx,
+
 		// Use debug duration and interval
 		Duration:      debugDuration.String(),
 		Interval:      debugInterval.String(),
@@ -127,13 +143,20 @@ func (dbg NomadDebug) Run() op.Op {
 	cmdStr := nomadCmdString(dbg, filterString, dir)
 
 	// Create and set the Command
-	cmd := runner.Command{
+	cmd, err := runner.NewCommandWithContext(dbg.ctx, runner.CommandConfig{
 		Command:    cmdStr,
 		Format:     "string",
 		Redactions: dbg.Redactions,
+	})
+	if err != nil {
+		return op.New(dbg.ID(), nil, op.Fail, err, runner.Params(dbg), startTime, time.Now())
 	}
 
 	o := cmd.Run()
+	// Cancellations and timeouts are passed through as-is, so they are not reported as failures of the debug command.
+	if o.Status == op.Canceled || o.Status == op.Timeout {
+		return op.New(dbg.ID(), o.Result, o.Status, o.Error, runner.Params(dbg), startTime, time.Now())
+	}
 	if o.Error != nil {
 		return op.New(dbg.ID(), o.Result, op.Fail, o.Error, runner.Params(dbg), startTime, time.Now())
 	}
@@ -141,6 +164,13 @@ func (dbg NomadDebug) Run() op.Op {
 	return op.New(dbg.ID(), o.Result, op.Success, nil, runner.Params(dbg), startTime, time.Now())
 }
 
+// TrackRedactions returns a copy of the NomadDebug runner whose redactions count the replacements they make.
+func (dbg NomadDebug) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	dbg.Redactions, tally = redact.Track(dbg.Redactions)
+	return dbg, tally
+}
+
 // nomadCmdString takes a NomadDebug and a filterString, and creates a valid nomad debug command string
 func nomadCmdString(dbg NomadDebug, filterString, tmpDir string) string {
 	dbg.output = path.Join(tmpDir, "NomadDebug")
@@ -4,6 +4,7 @@
 package debug
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -14,7 +15,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = VaultDebug{}
+var _ runner.Redactor = VaultDebug{}
 
 // VaultDebugConfig is a config struct for VaultDebug runners
 type VaultDebugConfig struct {
@@ -38,15 +39,29 @@ type VaultDebug struct {
 	Redactions      []*redact.Redact `json:"redactions"`
 
 	output string
+	ctx    context.Context
 }
 
 func (VaultDebug) ID() string {
 	return "VaultDebug"
 }
 
+// NewVaultDebug returns a VaultDebug runner configured from cfg, falling back to the product-wide debugDuration and
+// debugInterval when cfg does not set them.
 func NewVaultDebug(cfg VaultDebugConfig, tmpDir string, debugDuration time.Duration, debugInterval time.Duration) (*VaultDebug, error) {
+	return NewVaultDebugWithContext(context.Background(), cfg, tmpDir, debugDuration, debugInterval)
+}
+
+// NewVaultDebugWithContext is similar to NewVaultDebug, but it accepts a context.Context that is passed to the
+// underlying debug command, so that the command can be canceled or timed out along with the rest of a run.
+func NewVaultDebugWithContext(ctx context.Context, cfg VaultDebugConfig, tmpDir string, debugDuration time.Duration, debugInterval time.Duration) (*VaultDebug, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 
 	dbg := VaultDebug{
+		ctx: ctx,
+
 		// No compression because the hcdiag bundle will get compressed anyway
 		Compress: "true",
 		// Use debug duration and interval
@@ -92,13 +107,20 @@ func (dbg VaultDebug) Run() op.Op {
 	cmdStr := vaultCmdString(dbg, filterString, dir)
 
 	// Create and set the Command
-	cmd := runner.Command{
+	cmd, err := runner.NewCommandWithContext(dbg.ctx, runner.CommandConfig{
 		Command:    cmdStr,
 		Format:     "string",
 		Redactions: dbg.Redactions,
+	})
+	if err != nil {
+		return op.New(dbg.ID(), nil, op.Fail, err, runner.Params(dbg), startTime, time.Now())
 	}
 
 	o := cmd.Run()
+	// Cancellations and timeouts are passed through as-is, so they are not reported as failures of the debug command.
+	if o.Status == op.Canceled || o.Status == op.Timeout {
+		return op.New(dbg.ID(), o.Result, o.Status, o.Error, runner.Params(dbg), startTime, time.Now())
+	}
 	if o.Error != nil {
 		return op.New(dbg.ID(), o.Result, op.Fail, o.Error, runner.Params(dbg), startTime, time.Now())
 	}
@@ -106,6 +128,13 @@ func (dbg VaultDebug) Run() op.Op {
 	return op.New(dbg.ID(), o.Result, op.Success, nil, runner.Params(dbg), startTime, time.Now())
 }
 
+// TrackRedactions returns a copy of the VaultDebug runner whose redactions count the replacements they make.
+func (dbg VaultDebug) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	dbg.Redactions, tally = redact.Track(dbg.Redactions)
+	return dbg, tally
+}
+
 // vaultCmdString takes a VaultDebug, a filterString, and a tmpDir string that's safe to write files into, and creates a valid Vault debug command string
 func vaultCmdString(dbg VaultDebug, filterString, tmpDir string) string {
 	var fileEnding string
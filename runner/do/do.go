@@ -4,6 +4,7 @@
 package do
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -14,7 +15,7 @@ import (
 	"github.com/hashicorp/hcdiag/op"
 )
 
-var _ runner.Runner = Do{}
+var _ runner.Parent = Do{}
 
 // Do is a runner that wraps a collection of runners and executes each of them in a goroutine. It returns a map associating
 // each runner's Op to its ID.
@@ -23,11 +24,22 @@ type Do struct {
 	Label       string          `json:"label"`
 	Description string          `json:"description"`
 	log         hclog.Logger
+	ctx         context.Context
 }
 
 // New initializes a Do runner.
 func New(l hclog.Logger, label, description string, runners []runner.Runner) *Do {
+	return NewWithContext(context.Background(), l, label, description, runners)
+}
+
+// NewWithContext initializes a Do runner whose Runners stop waiting for a slot, and whose results are recorded as
+// canceled or timed out, once ctx is done.
+func NewWithContext(ctx context.Context, l hclog.Logger, label, description string, runners []runner.Runner) *Do {
+	if ctx == nil {
+		ctx = context.Background()
+	}
 	return &Do{
+		ctx:         ctx,
 		Label:       label,
 		Description: description,
 		Runners:     runners,
@@ -39,9 +51,21 @@ func (d Do) ID() string {
 	return "do " + d.Label
 }
 
-// Run asynchronously executes all Runners and returns the resulting ops when the last one has finished
+// Run asynchronously executes all Runners and returns the resulting ops when the last one has finished. If the Do's
+// context is canceled or times out first, runners that had not finished yet are included in the results as canceled
+// or timed out, respectively, and so is the Do itself.
 func (d Do) Run() op.Op {
+	return d.RunWith(nil)
+}
+
+// RunWith is similar to Run, but each of the Runners waits for a slot from s before it executes.
+func (d Do) RunWith(s *runner.Scheduler) op.Op {
 	startTime := time.Now()
+
+	if d.ctx == nil {
+		d.ctx = context.Background()
+	}
+
 	var wg sync.WaitGroup
 	m := sync.Map{}
 
@@ -49,12 +73,34 @@ func (d Do) Run() op.Op {
 	for _, r := range d.Runners {
 		d.log.Info("running operation", "runner", r.ID())
 		go func(results *sync.Map, r runner.Runner) {
-			results.Store(r.ID(), r.Run())
+			results.Store(r.ID(), s.RunWithContext(d.ctx, r))
 			wg.Done()
 		}(&m, r)
 	}
-	wg.Wait()
 
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-d.ctx.Done():
+	case <-done:
+	}
+
+	if err := d.ctx.Err(); err != nil {
+		// Fill in every runner that has not finished, so that nothing goes missing from the results.
+		res := snapshot(&m)
+		for _, r := range d.Runners {
+			if _, ok := res[r.ID()]; !ok {
+				res[r.ID()] = runner.ContextOp(r, err, time.Now())
+			}
+		}
+		o := runner.ContextOp(d, err, startTime)
+		o.Result = res
+		return o
+	}
 	return op.New(d.ID(), snapshot(&m), op.Success, nil, runner.Params(d), startTime, time.Now())
 }
 
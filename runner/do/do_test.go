@@ -0,0 +1,104 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package do
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/runner"
+)
+
+func TestDo_RunWith(t *testing.T) {
+	t.Run("nested parents do not deadlock with a limit of one", func(t *testing.T) {
+		l := hclog.NewNullLogger()
+		inner := New(l, "inner", "", []runner.Runner{
+			mockRunner{id: "a", status: op.Success, delay: time.Millisecond},
+			mockRunner{id: "b", status: op.Success, delay: time.Millisecond},
+		})
+		seq := NewSeq(SeqConfig{
+			Label:   "seq",
+			Logger:  l,
+			Runners: []runner.Runner{inner, mockRunner{id: "c", status: op.Success}},
+		})
+		outer := New(l, "outer", "", []runner.Runner{seq, mockRunner{id: "d", status: op.Success}})
+
+		done := make(chan op.Op)
+		go func() {
+			done <- runner.NewScheduler(context.Background(), 1).Run(outer)
+		}()
+
+		select {
+		case o := <-done:
+			assert.Equal(t, op.Success, o.Status)
+			require.Contains(t, o.Result, "seq seq")
+			seqOp := o.Result["seq seq"].(op.Op)
+			assert.Equal(t, op.Success, seqOp.Status)
+			assert.Contains(t, seqOp.Result, "do inner")
+		case <-time.After(5 * time.Second):
+			t.Fatal("nested runners deadlocked")
+		}
+	})
+
+	t.Run("nested parents reflect the deadline", func(t *testing.T) {
+		l := hclog.NewNullLogger()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		inner := NewWithContext(ctx, l, "inner", "", []runner.Runner{
+			mockRunner{id: "a", status: op.Success, delay: time.Minute, ctx: ctx},
+			// A runner that doesn't watch the context isn't waited for, either.
+			mockRunner{id: "b", status: op.Success, delay: time.Minute},
+		})
+		outer := NewWithContext(ctx, l, "outer", "", []runner.Runner{inner})
+
+		done := make(chan op.Op)
+		go func() {
+			done <- runner.NewScheduler(ctx, 1).Run(outer)
+		}()
+
+		select {
+		case o := <-done:
+			assert.Equal(t, op.Timeout, o.Status)
+			assert.ErrorIs(t, o.Error, context.DeadlineExceeded)
+			require.Contains(t, o.Result, "do inner")
+			assert.Equal(t, op.Timeout, o.Result["do inner"].(op.Op).Status)
+		case <-time.After(5 * time.Second):
+			t.Fatal("nested runners did not stop at the deadline")
+		}
+	})
+
+	t.Run("unfinished runners are recorded as timed out", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		d := NewWithContext(ctx, hclog.NewNullLogger(), "timeout", "", []runner.Runner{
+			mockRunner{id: "a", status: op.Success},
+			mockRunner{id: "b", status: op.Success, delay: time.Minute, ctx: ctx},
+			mockRunner{id: "c", status: op.Success, delay: time.Minute},
+		})
+		o := d.Run()
+		assert.Equal(t, op.Timeout, o.Status)
+		require.Len(t, o.Result, 3)
+		assert.Equal(t, op.Success, o.Result["a"].(op.Op).Status)
+		assert.Equal(t, op.Timeout, o.Result["b"].(op.Op).Status)
+		assert.Equal(t, op.Timeout, o.Result["c"].(op.Op).Status)
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		d := NewWithContext(ctx, hclog.NewNullLogger(), "canceled", "", []runner.Runner{
+			mockRunner{id: "a", status: op.Success, delay: time.Minute, ctx: ctx},
+		})
+		o := d.Run()
+		assert.Equal(t, op.Canceled, o.Status)
+		require.Contains(t, o.Result, "a")
+		assert.Equal(t, op.Canceled, o.Result["a"].(op.Op).Status)
+	})
+}
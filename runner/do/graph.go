@@ -0,0 +1,297 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package do
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/runner"
+)
+
+var _ runner.Parent = Graph{}
+
+// GraphNode is a runner in a Graph, along with the names of the nodes that must succeed before it runs.
+type GraphNode struct {
+	// Name identifies the node so that other nodes can depend on it. It may be empty if nothing depends on the node.
+	Name      string
+	DependsOn []string
+	Runner    runner.Runner
+}
+
+// GraphConfig is the configuration for a Graph runner.
+type GraphConfig struct {
+	Label       string
+	Description string
+	Nodes       []GraphNode
+	Logger      hclog.Logger
+}
+
+// Graph is a runner that executes a collection of runners according to their dependencies. Each runner starts as soon
+// as all of its prerequisites have succeeded, so independent runners execute concurrently. If a prerequisite does not
+// succeed, the runners that depend on it are skipped, or recorded as timed out or canceled if the prerequisite was.
+// Like Do, it returns a map associating each runner's Op to its ID, and its own status is the worst of theirs.
+type Graph struct {
+	Label       string          `json:"label"`
+	Description string          `json:"description"`
+	Runners     []runner.Runner `json:"runners"`
+
+	nodes []GraphNode
+	log   hclog.Logger
+	ctx   context.Context
+}
+
+// NewGraph initializes a Graph runner. It returns an error if a node depends on a name that no node has, if two nodes
+// share a name, or if the dependencies contain a cycle.
+func NewGraph(cfg GraphConfig) (*Graph, error) {
+	return NewGraphWithContext(context.Background(), cfg)
+}
+
+// NewGraphWithContext is similar to NewGraph, but once ctx is done, the Graph stops waiting for its runners, and those
+// that had not finished are recorded as canceled or timed out.
+func NewGraphWithContext(ctx context.Context, cfg GraphConfig) (*Graph, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if err := validateGraph(cfg.Nodes); err != nil {
+		return nil, err
+	}
+	runners := make([]runner.Runner, len(cfg.Nodes))
+	for i, n := range cfg.Nodes {
+		runners[i] = n.Runner
+	}
+	return &Graph{
+		Label:       cfg.Label,
+		Description: cfg.Description,
+		Runners:     runners,
+		nodes:       cfg.Nodes,
+		log:         cfg.Logger,
+		ctx:         ctx,
+	}, nil
+}
+
+func (g Graph) ID() string {
+	return "graph " + g.Label
+}
+
+// Run executes the Graph's runners, each one once its prerequisites have succeeded, and returns the resulting ops when
+// the last one has finished. If the Graph's context is canceled or times out first, runners that had not finished yet
+// are included in the results as canceled or timed out, respectively.
+func (g Graph) Run() op.Op {
+	return g.RunWith(nil)
+}
+
+// RunWith is similar to Run, but each of the Runners waits for a slot from s before it executes.
+func (g Graph) RunWith(s *runner.Scheduler) op.Op {
+	startTime := time.Now()
+
+	if g.ctx == nil {
+		g.ctx = context.Background()
+	}
+
+	// Each node closes its channel once its op is available in statuses, which lets its dependents proceed.
+	done := make(map[string]chan struct{}, len(g.nodes))
+	for _, n := range g.nodes {
+		if n.Name != "" {
+			done[n.Name] = make(chan struct{})
+		}
+	}
+	var statuses sync.Map
+
+	var wg sync.WaitGroup
+	m := sync.Map{}
+	wg.Add(len(g.nodes))
+	for _, n := range g.nodes {
+		go func(n GraphNode) {
+			defer wg.Done()
+			o := g.runNode(s, n, done, &statuses)
+			m.Store(n.Runner.ID(), o)
+			if n.Name != "" {
+				statuses.Store(n.Name, o.Status)
+				close(done[n.Name])
+			}
+		}(n)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+	select {
+	case <-g.ctx.Done():
+	case <-finished:
+	}
+
+	res := snapshot(&m)
+	if err := g.ctx.Err(); err != nil {
+		// Fill in every runner that has not finished, so that nothing in the graph goes missing from the results.
+		for _, n := range g.nodes {
+			if _, ok := res[n.Runner.ID()]; !ok {
+				res[n.Runner.ID()] = runner.ContextOp(n.Runner, err, time.Now())
+			}
+		}
+		o := runner.ContextOp(g, err, startTime)
+		o.Result = res
+		return o
+	}
+
+	status, err := g.rollUp(res)
+	return op.New(g.ID(), res, status, err, runner.Params(g), startTime, time.Now())
+}
+
+// runNode waits for each of n's prerequisites, then runs n. If any of them did not succeed, n is skipped, unless the
+// prerequisite timed out or was canceled, in which case n is recorded the same way.
+func (g Graph) runNode(s *runner.Scheduler, n GraphNode, done map[string]chan struct{}, statuses *sync.Map) op.Op {
+	for _, dep := range n.DependsOn {
+		select {
+		case <-g.ctx.Done():
+			return runner.ContextOp(n.Runner, g.ctx.Err(), time.Now())
+		case <-done[dep]:
+		}
+		status, _ := statuses.Load(dep)
+		if status != op.Success {
+			err := PrerequisiteError{Runner: n.Runner.ID(), Prerequisite: dep, Status: status.(op.Status)}
+			now := time.Now()
+			switch status {
+			case op.Timeout:
+				return runner.TimeoutOp(n.Runner, err, now)
+			case op.Canceled:
+				return runner.CancelOp(n.Runner, err, now)
+			}
+			g.log.Info("skipping operation", "runner", n.Runner.ID(), "reason", err)
+			return op.New(n.Runner.ID(), map[string]any{}, op.Skip, err, runner.Params(n.Runner), now, now)
+		}
+	}
+	g.log.Info("running operation", "runner", n.Runner.ID())
+	return s.RunWithContext(g.ctx, n.Runner)
+}
+
+// statusRank orders statuses from best to worst, for rolling them up into a Graph's status. Skipped runners don't
+// make a Graph any worse than successful ones do.
+var statusRank = map[op.Status]int{
+	op.Success:  0,
+	op.Skip:     0,
+	op.Unknown:  1,
+	op.Fail:     2,
+	op.Timeout:  3,
+	op.Canceled: 4,
+}
+
+// rollUp returns the worst status among the ops in res, along with an error for the runner that has it, if it's worse
+// than success.
+func (g Graph) rollUp(res map[string]any) (op.Status, error) {
+	status, worst := op.Success, op.Op{}
+	for _, n := range g.nodes {
+		o, ok := res[n.Runner.ID()].(op.Op)
+		if ok && statusRank[status] < statusRank[o.Status] {
+			status, worst = o.Status, o
+		}
+	}
+	if statusRank[status] == 0 {
+		return op.Success, nil
+	}
+	err := worst.Error
+	if err == nil {
+		err = fmt.Errorf("finished with status %q", worst.Status)
+	}
+	return status, ChildRunnerError{Parent: g.ID(), Child: worst.Identifier, err: err}
+}
+
+// PrerequisiteError explains why a runner in a Graph did not run.
+type PrerequisiteError struct {
+	Runner       string
+	Prerequisite string
+	Status       op.Status
+}
+
+func (e PrerequisiteError) Error() string {
+	return fmt.Sprintf("did not run runner %q because its prerequisite %q finished with status %q", e.Runner, e.Prerequisite, e.Status)
+}
+
+// validateGraph returns an error for duplicate names, unknown dependencies, or dependency cycles.
+func validateGraph(nodes []GraphNode) error {
+	deps := make(map[string][]string, len(nodes))
+	for _, n := range nodes {
+		if n.Name == "" {
+			continue
+		}
+		if _, ok := deps[n.Name]; ok {
+			return fmt.Errorf("duplicate graph node name %q", n.Name)
+		}
+		deps[n.Name] = n.DependsOn
+	}
+	for _, n := range nodes {
+		for _, dep := range n.DependsOn {
+			if _, ok := deps[dep]; !ok {
+				return fmt.Errorf("runner %q depends on unknown runner %q", n.Runner.ID(), dep)
+			}
+		}
+	}
+	if cycle := FindCycle(deps); cycle != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(cycle, " -> "))
+	}
+	return nil
+}
+
+// FindCycle returns the names along a dependency cycle, starting and ending with the same name, or nil if deps has
+// no cycles. deps maps each name to the names it depends on; dependencies without an entry of their own are ignored.
+func FindCycle(deps map[string][]string) []string {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(deps))
+	var path []string
+
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		state[name] = visiting
+		path = append(path, name)
+		for _, dep := range deps[name] {
+			if _, ok := deps[dep]; !ok {
+				continue
+			}
+			switch state[dep] {
+			case visiting:
+				// Trim the path down to where the cycle starts.
+				for i, p := range path {
+					if p == dep {
+						return append(append([]string{}, path[i:]...), dep)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dep); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		return nil
+	}
+
+	// Visit names in a stable order, so that the same config always reports the same cycle.
+	names := make([]string, 0, len(deps))
+	for name := range deps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if state[name] == unvisited {
+			if cycle := visit(name); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
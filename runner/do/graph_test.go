@@ -0,0 +1,137 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package do
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/op"
+)
+
+func TestGraph_Run(t *testing.T) {
+	g, err := NewGraph(GraphConfig{
+		Label:  "test",
+		Logger: hclog.NewNullLogger(),
+		Nodes: []GraphNode{
+			{Name: "ok", Runner: mockRunner{id: "ok", status: op.Success}},
+			{Name: "broken", Runner: mockRunner{id: "broken", status: op.Fail}},
+			{Name: "after-ok", DependsOn: []string{"ok"}, Runner: mockRunner{id: "after-ok", status: op.Success}},
+			{Name: "after-broken", DependsOn: []string{"broken"}, Runner: mockRunner{id: "after-broken", status: op.Success}},
+			{DependsOn: []string{"after-broken"}, Runner: mockRunner{id: "transitive", status: op.Success}},
+		},
+	})
+	require.NoError(t, err)
+
+	o := g.Run()
+	assert.Equal(t, op.Fail, o.Status)
+	require.Len(t, o.Result, 5)
+
+	var childErr ChildRunnerError
+	require.True(t, errors.As(o.Error, &childErr))
+	assert.Equal(t, "broken", childErr.Child)
+
+	statuses := map[string]op.Status{
+		"ok":           op.Success,
+		"broken":       op.Fail,
+		"after-ok":     op.Success,
+		"after-broken": op.Skip,
+		"transitive":   op.Skip,
+	}
+	for id, status := range statuses {
+		assert.Equal(t, status, o.Result[id].(op.Op).Status, id)
+	}
+
+	var prereqErr PrerequisiteError
+	require.True(t, errors.As(o.Result["after-broken"].(op.Op).Error, &prereqErr))
+	assert.Equal(t, "broken", prereqErr.Prerequisite)
+	assert.Equal(t, op.Fail, prereqErr.Status)
+}
+
+func TestGraph_RunWithContext(t *testing.T) {
+	newGraph := func(ctx context.Context) *Graph {
+		g, err := NewGraphWithContext(ctx, GraphConfig{
+			Label:  "test",
+			Logger: hclog.NewNullLogger(),
+			Nodes: []GraphNode{
+				{Name: "a", Runner: mockRunner{id: "a", status: op.Success, delay: time.Minute, ctx: ctx}},
+				{Name: "b", DependsOn: []string{"a"}, Runner: mockRunner{id: "b", status: op.Success}},
+				{DependsOn: []string{"b"}, Runner: mockRunner{id: "c", status: op.Success}},
+			},
+		})
+		require.NoError(t, err)
+		return g
+	}
+
+	t.Run("records unfinished runners as timed out", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		o := newGraph(ctx).Run()
+		assert.Equal(t, op.Timeout, o.Status)
+		require.Len(t, o.Result, 3)
+		for _, id := range []string{"a", "b", "c"} {
+			assert.Equal(t, op.Timeout, o.Result[id].(op.Op).Status, id)
+		}
+	})
+
+	t.Run("records unfinished runners as canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		o := newGraph(ctx).Run()
+		assert.Equal(t, op.Canceled, o.Status)
+		require.Len(t, o.Result, 3)
+		for _, id := range []string{"a", "b", "c"} {
+			assert.Equal(t, op.Canceled, o.Result[id].(op.Op).Status, id)
+		}
+	})
+}
+
+func TestNewGraph(t *testing.T) {
+	testCases := []struct {
+		name  string
+		nodes []GraphNode
+	}{
+		{
+			name: "unknown dependency",
+			nodes: []GraphNode{
+				{Name: "a", DependsOn: []string{"b"}, Runner: mockRunner{id: "a"}},
+			},
+		},
+		{
+			name: "duplicate name",
+			nodes: []GraphNode{
+				{Name: "a", Runner: mockRunner{id: "a"}},
+				{Name: "a", Runner: mockRunner{id: "b"}},
+			},
+		},
+		{
+			name: "cycle",
+			nodes: []GraphNode{
+				{Name: "a", DependsOn: []string{"b"}, Runner: mockRunner{id: "a"}},
+				{Name: "b", DependsOn: []string{"a"}, Runner: mockRunner{id: "b"}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewGraph(GraphConfig{Label: "test", Logger: hclog.NewNullLogger(), Nodes: tc.nodes})
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFindCycle(t *testing.T) {
+	assert.Nil(t, FindCycle(map[string][]string{"a": {"b"}, "b": nil}))
+	assert.Equal(t, []string{"a", "a"}, FindCycle(map[string][]string{"a": {"a"}}))
+	assert.Equal(t, []string{"a", "b", "c", "a"}, FindCycle(map[string][]string{"a": {"b"}, "b": {"c"}, "c": {"a"}}))
+}
@@ -6,6 +6,7 @@ package do
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/hashicorp/hcdiag/runner"
@@ -14,7 +15,7 @@ import (
 	"github.com/hashicorp/hcdiag/op"
 )
 
-var _ runner.Runner = Seq{}
+var _ runner.Parent = Seq{}
 
 type SeqConfig struct {
 	Runners     []runner.Runner
@@ -59,15 +60,21 @@ func (s Seq) ID() string {
 	return "seq " + s.Label
 }
 
-// Run executes the Command
+// Run executes the Seq's runners in order. If the Seq's context is canceled or times out first, runners that had not
+// finished yet are included in the results as canceled or timed out, respectively.
 func (s Seq) Run() op.Op {
+	return s.RunWith(nil)
+}
+
+// RunWith is similar to Run, but each of the Runners waits for a slot from sched before it executes.
+func (s Seq) RunWith(sched *runner.Scheduler) op.Op {
 	startTime := time.Now()
 
 	if s.ctx == nil {
 		s.ctx = context.Background()
 	}
 
-	resChan := make(chan op.Op)
+	resChan := make(chan op.Op, 1)
 
 	runCtx := s.ctx
 	var cancel context.CancelFunc
@@ -76,37 +83,46 @@ func (s Seq) Run() op.Op {
 		defer cancel()
 	}
 
+	var results sync.Map
 	go func(resChan chan<- op.Op, start time.Time) {
-		o := s.run()
+		o := s.run(runCtx, sched, &results)
 		o.Start = start
 		resChan <- o
 	}(resChan, startTime)
 
 	select {
 	case <-runCtx.Done():
-		switch runCtx.Err() {
-		case context.Canceled:
-			return op.NewCancel(s.ID(), runCtx.Err(), runner.Params(s), startTime)
-		case context.DeadlineExceeded:
-			return op.NewTimeout(s.ID(), runCtx.Err(), runner.Params(s), startTime)
-		default:
-			return op.New(s.ID(), nil, op.Unknown, runCtx.Err(), runner.Params(s), startTime, time.Now())
+		// Fill in every runner that has not finished, so that nothing in the sequence goes missing from the results.
+		res := snapshot(&results)
+		for _, r := range s.Runners {
+			if _, ok := res[r.ID()]; !ok {
+				res[r.ID()] = runner.ContextOp(r, runCtx.Err(), time.Now())
+			}
 		}
+		o := runner.ContextOp(s, runCtx.Err(), startTime)
+		o.Result = res
+		return o
 	case result := <-resChan:
 		return result
 	}
 }
 
-func (s Seq) run() op.Op {
-	results := make(map[string]any, 0)
-	for _, r := range s.Runners {
+func (s Seq) run(ctx context.Context, sched *runner.Scheduler, results *sync.Map) op.Op {
+	for i, r := range s.Runners {
+		// Don't start anything new once we've been canceled or timed out, and record the rest as unfinished.
+		if ctx.Err() != nil {
+			return s.interrupted(ctx.Err(), s.Runners[i:], results)
+		}
 		s.log.Info("running operation", "runner", r.ID())
-		o := r.Run()
+		o := sched.RunWithContext(ctx, r)
+		results.Store(o.Identifier, o)
+		// A child that was cut short by our own context takes the rest of the sequence down with it.
+		if ctx.Err() != nil && (o.Status == op.Canceled || o.Status == op.Timeout) {
+			return s.interrupted(ctx.Err(), s.Runners[i+1:], results)
+		}
 		// If any result op is not Success, abort and return all existing ops
 		if o.Status != op.Success {
-			// Add an op for this failed Seq at the end of the slice
-			results[o.Identifier] = o
-			return op.New(s.ID(), results, op.Fail, ChildRunnerError{
+			return op.New(s.ID(), snapshot(results), op.Fail, ChildRunnerError{
 				Parent: s.ID(),
 				Child:  o.Identifier,
 				err:    o.Error,
@@ -114,7 +130,19 @@ func (s Seq) run() op.Op {
 		}
 	}
 	// Return runner ops, adding one at the end for our successful Seq run
-	return op.New(s.ID(), results, op.Success, nil, runner.Params(s), time.Time{}, time.Now())
+	return op.New(s.ID(), snapshot(results), op.Success, nil, runner.Params(s), time.Time{}, time.Now())
+}
+
+// interrupted records each of the remaining runners as canceled or timed out, according to err, and returns an op for
+// the Seq with the same status.
+func (s Seq) interrupted(err error, remaining []runner.Runner, results *sync.Map) op.Op {
+	now := time.Now()
+	for _, r := range remaining {
+		results.Store(r.ID(), runner.ContextOp(r, err, now))
+	}
+	o := runner.ContextOp(s, err, time.Time{})
+	o.Result = snapshot(results)
+	return o
 }
 
 type ChildRunnerError struct {
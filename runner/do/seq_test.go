@@ -0,0 +1,113 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package do
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/runner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockRunner returns an op with its status after waiting for delay, or a canceled/timed out op if its context is
+// done first. Any status other than success comes with an error.
+type mockRunner struct {
+	id     string
+	status op.Status
+	delay  time.Duration
+	ctx    context.Context
+}
+
+func (m mockRunner) ID() string {
+	return m.id
+}
+
+func (m mockRunner) Run() op.Op {
+	start := time.Now()
+	ctx := m.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-ctx.Done():
+		return runner.ContextOp(m, ctx.Err(), start)
+	case <-time.After(m.delay):
+		var err error
+		if m.status != op.Success {
+			err = errors.New("mock failure")
+		}
+		return op.New(m.ID(), map[string]any{}, m.status, err, nil, start, time.Now())
+	}
+}
+
+func TestSeq_Run(t *testing.T) {
+	t.Run("includes every child result on success", func(t *testing.T) {
+		s := NewSeq(SeqConfig{
+			Label:  "test",
+			Logger: hclog.NewNullLogger(),
+			Runners: []runner.Runner{
+				mockRunner{id: "one", status: op.Success},
+				mockRunner{id: "two", status: op.Success},
+			},
+		})
+		o := s.Run()
+		assert.Equal(t, op.Success, o.Status)
+		assert.Contains(t, o.Result, "one")
+		assert.Contains(t, o.Result, "two")
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		s := NewSeq(SeqConfig{
+			Label:  "test",
+			Logger: hclog.NewNullLogger(),
+			Runners: []runner.Runner{
+				mockRunner{id: "one", status: op.Fail},
+				mockRunner{id: "two", status: op.Success},
+			},
+		})
+		o := s.Run()
+		assert.Equal(t, op.Fail, o.Status)
+		assert.Contains(t, o.Result, "one")
+		assert.NotContains(t, o.Result, "two")
+	})
+
+	t.Run("records unfinished children as timed out", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		s := NewSeqWithContext(ctx, SeqConfig{
+			Label:  "test",
+			Logger: hclog.NewNullLogger(),
+			Runners: []runner.Runner{
+				mockRunner{id: "fast", status: op.Success},
+				mockRunner{id: "slow", status: op.Success, delay: time.Minute, ctx: ctx},
+				mockRunner{id: "never", status: op.Success},
+			},
+		})
+		o := s.Run()
+		assert.Equal(t, op.Timeout, o.Status)
+		require.Len(t, o.Result, 3)
+		assert.Equal(t, op.Success, o.Result["fast"].(op.Op).Status)
+		assert.Equal(t, op.Timeout, o.Result["slow"].(op.Op).Status)
+		assert.Equal(t, op.Timeout, o.Result["never"].(op.Op).Status)
+	})
+
+	t.Run("records children as canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		s := NewSeqWithContext(ctx, SeqConfig{
+			Label:   "test",
+			Logger:  hclog.NewNullLogger(),
+			Runners: []runner.Runner{mockRunner{id: "one", status: op.Success}},
+		})
+		o := s.Run()
+		assert.Equal(t, op.Canceled, o.Status)
+		assert.Equal(t, op.Canceled, o.Result["one"].(op.Op).Status)
+	})
+}
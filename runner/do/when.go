@@ -0,0 +1,50 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package do
+
+import (
+	"time"
+
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/runner"
+)
+
+var _ runner.Parent = When{}
+
+// When is a runner that wraps another runner, and only executes it if a condition holds. When the condition doesn't
+// hold, the wrapped runner is skipped, with the condition's error as the reason. When has the same ID as the runner it
+// wraps, so that results, excludes, and selects are unchanged by wrapping it.
+type When struct {
+	Runner    runner.Runner `json:"runner"`
+	condition func() error
+}
+
+// NewWhen initializes a When runner, which runs r only if condition returns nil. condition is evaluated each time the
+// When runs, rather than when it's initialized.
+func NewWhen(r runner.Runner, condition func() error) *When {
+	return &When{
+		Runner:    r,
+		condition: condition,
+	}
+}
+
+func (w When) ID() string {
+	return w.Runner.ID()
+}
+
+// Run executes the wrapped runner if the condition holds, or returns a skipped op for it otherwise.
+func (w When) Run() op.Op {
+	return w.RunWith(nil)
+}
+
+// RunWith is similar to Run, but the wrapped runner waits for a slot from s before it executes.
+func (w When) RunWith(s *runner.Scheduler) op.Op {
+	startTime := time.Now()
+	if w.condition != nil {
+		if err := w.condition(); err != nil {
+			return op.New(w.ID(), nil, op.Skip, err, runner.Params(w.Runner), startTime, time.Now())
+		}
+	}
+	return s.Run(w.Runner)
+}
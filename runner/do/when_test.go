@@ -0,0 +1,75 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package do
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/runner"
+)
+
+func TestWhen_Run(t *testing.T) {
+	testCases := []struct {
+		name           string
+		runner         runner.Runner
+		condition      func() error
+		expectedStatus op.Status
+		expectErr      bool
+	}{
+		{
+			name:           "runs the runner when the condition holds",
+			runner:         mockRunner{id: "a", status: op.Success},
+			condition:      func() error { return nil },
+			expectedStatus: op.Success,
+		},
+		{
+			name:           "runs the runner without a condition",
+			runner:         mockRunner{id: "a", status: op.Fail},
+			expectedStatus: op.Fail,
+		},
+		{
+			name:           "skips the runner when the condition fails",
+			runner:         mockRunner{id: "a", status: op.Fail},
+			condition:      func() error { return errors.New("not applicable") },
+			expectedStatus: op.Skip,
+			expectErr:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := NewWhen(tc.runner, tc.condition)
+			assert.Equal(t, tc.runner.ID(), w.ID())
+
+			o := w.Run()
+			assert.Equal(t, tc.runner.ID(), o.Identifier)
+			assert.Equal(t, tc.expectedStatus, o.Status)
+			if tc.expectErr {
+				assert.Error(t, o.Error)
+			}
+		})
+	}
+}
+
+func TestWhen_RunWith(t *testing.T) {
+	t.Run("hands a parent back to the scheduler", func(t *testing.T) {
+		l := hclog.NewNullLogger()
+		inner := New(l, "inner", "", []runner.Runner{
+			mockRunner{id: "a", status: op.Success},
+			mockRunner{id: "b", status: op.Success},
+		})
+		o := runner.NewScheduler(context.Background(), 1).Run(NewWhen(inner, func() error { return nil }))
+		assert.Equal(t, "do inner", o.Identifier)
+		assert.Equal(t, op.Success, o.Status)
+		require.Contains(t, o.Result, "a")
+		require.Contains(t, o.Result, "b")
+	})
+}
@@ -24,9 +24,11 @@ type Partition struct {
 	Mountpoint string   `json:"mountpoint"`
 	Fstype     string   `json:"fstype"`
 	Opts       []string `json:"opts"`
+	// UsedPercent is how full the partition is, from 0 to 100. It is omitted if usage could not be read.
+	UsedPercent float64 `json:"used_percent,omitempty"`
 }
 
-var _ runner.Runner = Disk{}
+var _ runner.Redactor = Disk{}
 
 type DiskConfig struct {
 	// Redactions includes any redactions to apply to the output of the runner.
@@ -97,6 +99,13 @@ func (d Disk) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Disk runner whose redactions count the replacements they make.
+func (d Disk) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	d.Redactions, tally = redact.Track(d.Redactions)
+	return d, tally
+}
+
 func (d Disk) run() op.Op {
 	var partitions []Partition
 	dp, err := disk.Partitions(true)
@@ -152,6 +161,14 @@ func (d Disk) partitions(dps []disk.PartitionStat) ([]Partition, error) {
 		}
 		partition.Opts = opts
 
+		// Usage is read from the real mountpoint, before redaction. Some partitions, such as pseudo filesystems or
+		// ones the current user can't access, have no usage, so errors here are not fatal.
+		if usage, err := disk.Usage(dp.Mountpoint); err == nil {
+			partition.UsedPercent = usage.UsedPercent
+		} else {
+			hclog.L().Trace("runner/host.Disk.partitions() unable to read usage", "mountpoint", dp.Mountpoint, "error", err)
+		}
+
 		partitions = append(partitions, partition)
 	}
 
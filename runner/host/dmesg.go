@@ -14,7 +14,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = DMesg{}
+var _ runner.Redactor = DMesg{}
 
 // DMesgConfig takes each parameter to configure an DMesg runner implementation.
 type DMesgConfig struct {
@@ -78,3 +78,10 @@ func (r DMesg) Run() op.Op {
 	o := r.Shell.Run()
 	return op.New(r.ID(), o.Result, o.Status, o.Error, runner.Params(r), startTime, time.Now())
 }
+
+// TrackRedactions returns a copy of the DMesg runner whose shell counts the replacements its redactions make.
+func (r DMesg) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	r.Shell, tally = runner.TrackRedactions(r.Shell)
+	return r, tally
+}
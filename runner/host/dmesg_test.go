@@ -4,12 +4,17 @@
 package host
 
 import (
+	"context"
 	"fmt"
+	"runtime"
 	"testing"
 
 	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/redact"
+	"github.com/hashicorp/hcdiag/runner"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDMesg_Run(t *testing.T) {
@@ -135,3 +140,23 @@ func TestNewDMesg(t *testing.T) {
 		})
 	}
 }
+
+func TestDMesg_TrackRedactions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	t.Setenv("SHELL", "/bin/sh")
+
+	r, err := redact.New(redact.Config{ID: "secret", Matcher: "secret", Replace: "REDACTED"})
+	require.NoError(t, err)
+	shell, err := runner.NewShell(runner.ShellConfig{Command: "echo secret secret", Redactions: []*redact.Redact{r}})
+	require.NoError(t, err)
+	dmesg := DMesg{OS: "linux", Shell: shell}
+
+	// The shell's replacements are reported as the DMesg runner's.
+	o := runner.NewScheduler(context.Background(), 1).Run(dmesg)
+	require.NoError(t, o.Error)
+	assert.Equal(t, "dmesg -T", o.Identifier)
+	assert.Equal(t, map[string]int{"secret": 2}, o.Redactions)
+	assert.Equal(t, []*redact.Redact{r}, shell.Redactions, "the shell is not changed")
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = EtcHosts{}
+var _ runner.Redactor = EtcHosts{}
 
 type EtcHostsConfig struct {
 	OS         string
@@ -87,6 +87,13 @@ func (r EtcHosts) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the EtcHosts runner whose redactions count the replacements they make.
+func (r EtcHosts) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	r.Redactions, tally = redact.Track(r.Redactions)
+	return r, tally
+}
+
 func (r EtcHosts) run() op.Op {
 	// Not compatible with windows
 	if r.OS == "windows" {
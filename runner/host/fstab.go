@@ -14,7 +14,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = FSTab{}
+var _ runner.Redactor = FSTab{}
 
 // FSTabConfig takes each parameter to configure an FSTab runner implementation.
 type FSTabConfig struct {
@@ -72,3 +72,10 @@ func (r FSTab) Run() op.Op {
 	o := r.Shell.Run()
 	return op.New(r.ID(), o.Result, o.Status, o.Error, runner.Params(r), startTime, time.Now())
 }
+
+// TrackRedactions returns a copy of the FSTab runner whose shell counts the replacements its redactions make.
+func (r FSTab) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	r.Shell, tally = runner.TrackRedactions(r.Shell)
+	return r, tally
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = Get{}
+var _ runner.Redactor = Get{}
 
 type GetConfig struct {
 	Path string
@@ -87,6 +87,13 @@ func (g Get) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Get runner whose redactions count the replacements they make.
+func (g Get) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	g.Redactions, tally = redact.Track(g.Redactions)
+	return g, tally
+}
+
 func (g Get) run() op.Op {
 	cmd := strings.Join([]string{"curl -s", g.Path}, " ")
 	// NOTE(mkcp): We will get JSON back from a lot of requests, so this can be improved
@@ -34,7 +34,7 @@ type InfoStat struct {
 	Procs    uint64 `json:"procs"`
 }
 
-var _ runner.Runner = Info{}
+var _ runner.Redactor = Info{}
 
 type InfoConfig struct {
 	// Redactions includes any redactions to apply to the output of the runner.
@@ -104,6 +104,13 @@ func (i Info) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Info runner whose redactions count the replacements they make.
+func (i Info) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	i.Redactions, tally = redact.Track(i.Redactions)
+	return i, tally
+}
+
 func (i Info) run() op.Op {
 	// third party
 	var hostInfo InfoStat
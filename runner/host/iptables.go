@@ -15,7 +15,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = IPTables{}
+var _ runner.Redactor = IPTables{}
 
 type IPTablesConfig struct {
 	OS         string
@@ -93,6 +93,13 @@ func (r IPTables) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the IPTables runner whose redactions count the replacements they make.
+func (r IPTables) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	r.Redactions, tally = redact.Track(r.Redactions)
+	return r, tally
+}
+
 func (r IPTables) run(ctx context.Context) op.Op {
 	if r.OS != "linux" {
 		return op.New(r.ID(), nil, op.Skip, fmt.Errorf("os not linux, skipping, os=%s", runtime.GOOS), runner.Params(r), time.Time{}, time.Now())
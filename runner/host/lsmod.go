@@ -14,7 +14,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = Lsmod{}
+var _ runner.Redactor = Lsmod{}
 
 // LsmodConfig takes each parameter to configure an lsmod runner implementation.
 type LsmodConfig struct {
@@ -71,3 +71,10 @@ func (r Lsmod) Run() op.Op {
 	o := r.Shell.Run()
 	return op.New(r.ID(), o.Result, o.Status, o.Error, runner.Params(r), startTime, time.Now())
 }
+
+// TrackRedactions returns a copy of the Lsmod runner whose shell counts the replacements its redactions make.
+func (r Lsmod) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	r.Shell, tally = runner.TrackRedactions(r.Shell)
+	return r, tally
+}
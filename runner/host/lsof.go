@@ -14,7 +14,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = Lsof{}
+var _ runner.Redactor = Lsof{}
 
 // LsofConfig takes each parameter to configure an lsof runner implementation.
 type LsofConfig struct {
@@ -71,3 +71,10 @@ func (r Lsof) Run() op.Op {
 	o := r.Shell.Run()
 	return op.New(r.ID(), o.Result, o.Status, o.Error, runner.Params(r), startTime, time.Now())
 }
+
+// TrackRedactions returns a copy of the Lsof runner whose shell counts the replacements its redactions make.
+func (r Lsof) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	r.Shell, tally = runner.TrackRedactions(r.Shell)
+	return r, tally
+}
@@ -15,7 +15,7 @@ import (
 	"github.com/shirou/gopsutil/v3/net"
 )
 
-var _ runner.Runner = &Network{}
+var _ runner.Redactor = &Network{}
 
 // NetworkInterface represents details about a network interface. This serves as the basis for the results produced
 // by the Network runner.
@@ -95,6 +95,13 @@ func (n Network) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Network runner whose redactions count the replacements they make.
+func (n Network) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	n.Redactions, tally = redact.Track(n.Redactions)
+	return n, tally
+}
+
 func (n Network) run() op.Op {
 	result := make(map[string]any)
 
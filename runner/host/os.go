@@ -12,7 +12,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = OS{}
+var _ runner.Redactor = OS{}
 
 type OSConfig struct {
 	// OS is the operating system family of the host.
@@ -78,3 +78,10 @@ func (o OS) Run() op.Op {
 	c := cmdRunner.Run()
 	return op.New(o.ID(), c.Result, c.Status, c.Error, runner.Params(o), startTime, time.Now())
 }
+
+// TrackRedactions returns a copy of the OS runner whose redactions count the replacements they make.
+func (o OS) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	o.Redactions, tally = redact.Track(o.Redactions)
+	return o, tally
+}
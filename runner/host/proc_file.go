@@ -14,7 +14,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = ProcFile{}
+var _ runner.Redactor = ProcFile{}
 
 type ProcFileConfig struct {
 	OS         string
@@ -91,6 +91,13 @@ func (p ProcFile) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the ProcFile runner whose redactions count the replacements they make.
+func (p ProcFile) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	p.Redactions, tally = redact.Track(p.Redactions)
+	return p, tally
+}
+
 func (p ProcFile) run(ctx context.Context) op.Op {
 	result := make(map[string]any)
 	if p.OS != "linux" {
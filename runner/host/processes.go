@@ -14,7 +14,7 @@ import (
 	"github.com/mitchellh/go-ps"
 )
 
-var _ runner.Runner = &Process{}
+var _ runner.Redactor = &Process{}
 
 type ProcessConfig struct {
 	// Redactions includes any redactions to apply to the output of the runner.
@@ -92,6 +92,13 @@ func (p Process) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Process runner whose redactions count the replacements they make.
+func (p Process) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	p.Redactions, tally = redact.Track(p.Redactions)
+	return p, tally
+}
+
 func (p Process) run() op.Op {
 	var procs []Proc
 
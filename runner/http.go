@@ -7,6 +7,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/hcdiag/client"
@@ -14,12 +16,13 @@ import (
 	"github.com/hashicorp/hcdiag/redact"
 )
 
-var _ Runner = HTTP{}
+var _ Redactor = HTTP{}
 
 // HTTP hits APIs.
 type HTTP struct {
 	// Parameters that are not shared/common
 	Path   string            `json:"path"`
+	Format string            `json:"format"`
 	Client *client.APIClient `json:"client"`
 
 	// Parameters that are common across runner types
@@ -27,6 +30,8 @@ type HTTP struct {
 
 	Timeout    Timeout          `json:"timeout"`
 	Redactions []*redact.Redact `json:"redactions"`
+
+	skipResponse func(err client.StatusError, body []byte) error
 }
 
 // HttpConfig is the configuration object passed into NewHTTP or NewHTTPWithContext. It includes
@@ -38,11 +43,20 @@ type HttpConfig struct {
 	// Path is the path portion of the URL that the runner will hit.
 	Path string
 
+	// Format is the format of the response. Valid options are "json" or "string", for endpoints that respond with
+	// plain text; the default is "json".
+	Format string
+
 	// Timeout specifies the amount of time that the runner should be allowed to execute before cancellation.
 	Timeout time.Duration
 
 	// Redactions includes any redactions to apply to the output of the runner.
 	Redactions []*redact.Redact
+
+	// SkipResponse, if set, is called with the error status and the unredacted body of a response that isn't
+	// successful. If it returns an error, the runner is skipped with that error, rather than recorded as unknown. This
+	// lets products skip endpoints that respond with an error when a feature isn't enabled.
+	SkipResponse func(err client.StatusError, body []byte) error
 }
 
 func NewHTTP(cfg HttpConfig) (*HTTP, error) {
@@ -57,6 +71,20 @@ func NewHTTPWithContext(ctx context.Context, cfg HttpConfig) (*HTTP, error) {
 		}
 	}
 
+	var format string
+	switch f := strings.ToLower(cfg.Format); f {
+	// default to "json" if not set
+	case "":
+		format = "json"
+	case "string", "json":
+		format = f
+	default:
+		return nil, HTTPConfigError{
+			config: cfg,
+			err:    fmt.Errorf("format must be either 'string' or 'json', but got '%s'", f),
+		}
+	}
+
 	timeout := cfg.Timeout
 	if timeout < 0 {
 		return nil, HTTPConfigError{
@@ -73,8 +101,11 @@ func NewHTTPWithContext(ctx context.Context, cfg HttpConfig) (*HTTP, error) {
 		ctx:        ctx,
 		Client:     cfg.Client,
 		Path:       cfg.Path,
+		Format:     format,
 		Timeout:    Timeout(cfg.Timeout),
 		Redactions: cfg.Redactions,
+
+		skipResponse: cfg.SkipResponse,
 	}, nil
 }
 
@@ -82,7 +113,8 @@ func (h HTTP) ID() string {
 	return "GET" + " " + h.Path
 }
 
-// Run executes a GET request to the Path using the Client
+// Run executes a GET request to the Path using the Client. If the API responds 403 Forbidden, the runner is skipped,
+// because the token it was given isn't allowed to read Path. It's also skipped if HttpConfig.SkipResponse says so.
 func (h HTTP) Run() op.Op {
 	// protect from accidental nil reference panics
 	if h.ctx == nil {
@@ -98,11 +130,50 @@ func (h HTTP) Run() op.Op {
 
 	startTime := time.Now()
 
-	redactedResponse, err := h.Client.RedactGetWithContext(runCtx, h.Path, h.Redactions)
-	result := map[string]any{"response": redactedResponse}
+	// Large responses are streamed to a file in the bundle, and redacted on the way, rather than decoded.
+	ext := ".json"
+	if h.Format == "string" {
+		ext = ".txt"
+	}
+	out := NewOutput(h.ctx, h.ID(), ext, h.Redactions)
+	err := h.Client.GetToWithContext(runCtx, h.Path, out)
+	if closeErr := out.Close(); closeErr != nil {
+		return op.New(h.ID(), nil, op.Fail, closeErr, Params(h), startTime, time.Now())
+	}
+	var result map[string]any
+	if out.InFile() {
+		result = out.FileResult()
+	} else {
+		var statusErr client.StatusError
+		if err == nil || errors.As(err, &statusErr) {
+			var redactedResponse any
+			var decodeErr error
+			if h.Format == "string" {
+				redactedResponse, decodeErr = redact.String(string(out.Bytes()), h.Redactions)
+			} else {
+				redactedResponse, decodeErr = client.DecodeResponse(out.Bytes(), h.Redactions)
+			}
+			result = map[string]any{"response": redactedResponse}
+			if err == nil {
+				err = decodeErr
+			}
+		} else {
+			result = map[string]any{"response": nil}
+		}
+	}
 	if err != nil {
 		var failureType op.Status
+		var statusErr client.StatusError
+		var skipErr error
+		if errors.As(err, &statusErr) && h.skipResponse != nil {
+			skipErr = h.skipResponse(statusErr, out.Bytes())
+		}
 		switch {
+		case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusForbidden:
+			failureType = op.Skip
+		case skipErr != nil:
+			failureType = op.Skip
+			err = skipErr
 		// The error returned from the http package will wrap timeouts/cancellations, so we check whether we find
 		// these in the error chain to determine the proper op.Status for the failure type.
 		case errors.Is(err, context.DeadlineExceeded):
@@ -118,6 +189,13 @@ func (h HTTP) Run() op.Op {
 	return op.New(h.ID(), result, op.Success, nil, Params(h), startTime, time.Now())
 }
 
+// TrackRedactions returns a copy of the HTTP runner whose redactions count the replacements they make.
+func (h HTTP) TrackRedactions() (Runner, *redact.Tally) {
+	var tally *redact.Tally
+	h.Redactions, tally = redact.Track(h.Redactions)
+	return h, tally
+}
+
 var _ error = HTTPConfigError{}
 
 type HTTPConfigError struct {
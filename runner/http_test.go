@@ -5,6 +5,10 @@ package runner
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -35,12 +39,29 @@ func TestNewHTTP(t *testing.T) {
 			cfg:  HttpConfig{Client: c},
 			expect: &HTTP{
 				Path:       "",
+				Format:     "json",
 				Client:     c,
 				ctx:        context.Background(),
 				Timeout:    0,
 				Redactions: nil,
 			},
 		},
+		{
+			desc: "string format",
+			cfg:  HttpConfig{Client: c, Path: "/metrics", Format: "string"},
+			expect: &HTTP{
+				Path:    "/metrics",
+				Format:  "string",
+				Client:  c,
+				ctx:     context.Background(),
+				Timeout: 0,
+			},
+		},
+		{
+			desc:      "invalid format causes an error",
+			cfg:       HttpConfig{Client: c, Format: "yaml"},
+			expectErr: true,
+		},
 		{
 			desc: "negative timeout duration causes an error",
 			cfg: HttpConfig{
@@ -98,6 +119,103 @@ func TestHTTP_RunTimeout(t *testing.T) {
 	assert.ErrorIs(t, result.Error, context.DeadlineExceeded)
 }
 
+func TestHTTP_RunStatus(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		case "/disabled":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":["feature is not enabled"]}`))
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"errors":["internal error"]}`))
+		case "/metrics":
+			_, _ = w.Write([]byte("# TYPE up gauge\nup 1\n"))
+		default:
+			_, _ = w.Write([]byte(`{"ok":true}`))
+		}
+	}))
+	defer srv.Close()
+
+	c, err := client.NewAPIClient(client.APIConfig{Product: "consul", BaseURL: srv.URL})
+	require.NoError(t, err)
+
+	skipDisabled := func(err client.StatusError, body []byte) error {
+		if strings.Contains(string(body), "not enabled") {
+			return fmt.Errorf("%w: not enabled", err)
+		}
+		return nil
+	}
+
+	tt := []struct {
+		desc     string
+		cfg      HttpConfig
+		status   op.Status
+		response any
+		err      string
+	}{
+		{
+			desc:     "success",
+			cfg:      HttpConfig{Client: c, Path: "/ok"},
+			status:   op.Success,
+			response: map[string]any{"ok": true},
+		},
+		{
+			desc:     "forbidden is skipped",
+			cfg:      HttpConfig{Client: c, Path: "/forbidden"},
+			status:   op.Skip,
+			response: map[string]any{"errors": []any{"permission denied"}},
+			err:      "403 Forbidden",
+		},
+		{
+			desc:     "responses that SkipResponse returns an error for are skipped",
+			cfg:      HttpConfig{Client: c, Path: "/disabled", Format: "string", SkipResponse: skipDisabled},
+			status:   op.Skip,
+			response: `{"errors":["feature is not enabled"]}`,
+			err:      "400 Bad Request: not enabled",
+		},
+		{
+			desc:     "bad requests are unknown without SkipResponse",
+			cfg:      HttpConfig{Client: c, Path: "/disabled", Format: "string"},
+			status:   op.Unknown,
+			response: `{"errors":["feature is not enabled"]}`,
+			err:      "400 Bad Request",
+		},
+		{
+			desc:     "other errors are unknown",
+			cfg:      HttpConfig{Client: c, Path: "/error", SkipResponse: skipDisabled},
+			status:   op.Unknown,
+			response: map[string]any{"errors": []any{"internal error"}},
+			err:      "500 Internal Server Error",
+		},
+		{
+			desc:     "string format",
+			cfg:      HttpConfig{Client: c, Path: "/metrics", Format: "string"},
+			status:   op.Success,
+			response: "# TYPE up gauge\nup 1\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.desc, func(t *testing.T) {
+			h, err := NewHTTP(tc.cfg)
+			require.NoError(t, err)
+			result := h.Run()
+			assert.Equal(t, tc.status, result.Status)
+			assert.Equal(t, tc.response, result.Result["response"])
+			if tc.err != "" {
+				assert.EqualError(t, result.Error, tc.err)
+			} else {
+				assert.NoError(t, result.Error)
+			}
+		})
+	}
+}
+
 func getTestAPIClient(t *testing.T) *client.APIClient {
 	t.Helper()
 
@@ -15,7 +15,7 @@ import (
 	"github.com/hashicorp/hcdiag/runner"
 )
 
-var _ runner.Runner = Docker{}
+var _ runner.Redactor = Docker{}
 
 type DockerConfig struct {
 	// Container is the name of the docker container to get logs from
@@ -104,6 +104,13 @@ func (d Docker) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Docker runner whose redactions count the replacements they make.
+func (d Docker) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	d.Redactions, tally = redact.Track(d.Redactions)
+	return d, tally
+}
+
 func (d Docker) run() op.Op {
 	// Check that docker exists
 	version, err := runner.NewShell(runner.ShellConfig{
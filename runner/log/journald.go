@@ -17,7 +17,7 @@ import (
 	"github.com/hashicorp/go-hclog"
 )
 
-var _ runner.Runner = Journald{}
+var _ runner.Redactor = Journald{}
 
 // JournaldTimeLayout custom go time layouts must match the reference time Jan 2 15:04:05 2006 MST
 const JournaldTimeLayout = "2006-01-02 15:04:05"
@@ -106,6 +106,13 @@ func (j Journald) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Journald runner whose redactions count the replacements they make.
+func (j Journald) TrackRedactions() (runner.Runner, *redact.Tally) {
+	var tally *redact.Tally
+	j.Redactions, tally = redact.Track(j.Redactions)
+	return j, tally
+}
+
 func (j Journald) run() op.Op {
 	s, err := runner.NewShell(runner.ShellConfig{
 		Command:    "journalctl --version",
@@ -0,0 +1,285 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/hashicorp/hcdiag/redact"
+)
+
+const (
+	// DefaultMaxInlineOutput is the largest output, in bytes, that a runner keeps in memory and includes in
+	// results.json, if OutputConfig.MaxInline is not set.
+	DefaultMaxInlineOutput = 1 << 20
+
+	// OutputsDir is the directory, relative to the root of a bundle, that large outputs are written to.
+	OutputsDir = "outputs"
+)
+
+// OutputConfig describes where runners write outputs that are too large to keep in memory. It is carried on the
+// context that runners are created with, so that it applies to every runner in a run, including those created by
+// other runners.
+type OutputConfig struct {
+	// Dir is the root of the bundle. Large outputs are written under Dir/outputs, and referred to in results.json by
+	// their path relative to Dir.
+	Dir string
+	// MaxInline is the largest output, in bytes, that is kept in memory. Zero means DefaultMaxInlineOutput.
+	MaxInline int
+	// RawDir is where JSON outputs are held, unredacted, until they're redacted into Dir. It should be a private
+	// directory outside Dir, so that they're never archived. Empty means the system's temporary directory.
+	RawDir string
+
+	// outputs holds every Output created with the config that hasn't been closed yet.
+	outputs *outputSet
+}
+
+// ErrOutputClosed is returned when writing to an Output that has been closed, such as by a runner that is still
+// running after CloseOutputs.
+var ErrOutputClosed = errors.New("output is closed")
+
+// outputSet tracks the open Outputs of a run, so that they can be closed once it ends.
+type outputSet struct {
+	mu   sync.Mutex
+	open map[*Output]struct{}
+}
+
+type outputConfigKey struct{}
+
+// WithOutputConfig returns a copy of ctx that carries cfg. Runners created with the returned context write large
+// outputs to files, rather than holding them in memory. Without it, all outputs are held in memory.
+func WithOutputConfig(ctx context.Context, cfg OutputConfig) context.Context {
+	cfg.outputs = &outputSet{open: make(map[*Output]struct{})}
+	return context.WithValue(ctx, outputConfigKey{}, cfg)
+}
+
+// CloseOutputs closes every Output created with the OutputConfig carried by ctx that is still open. Runners that
+// outlive the run, such as commands that are still running after its deadline, would otherwise keep writing into the
+// bundle while it's archived. Their writes fail with ErrOutputClosed instead.
+func CloseOutputs(ctx context.Context) error {
+	cfg, ok := outputConfig(ctx)
+	if !ok {
+		return nil
+	}
+	cfg.outputs.mu.Lock()
+	open := make([]*Output, 0, len(cfg.outputs.open))
+	for o := range cfg.outputs.open {
+		open = append(open, o)
+	}
+	cfg.outputs.mu.Unlock()
+
+	var errs []error
+	for _, o := range open {
+		errs = append(errs, o.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// outputConfig returns the OutputConfig carried by ctx, if any.
+func outputConfig(ctx context.Context) (OutputConfig, bool) {
+	if ctx == nil {
+		return OutputConfig{}, false
+	}
+	cfg, ok := ctx.Value(outputConfigKey{}).(OutputConfig)
+	return cfg, ok && cfg.Dir != ""
+}
+
+var _ io.WriteCloser = &Output{}
+
+// Output collects the output of a runner. It is held in memory until it grows past the configured limit, at which
+// point it is moved to a file in the bundle, and everything written afterwards is streamed to that file through a
+// redact.Writer. JSON outputs are instead collected in a temporary file in OutputConfig.RawDir, and redacted into the
+// bundle with redact.StreamJSON when the Output is closed, so that they're redacted the same way whatever their size.
+// Output held in memory is not redacted, so that runners can parse and redact it as they always have.
+type Output struct {
+	// mu serializes writes with Close, which may be called by CloseOutputs while the runner is still writing.
+	mu         sync.Mutex
+	closed     bool
+	cfg        OutputConfig
+	spill      bool
+	json       bool
+	name       string
+	ext        string
+	redactions []*redact.Redact
+
+	buf  bytes.Buffer
+	file *os.File
+	// w is written to once the output is in a file. It's either rw, which redacts into file, or raw, which holds a JSON
+	// output until it's redacted into file.
+	w    io.Writer
+	rw   *redact.Writer
+	raw  *os.File
+	size int64
+}
+
+// unsafeFileChars matches characters that are replaced when a runner's ID is used to name its output file.
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// NewOutput returns an Output for the runner with the given ID, using the OutputConfig carried by ctx. If ctx does
+// not carry one, everything written is held in memory. ext, such as ".json", is appended to the output's file name.
+func NewOutput(ctx context.Context, id, ext string, redactions []*redact.Redact) *Output {
+	cfg, ok := outputConfig(ctx)
+	if cfg.MaxInline <= 0 {
+		cfg.MaxInline = DefaultMaxInlineOutput
+	}
+	name := unsafeFileChars.ReplaceAllString(id, "_")
+	if 64 < len(name) {
+		name = name[:64]
+	}
+	o := &Output{
+		cfg:        cfg,
+		spill:      ok,
+		json:       ext == ".json" && 0 < len(redactions),
+		name:       name,
+		ext:        ext,
+		redactions: redactions,
+	}
+	if ok {
+		cfg.outputs.mu.Lock()
+		cfg.outputs.open[o] = struct{}{}
+		cfg.outputs.mu.Unlock()
+	}
+	return o
+}
+
+// Write holds p in memory, or writes it to the output's file if the output has grown too large. It returns
+// ErrOutputClosed once the output has been closed.
+func (o *Output) Write(p []byte) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return 0, ErrOutputClosed
+	}
+	o.size += int64(len(p))
+	if o.w == nil && (!o.spill || o.buf.Len()+len(p) <= o.cfg.MaxInline) {
+		return o.buf.Write(p)
+	}
+	if o.w == nil {
+		if err := o.open(); err != nil {
+			return 0, err
+		}
+	}
+	return o.w.Write(p)
+}
+
+// open creates the output's file and moves everything held in memory into it.
+func (o *Output) open() error {
+	dir := filepath.Join(o.cfg.Dir, OutputsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := o.create(dir)
+	if err != nil {
+		return err
+	}
+	o.file = f
+	if o.json {
+		// Keep the unredacted output out of the bundle, so that it's never archived, even if hcdiag exits early.
+		raw, err := os.CreateTemp(o.cfg.RawDir, "hcdiag-"+o.name+"-*"+o.ext)
+		if err != nil {
+			return err
+		}
+		o.raw = raw
+		o.w = raw
+	} else {
+		o.rw = redact.NewWriter(f, o.redactions)
+		o.w = o.rw
+	}
+	_, err = o.w.Write(o.buf.Bytes())
+	o.buf = bytes.Buffer{}
+	return err
+}
+
+// create creates the output's file in dir. It's named after the runner's ID, rather than given a random name, so that
+// bundles from different runs can be compared file by file. If another output already has that name, because runner
+// IDs were the same or only differed in their unsafe characters, a counter is appended to it.
+func (o *Output) create(dir string) (*os.File, error) {
+	for i := 1; ; i++ {
+		name := o.name + o.ext
+		if 1 < i {
+			name = fmt.Sprintf("%s-%d%s", o.name, i, o.ext)
+		}
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0600)
+		if !errors.Is(err, fs.ErrExist) {
+			return f, err
+		}
+	}
+}
+
+// Close flushes and closes the output's file, if it has one. A JSON output is redacted into its file first. Closing an
+// output more than once has no effect.
+func (o *Output) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return nil
+	}
+	o.closed = true
+	if o.spill {
+		o.cfg.outputs.mu.Lock()
+		delete(o.cfg.outputs.open, o)
+		o.cfg.outputs.mu.Unlock()
+	}
+	if o.w == nil {
+		return nil
+	}
+	if o.raw != nil {
+		err := o.redactJSON()
+		return errors.Join(err, o.raw.Close(), os.Remove(o.raw.Name()), o.file.Close())
+	}
+	return errors.Join(o.rw.Close(), o.file.Close())
+}
+
+// redactJSON redacts the raw JSON output into the output's file. If the output turns out not to be valid JSON, it's
+// redacted as text instead, so that it's still written out.
+func (o *Output) redactJSON() error {
+	// Check that the output is valid before redacting it, so that redactions aren't counted twice if it isn't.
+	if _, err := o.raw.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	valid := redact.StreamJSON(io.Discard, o.raw, nil) == nil
+	if _, err := o.raw.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if valid {
+		return redact.StreamJSON(o.file, o.raw, o.redactions)
+	}
+	w := redact.NewWriter(o.file, o.redactions)
+	if _, err := io.Copy(w, o.raw); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// InFile returns true if the output was too large to keep in memory, and was written to a file instead.
+func (o *Output) InFile() bool {
+	return o.w != nil
+}
+
+// Bytes returns the output held in memory, which is not redacted. It is empty if the output was written to a file.
+func (o *Output) Bytes() []byte {
+	return o.buf.Bytes()
+}
+
+// FileResult returns the result that refers to the output's file, for runners to use in place of the output itself.
+// The file's path is relative to the root of the bundle, and its size is the size of the output before redaction.
+func (o *Output) FileResult() map[string]any {
+	rel, err := filepath.Rel(o.cfg.Dir, o.file.Name())
+	if err != nil {
+		rel = o.file.Name()
+	}
+	return map[string]any{
+		"file": filepath.ToSlash(rel),
+		"size": o.size,
+	}
+}
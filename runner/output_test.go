@@ -0,0 +1,171 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package runner
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/redact"
+)
+
+func TestOutput(t *testing.T) {
+	r, err := redact.New(redact.Config{Matcher: "secret", Replace: "REDACTED"})
+	require.NoError(t, err)
+	redactions := []*redact.Redact{r}
+
+	t.Run("held in memory without an output config", func(t *testing.T) {
+		out := NewOutput(context.Background(), "test", ".txt", redactions)
+		_, err := out.Write([]byte(strings.Repeat("a secret\n", DefaultMaxInlineOutput)))
+		require.NoError(t, err)
+		require.NoError(t, out.Close())
+		assert.False(t, out.InFile())
+		assert.Equal(t, 9*DefaultMaxInlineOutput, len(out.Bytes()))
+	})
+
+	t.Run("held in memory under the limit", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx := WithOutputConfig(context.Background(), OutputConfig{Dir: dir, MaxInline: 16})
+		out := NewOutput(ctx, "test", ".txt", redactions)
+		_, err := out.Write([]byte("a secret\n"))
+		require.NoError(t, err)
+		require.NoError(t, out.Close())
+		assert.False(t, out.InFile())
+		assert.Equal(t, "a secret\n", string(out.Bytes()))
+		assert.NoDirExists(t, filepath.Join(dir, OutputsDir))
+	})
+
+	t.Run("JSON written to a file over the limit is redacted as JSON", func(t *testing.T) {
+		path, err := redact.NewPath(redact.Config{}, "**.password")
+		require.NoError(t, err)
+		dir, rawDir := t.TempDir(), t.TempDir()
+		ctx := WithOutputConfig(context.Background(), OutputConfig{Dir: dir, MaxInline: 16, RawDir: rawDir})
+		out := NewOutput(ctx, "test", ".json", []*redact.Redact{r, path})
+		_, err = out.Write([]byte(`{"password": "hunter2", "note": "a secret"}`))
+		require.NoError(t, err)
+
+		// The unredacted output is held in RawDir until the output is closed.
+		raw, err := os.ReadDir(rawDir)
+		require.NoError(t, err)
+		assert.Len(t, raw, 1)
+		require.NoError(t, out.Close())
+		require.True(t, out.InFile())
+		raw, err = os.ReadDir(rawDir)
+		require.NoError(t, err)
+		assert.Empty(t, raw)
+
+		bts, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(out.FileResult()["file"].(string))))
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"password": "<REDACTED>", "note": "a REDACTED"}`, string(bts))
+	})
+
+	t.Run("invalid JSON written to a file over the limit is redacted as text", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx := WithOutputConfig(context.Background(), OutputConfig{Dir: dir, MaxInline: 16})
+		out := NewOutput(ctx, "test", ".json", redactions)
+		_, err = out.Write([]byte("not JSON, but a secret\n"))
+		require.NoError(t, err)
+		require.NoError(t, out.Close())
+		require.True(t, out.InFile())
+
+		bts, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(out.FileResult()["file"].(string))))
+		require.NoError(t, err)
+		assert.Equal(t, "not JSON, but a REDACTED\n", string(bts))
+	})
+
+	t.Run("written to a file over the limit", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx := WithOutputConfig(context.Background(), OutputConfig{Dir: dir, MaxInline: 16})
+		out := NewOutput(ctx, "command ls /some/path", ".txt", redactions)
+		for i := 0; i < 3; i++ {
+			_, err := out.Write([]byte("a secret\n"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, out.Close())
+		require.True(t, out.InFile())
+		assert.Empty(t, out.Bytes())
+
+		result := out.FileResult()
+		assert.Equal(t, int64(27), result["size"])
+		file := result["file"].(string)
+		assert.Equal(t, OutputsDir+"/command_ls_some_path.txt", file)
+
+		bts, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(file)))
+		require.NoError(t, err)
+		assert.Equal(t, strings.Repeat("a REDACTED\n", 3), string(bts))
+	})
+
+	t.Run("closed by CloseOutputs while still being written", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx := WithOutputConfig(context.Background(), OutputConfig{Dir: dir, MaxInline: 16})
+		out := NewOutput(ctx, "test", ".txt", redactions)
+		_, err := out.Write([]byte("a secret, over the limit\n"))
+		require.NoError(t, err)
+
+		require.NoError(t, CloseOutputs(ctx))
+		_, err = out.Write([]byte("a secret, too late\n"))
+		assert.ErrorIs(t, err, ErrOutputClosed)
+		assert.NoError(t, out.Close(), "the runner's own Close has no effect")
+
+		bts, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(out.FileResult()["file"].(string))))
+		require.NoError(t, err)
+		assert.Equal(t, "a REDACTED, over the limit\n", string(bts))
+	})
+
+	t.Run("files for the same runner ID are numbered", func(t *testing.T) {
+		dir := t.TempDir()
+		ctx := WithOutputConfig(context.Background(), OutputConfig{Dir: dir, MaxInline: 16})
+		files := make([]string, 3)
+		for i := range files {
+			out := NewOutput(ctx, "shell ls", ".txt", redactions)
+			_, err := out.Write([]byte("over the limit\n"))
+			require.NoError(t, err)
+			_, err = out.Write([]byte("over the limit\n"))
+			require.NoError(t, err)
+			require.NoError(t, out.Close())
+			files[i] = out.FileResult()["file"].(string)
+		}
+		assert.Equal(t, []string{
+			OutputsDir + "/shell_ls.txt",
+			OutputsDir + "/shell_ls-2.txt",
+			OutputsDir + "/shell_ls-3.txt",
+		}, files)
+	})
+}
+
+func TestShell_LargeOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	t.Setenv("SHELL", "/bin/sh")
+
+	r, err := redact.New(redact.Config{Matcher: "secret", Replace: "REDACTED"})
+	require.NoError(t, err)
+	dir := t.TempDir()
+	ctx := WithOutputConfig(context.Background(), OutputConfig{Dir: dir, MaxInline: 64})
+	sh, err := NewShellWithContext(ctx, ShellConfig{
+		Command:    "for i in 1 2 3 4 5 6 7 8 9 10; do echo line $i secret; done",
+		Redactions: []*redact.Redact{r},
+	})
+	require.NoError(t, err)
+
+	o := sh.Run()
+	require.NoError(t, o.Error)
+	assert.Equal(t, op.Success, o.Status)
+	require.Contains(t, o.Result, "file")
+	assert.NotContains(t, o.Result, "shell")
+
+	bts, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(o.Result["file"].(string))))
+	require.NoError(t, err)
+	assert.Contains(t, string(bts), "line 10 REDACTED\n")
+	assert.NotContains(t, string(bts), "secret")
+}
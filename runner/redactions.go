@@ -0,0 +1,35 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package runner
+
+import (
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/redact"
+)
+
+// Redactor is implemented by runners that apply redactions to their results.
+type Redactor interface {
+	Runner
+	// TrackRedactions returns a copy of the runner whose redactions count the replacements they make in the returned
+	// Tally. The runner itself is left as it is, since it may be shared.
+	TrackRedactions() (Runner, *redact.Tally)
+}
+
+// CountRedactions runs r with copies of its redactions that count the replacements they make, and adds the counts to
+// the returned op. Every runner run by a Scheduler is counted this way.
+func CountRedactions(r Runner) op.Op {
+	counted, tally := TrackRedactions(r)
+	o := counted.Run()
+	o.Redactions = redact.MergeCounts(o.Redactions, tally.Counts())
+	return o
+}
+
+// TrackRedactions returns a copy of r whose redactions count replacements in the returned Tally, if r is a Redactor.
+// Other runners are returned as they are, with a nil Tally, which counts nothing.
+func TrackRedactions(r Runner) (Runner, *redact.Tally) {
+	if rr, ok := r.(Redactor); ok {
+		return rr.TrackRedactions()
+	}
+	return r, nil
+}
@@ -0,0 +1,57 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package runner
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/hcdiag/op"
+	"github.com/hashicorp/hcdiag/redact"
+)
+
+func TestCountRedactions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		return
+	}
+	t.Setenv("SHELL", "/bin/sh")
+
+	r, err := redact.New(redact.Config{ID: "secret", Matcher: "secret", Replace: "REDACTED"})
+	require.NoError(t, err)
+	sh, err := NewShell(ShellConfig{
+		Command:    "echo secret secret; echo public",
+		Redactions: []*redact.Redact{r},
+	})
+	require.NoError(t, err)
+
+	t.Run("pointer runner", func(t *testing.T) {
+		o := CountRedactions(sh)
+		require.NoError(t, o.Error)
+		assert.Equal(t, map[string]any{"shell": "REDACTED REDACTED\npublic\n"}, o.Result)
+		assert.Equal(t, map[string]int{"secret": 2}, o.Redactions)
+	})
+
+	t.Run("value runner through a scheduler", func(t *testing.T) {
+		o := NewScheduler(context.Background(), 1).Run(*sh)
+		require.NoError(t, o.Error)
+		assert.Equal(t, map[string]int{"secret": 2}, o.Redactions)
+	})
+
+	t.Run("runner is not changed", func(t *testing.T) {
+		CountRedactions(sh)
+		assert.Equal(t, []*redact.Redact{r}, sh.Redactions)
+	})
+
+	t.Run("no replacements", func(t *testing.T) {
+		quiet, err := NewShell(ShellConfig{Command: "echo public", Redactions: []*redact.Redact{r}})
+		require.NoError(t, err)
+		o := CountRedactions(quiet)
+		assert.Equal(t, op.Success, o.Status)
+		assert.Nil(t, o.Redactions)
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package runner
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/hcdiag/op"
+)
+
+// Parent is implemented by runners that only coordinate other runners, such as do.Do and do.Seq. A Scheduler does
+// not hold a slot while a Parent runs; the Parent hands each of its children back to the Scheduler instead. That way
+// only leaf runners count against the concurrency limit, and nested parents can't deadlock waiting on each other.
+type Parent interface {
+	Runner
+	// RunWith runs the Parent's children through s. A nil Scheduler runs them without any limit.
+	RunWith(s *Scheduler) op.Op
+}
+
+// Scheduler bounds how many runners execute at the same time. A single Scheduler is shared across every product in a
+// run, so that the limit applies to the run as a whole. The zero value and a nil *Scheduler are both unbounded.
+type Scheduler struct {
+	ctx   context.Context
+	slots chan struct{}
+}
+
+// NewScheduler returns a Scheduler that allows at most limit runners to execute at once. A limit of zero or less
+// means no limit. Runners waiting for a slot give up when ctx is done.
+func NewScheduler(ctx context.Context, limit int) *Scheduler {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s := Scheduler{ctx: ctx}
+	if 0 < limit {
+		s.slots = make(chan struct{}, limit)
+	}
+	return &s
+}
+
+// Run executes r once a slot is free, and records how long it waited for the slot, and how many replacements its
+// redactions made, in the returned op.
+func (s *Scheduler) Run(r Runner) op.Op {
+	if s == nil {
+		return s.RunWithContext(context.Background(), r)
+	}
+	return s.RunWithContext(s.ctx, r)
+}
+
+// RunWithContext is similar to Run, but it stops waiting for a slot when ctx is done, instead of the context the
+// Scheduler was created with. In that case, r does not run at all, and a canceled or timed out op is returned for it.
+func (s *Scheduler) RunWithContext(ctx context.Context, r Runner) op.Op {
+	if p, ok := r.(Parent); ok {
+		return p.RunWith(s)
+	}
+	if s == nil || s.slots == nil {
+		return CountRedactions(r)
+	}
+
+	waitStart := time.Now()
+	select {
+	case <-ctx.Done():
+		o := ContextOp(r, ctx.Err(), waitStart)
+		o.QueueWait = time.Since(waitStart)
+		return o
+	case s.slots <- struct{}{}:
+	}
+	wait := time.Since(waitStart)
+	defer func() { <-s.slots }()
+
+	o := CountRedactions(r)
+	o.QueueWait = wait
+	return o
+}
+
+// ContextOp returns a canceled or timed out op for r, depending on the context error err.
+func ContextOp(r Runner, err error, start time.Time) op.Op {
+	switch err {
+	case context.Canceled:
+		return CancelOp(r, err, start)
+	case context.DeadlineExceeded:
+		return TimeoutOp(r, err, start)
+	default:
+		return op.New(r.ID(), nil, op.Unknown, err, Params(r), start, time.Now())
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package runner
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hashicorp/hcdiag/op"
+)
+
+// countingRunner tracks how many countingRunners are executing at once.
+type countingRunner struct {
+	id      string
+	delay   time.Duration
+	running *int32
+	maxSeen *int32
+}
+
+func (c countingRunner) ID() string {
+	return c.id
+}
+
+func (c countingRunner) Run() op.Op {
+	start := time.Now()
+	n := atomic.AddInt32(c.running, 1)
+	for {
+		seen := atomic.LoadInt32(c.maxSeen)
+		if n <= seen || atomic.CompareAndSwapInt32(c.maxSeen, seen, n) {
+			break
+		}
+	}
+	time.Sleep(c.delay)
+	atomic.AddInt32(c.running, -1)
+	return op.New(c.ID(), map[string]any{}, op.Success, nil, Params(c), start, time.Now())
+}
+
+func TestScheduler_Run(t *testing.T) {
+	testTable := []struct {
+		desc    string
+		limit   int
+		runners int
+		maxSeen int32
+	}{
+		{
+			desc:    "limit of one runs runners one at a time",
+			limit:   1,
+			runners: 4,
+			maxSeen: 1,
+		},
+		{
+			desc:    "limit is respected when more runners are queued",
+			limit:   2,
+			runners: 6,
+			maxSeen: 2,
+		},
+		{
+			desc:    "zero means no limit",
+			limit:   0,
+			runners: 4,
+			maxSeen: 4,
+		},
+	}
+
+	for _, tc := range testTable {
+		t.Run(tc.desc, func(t *testing.T) {
+			var running, maxSeen int32
+			s := NewScheduler(context.Background(), tc.limit)
+
+			var wg sync.WaitGroup
+			ops := make([]op.Op, tc.runners)
+			for i := 0; i < tc.runners; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					ops[i] = s.Run(countingRunner{id: "counter", delay: 50 * time.Millisecond, running: &running, maxSeen: &maxSeen})
+				}(i)
+			}
+			wg.Wait()
+
+			assert.Equal(t, tc.maxSeen, maxSeen)
+			for _, o := range ops {
+				assert.Equal(t, op.Success, o.Status)
+			}
+		})
+	}
+}
+
+func TestScheduler_RunRecordsQueueWait(t *testing.T) {
+	var running, maxSeen int32
+	s := NewScheduler(context.Background(), 1)
+
+	first := make(chan op.Op)
+	go func() {
+		first <- s.Run(countingRunner{id: "first", delay: 100 * time.Millisecond, running: &running, maxSeen: &maxSeen})
+	}()
+	// Give the first runner a head start so that it holds the only slot.
+	time.Sleep(20 * time.Millisecond)
+	second := s.Run(countingRunner{id: "second", running: &running, maxSeen: &maxSeen})
+
+	// The second runner can only start once the first has finished with the slot.
+	assert.GreaterOrEqual(t, second.QueueWait, 50*time.Millisecond)
+	assert.Less(t, (<-first).QueueWait, second.QueueWait)
+}
+
+func TestScheduler_RunWithContext(t *testing.T) {
+	var running, maxSeen int32
+	s := NewScheduler(context.Background(), 1)
+
+	// Hold the only slot while the second runner waits.
+	go s.Run(countingRunner{id: "first", delay: 200 * time.Millisecond, running: &running, maxSeen: &maxSeen})
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	o := s.RunWithContext(ctx, countingRunner{id: "second", running: &running, maxSeen: &maxSeen})
+	assert.Equal(t, op.Timeout, o.Status)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&maxSeen), "the waiting runner should never have run")
+}
+
+func TestScheduler_Nil(t *testing.T) {
+	var running, maxSeen int32
+	var s *Scheduler
+	o := s.Run(countingRunner{id: "nil", running: &running, maxSeen: &maxSeen})
+	assert.Equal(t, op.Success, o.Status)
+}
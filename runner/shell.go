@@ -97,6 +97,13 @@ func (s Shell) Run() op.Op {
 	}
 }
 
+// TrackRedactions returns a copy of the Shell runner whose redactions count the replacements they make.
+func (s Shell) TrackRedactions() (Runner, *redact.Tally) {
+	var tally *redact.Tally
+	s.Redactions, tally = redact.Track(s.Redactions)
+	return s, tally
+}
+
 func (s Shell) run() op.Op {
 	// Read the shell from the environment
 	shell, err := util.GetShell()
@@ -107,7 +114,25 @@ func (s Shell) run() op.Op {
 
 	// Run the command
 	args := []string{"-c", s.Command}
-	bts, cmdErr := exec.Command(s.Shell, args...).CombinedOutput()
+	out := NewOutput(s.ctx, s.ID(), ".txt", s.Redactions)
+	cmd := exec.Command(s.Shell, args...)
+	cmd.Stdout, cmd.Stderr = out, out
+	cmdErr := cmd.Run()
+	if closeErr := out.Close(); closeErr != nil {
+		return op.New(s.ID(), nil, op.Fail, closeErr, Params(s), time.Time{}, time.Now())
+	}
+	// Large outputs are streamed to a file in the bundle, and redacted on the way.
+	if out.InFile() {
+		if cmdErr != nil {
+			return op.New(s.ID(), out.FileResult(), op.Unknown,
+				ShellExecError{
+					command: s.Command,
+					err:     cmdErr,
+				}, Params(s), time.Time{}, time.Now())
+		}
+		return op.New(s.ID(), out.FileResult(), op.Success, nil, Params(s), time.Time{}, time.Now())
+	}
+	bts := out.Bytes()
 	// Store and redact the result before cmd error handling, so we can return it in error and success cases.
 	redBts, redErr := redact.Bytes(bts, s.Redactions)
 	// Fail run if unable to redact
@@ -0,0 +1,19 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+host {
+  command {
+    id     = "bundle"
+    run    = "echo bundle"
+    format = "string"
+  }
+
+  copy {
+    path       = "./*"
+    depends_on = ["bundle"]
+  }
+
+  shell {
+    run = "echo independent"
+  }
+}
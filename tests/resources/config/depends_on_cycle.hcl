@@ -0,0 +1,17 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+host {
+  command {
+    id         = "first"
+    run        = "echo first"
+    format     = "string"
+    depends_on = ["second"]
+  }
+
+  shell {
+    id         = "second"
+    run        = "echo second"
+    depends_on = ["first"]
+  }
+}
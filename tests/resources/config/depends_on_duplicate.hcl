@@ -0,0 +1,15 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+host {
+  command {
+    run    = "echo first"
+    format = "string"
+    id     = "echo"
+  }
+
+  shell {
+    run = "echo second"
+    id  = "echo"
+  }
+}
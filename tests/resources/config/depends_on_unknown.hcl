@@ -0,0 +1,10 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+host {
+  command {
+    run        = "echo first"
+    format     = "string"
+    depends_on = ["missing"]
+  }
+}
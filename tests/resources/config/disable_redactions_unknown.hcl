@@ -0,0 +1,6 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+agent {
+  disable_redactions = ["email-address", "not-a-pattern"]
+}
@@ -0,0 +1,43 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+agent {
+  max_concurrency = -1
+  redact "regex" {
+    match = "[unclosed"
+  }
+}
+
+host {
+  command {
+    run     = "uptime"
+    format  = "yaml"
+    timeout = "5 minutes"
+  }
+  excludes = ["good", "bad[" ]
+}
+
+product "consol" {
+  GET {
+    path = "/v1/status/leader"
+  }
+}
+
+product "vault" {
+  redact "literal" {
+    match = "x"
+  }
+  command {
+    id         = "a"
+    run        = "vault status"
+    format     = "json"
+    depends_on = ["b"]
+  }
+}
+
+rule "r" {
+  product  = "vault"
+  severity = "urgent"
+  when     = true
+  message  = "m"
+}
@@ -0,0 +1,15 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+agent {
+  redact "path" {
+    path = "**.password"
+  }
+  redact "path" {
+    path  = "data..secret_id"
+    match = "secret"
+  }
+  redact "regex" {
+    path = "**.token"
+  }
+}
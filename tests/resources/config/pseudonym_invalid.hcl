@@ -0,0 +1,14 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+agent {
+  redact "regex" {
+    match     = "10\\.0\\.0\\.1"
+    replace   = "<IP>"
+    pseudonym = "IP"
+  }
+  redact "regex" {
+    match     = "server-1"
+    pseudonym = "1NODE"
+  }
+}
@@ -0,0 +1,13 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+redact "regex" {
+  id      = "hostname"
+  match   = "node1"
+  replace = "<HOSTNAME>"
+}
+
+redact "path" {
+  id   = "versions"
+  path = "**.version"
+}
@@ -0,0 +1,17 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+host {
+  command {
+    run    = "uptime"
+    format = "string"
+  }
+}
+
+rule "host-recently-rebooted" {
+  product     = "host"
+  severity    = "info"
+  description = "Flags hosts that were rebooted shortly before the run."
+  when        = length(regexall("min", result("uptime", "text"))) > 0
+  message     = "The host was rebooted recently: ${result("uptime", "text")}"
+}
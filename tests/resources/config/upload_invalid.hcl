@@ -0,0 +1,10 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+agent {
+  upload {
+    endpoint  = "s3.us-east-1.amazonaws.com"
+    bucket    = "support-bundles"
+    part_size = "1MiB"
+  }
+}
@@ -0,0 +1,26 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+product "acme-gateway" {
+  api {
+    address     = "http://127.0.0.1:8080"
+    address_env = "ACME_ADDR"
+    auth_env    = "ACME_TOKEN"
+    auth_scheme = "Bearer"
+    ca_cert_env = "ACME_CACERT"
+  }
+  healthcheck = "acme-gateway version"
+
+  command {
+    run    = "acme-gateway version"
+    format = "string"
+  }
+
+  GET {
+    path = "/v1/status"
+  }
+
+  copy {
+    path = "/etc/acme-gateway/*.hcl"
+  }
+}
@@ -0,0 +1,26 @@
+# Copyright IBM Corp. 2021, 2025
+# SPDX-License-Identifier: MPL-2.0
+
+product "vault" {
+  healthcheck = "vault version"
+}
+
+product "acme-gateway" {
+  healthcheck = "acme-gateway version"
+
+  GET {
+    path = "/v1/status"
+  }
+}
+
+product "acme-worker" {
+  api {
+    auth_env = "ACME_TOKEN"
+  }
+}
+
+product "acme-relay" {
+  api {
+    address = "127.0.0.1:8080"
+  }
+}
@@ -0,0 +1,389 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+// Package upload uploads bundles to Amazon S3, or an S3-compatible object store such as MinIO, with multipart uploads
+// that are signed with AWS Signature Version 4.
+package upload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultRegion is the region that requests are signed for when none is configured. Most S3-compatible stores
+	// accept it, whatever their location.
+	DefaultRegion = "us-east-1"
+	// DefaultPartSize is the size of each part of a multipart upload when none is configured.
+	DefaultPartSize = 16 << 20
+	// MinPartSize is the smallest part that S3 accepts, other than the last part of an upload.
+	MinPartSize = 5 << 20
+	// MaxParts is the most parts that S3 accepts in a single upload.
+	MaxParts = 10000
+	// DefaultRetries is how many times a failed request is retried when no number is configured.
+	DefaultRetries = 3
+	// DefaultRetryWait is how long to wait before the first retry of a request, when no wait is configured. The wait
+	// doubles with each retry.
+	DefaultRetryWait = time.Second
+)
+
+// Credentials are the keys that requests are signed with.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	// SessionToken is only needed with temporary credentials.
+	SessionToken string
+}
+
+// CredentialsFromEnv reads credentials from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN, the
+// environment variables that the AWS CLI reads.
+func CredentialsFromEnv() Credentials {
+	return Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// Config configures an Uploader.
+type Config struct {
+	// Endpoint is the URL of the object store, such as "https://s3.us-west-2.amazonaws.com" or "http://minio:9000".
+	// Objects are addressed by path, as Endpoint/Bucket/Key.
+	Endpoint string
+	Bucket   string
+	// Prefix is prepended to the name of each file to make its key, such as "support/" for "support/hcdiag.tar.gz".
+	Prefix string
+	// Region defaults to DefaultRegion.
+	Region      string
+	Credentials Credentials
+	// PartSize is the size of each part of a multipart upload, other than the last. It defaults to DefaultPartSize,
+	// and is raised for files that would otherwise need more than MaxParts parts.
+	PartSize int64
+	// Retries is how many times a failed request is retried, such as after a network error or a 5xx response. It
+	// defaults to DefaultRetries; a negative number disables retries.
+	Retries int
+	// RetryWait is how long to wait before the first retry of a request, which doubles with each retry. It defaults
+	// to DefaultRetryWait.
+	RetryWait time.Duration
+}
+
+// Object is an object that was uploaded.
+type Object struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Size   int64  `json:"size"`
+	ETag   string `json:"etag"`
+}
+
+// String returns the object's location as an s3:// URL.
+func (o Object) String() string {
+	return fmt.Sprintf("s3://%s/%s", o.Bucket, o.Key)
+}
+
+// Progress is called after each part of a file is uploaded, with the name of the file, the number of bytes of it that
+// have been uploaded so far, and its size.
+type Progress func(name string, sent, total int64)
+
+// Uploader uploads files to an object store.
+type Uploader struct {
+	cfg      Config
+	endpoint *url.URL
+	http     *http.Client
+	now      func() time.Time
+}
+
+// NewUploader returns an Uploader for cfg. If cfg has no credentials, they're read with CredentialsFromEnv.
+func NewUploader(cfg Config) (*Uploader, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload endpoint %q: %w", cfg.Endpoint, err)
+	}
+	if (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid upload endpoint %q, expected a URL such as https://s3.us-east-1.amazonaws.com", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, errors.New("an upload bucket is required")
+	}
+	if cfg.Region == "" {
+		cfg.Region = DefaultRegion
+	}
+	if cfg.Credentials == (Credentials{}) {
+		cfg.Credentials = CredentialsFromEnv()
+	}
+	if cfg.Credentials.AccessKeyID == "" || cfg.Credentials.SecretAccessKey == "" {
+		return nil, errors.New("no upload credentials were configured, and AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY aren't set")
+	}
+	if cfg.PartSize == 0 {
+		cfg.PartSize = DefaultPartSize
+	}
+	if cfg.PartSize < 0 {
+		return nil, fmt.Errorf("upload part size must be positive, got %d", cfg.PartSize)
+	}
+	if cfg.Retries == 0 {
+		cfg.Retries = DefaultRetries
+	}
+	if cfg.RetryWait == 0 {
+		cfg.RetryWait = DefaultRetryWait
+	}
+
+	return &Uploader{
+		cfg:      cfg,
+		endpoint: endpoint,
+		http:     &http.Client{},
+		now:      time.Now,
+	}, nil
+}
+
+// Key returns the key that the file at path is uploaded to.
+func (u *Uploader) Key(path string) string {
+	name := filepath.Base(path)
+	prefix := strings.Trim(u.cfg.Prefix, "/")
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
+
+// Upload uploads the file at path with a multipart upload, and returns the object it was uploaded to. Each request is
+// retried according to the Uploader's Config, and progress, if it isn't nil, is called after each part. If the upload
+// fails, it's aborted, so that the parts that were uploaded don't linger in the bucket.
+func (u *Uploader) Upload(ctx context.Context, path string, progress Progress) (Object, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Object{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Object{}, err
+	}
+
+	obj := Object{Bucket: u.cfg.Bucket, Key: u.Key(path), Size: info.Size()}
+	uploadID, err := u.createMultipartUpload(ctx, obj.Key)
+	if err != nil {
+		return Object{}, fmt.Errorf("unable to start upload of %s: %w", obj, err)
+	}
+
+	obj.ETag, err = u.uploadParts(ctx, f, obj, uploadID, progress)
+	if err != nil {
+		// The upload is aborted even if ctx was canceled, since the parts would otherwise be kept, and billed.
+		abortCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 30*time.Second)
+		defer cancel()
+		if abortErr := u.abortMultipartUpload(abortCtx, obj.Key, uploadID); abortErr != nil {
+			err = errors.Join(err, fmt.Errorf("unable to abort upload: %w", abortErr))
+		}
+		return Object{}, fmt.Errorf("unable to upload %s: %w", obj, err)
+	}
+	return obj, nil
+}
+
+// uploadParts uploads the contents of f in parts, completes the upload, and returns the ETag of the object.
+func (u *Uploader) uploadParts(ctx context.Context, f io.ReaderAt, obj Object, uploadID string, progress Progress) (string, error) {
+	partSize := u.cfg.PartSize
+	if n := (obj.Size + partSize - 1) / partSize; n > MaxParts {
+		partSize = (obj.Size + MaxParts - 1) / MaxParts
+	}
+
+	complete := completeMultipartUpload{}
+	// Even an empty file is uploaded as a single, empty part.
+	for offset, number := int64(0), 1; offset < obj.Size || number == 1; offset, number = offset+partSize, number+1 {
+		size := min(partSize, obj.Size-offset)
+		etag, err := u.uploadPart(ctx, obj.Key, uploadID, number, io.NewSectionReader(f, offset, size))
+		if err != nil {
+			return "", fmt.Errorf("part %d: %w", number, err)
+		}
+		complete.Parts = append(complete.Parts, completedPart{PartNumber: number, ETag: etag})
+		if progress != nil {
+			progress(filepath.Base(obj.Key), offset+size, obj.Size)
+		}
+	}
+	return u.completeMultipartUpload(ctx, obj.Key, uploadID, complete)
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName xml.Name
+	ETag    string `xml:"ETag"`
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (u *Uploader) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	body, err := u.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	var result initiateMultipartUploadResult
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("unable to decode response: %w", err)
+	}
+	if result.UploadID == "" {
+		return "", errors.New("no upload ID in response")
+	}
+	return result.UploadID, nil
+}
+
+func (u *Uploader) uploadPart(ctx context.Context, key, uploadID string, number int, part *io.SectionReader) (string, error) {
+	q := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	var etag string
+	_, err := u.doWithResponse(ctx, http.MethodPut, key, q, part, func(resp *http.Response) {
+		etag = resp.Header.Get("ETag")
+	})
+	if err != nil {
+		return "", err
+	}
+	if etag == "" {
+		return "", errors.New("no ETag in response")
+	}
+	return etag, nil
+}
+
+func (u *Uploader) completeMultipartUpload(ctx context.Context, key, uploadID string, complete completeMultipartUpload) (string, error) {
+	payload, err := xml.Marshal(complete)
+	if err != nil {
+		return "", err
+	}
+	body, err := u.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, io.NewSectionReader(bytes.NewReader(payload), 0, int64(len(payload))))
+	if err != nil {
+		return "", err
+	}
+	// S3 may report an error in the body of a 200 OK response, since it starts responding before the parts are joined.
+	var result completeMultipartUploadResult
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("unable to decode response: %w", err)
+	}
+	if result.XMLName.Local == "Error" {
+		return "", fmt.Errorf("%s: %s", result.Code, result.Message)
+	}
+	return result.ETag, nil
+}
+
+func (u *Uploader) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := u.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil)
+	return err
+}
+
+// StatusError is returned when the object store responds with an unexpected status.
+type StatusError struct {
+	StatusCode int
+	// Code and Message are from the body of the response, if it was an S3 error document.
+	Code    string
+	Message string
+}
+
+func (e StatusError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("%d %s: %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Code, e.Message)
+	}
+	return fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// retryable returns true for the statuses that may succeed if the request is retried.
+func (e StatusError) retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout
+}
+
+// do makes a signed request, and returns the body of the response.
+func (u *Uploader) do(ctx context.Context, method, key string, q url.Values, body *io.SectionReader) ([]byte, error) {
+	return u.doWithResponse(ctx, method, key, q, body, nil)
+}
+
+// doWithResponse makes a signed request for key, with body, if it isn't nil, and returns the body of the response. A
+// request that fails with a network error or a retryable status is retried, with a wait that doubles each time.
+// onSuccess, if it isn't nil, is called with the successful response.
+func (u *Uploader) doWithResponse(ctx context.Context, method, key string, q url.Values, body *io.SectionReader, onSuccess func(*http.Response)) ([]byte, error) {
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		h := sha256.New()
+		if _, err := io.Copy(h, io.NewSectionReader(body, 0, body.Size())); err != nil {
+			return nil, err
+		}
+		payloadHash = hex.EncodeToString(h.Sum(nil))
+	}
+
+	wait := u.cfg.RetryWait
+	for attempt := 0; ; attempt++ {
+		respBody, err := u.attempt(ctx, method, key, q, body, payloadHash, onSuccess)
+		var statusErr StatusError
+		retryable := err != nil && ctx.Err() == nil && (!errors.As(err, &statusErr) || statusErr.retryable())
+		if !retryable || attempt >= u.cfg.Retries {
+			return respBody, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// attempt makes a single signed request.
+func (u *Uploader) attempt(ctx context.Context, method, key string, q url.Values, body *io.SectionReader, payloadHash string, onSuccess func(*http.Response)) ([]byte, error) {
+	target := *u.endpoint
+	target.Path = strings.TrimSuffix(target.Path, "/") + "/" + u.cfg.Bucket + "/" + key
+	target.RawPath = ""
+	target.RawQuery = strings.ReplaceAll(q.Encode(), "uploads=", "uploads")
+
+	var reqBody io.Reader = http.NoBody
+	if body != nil {
+		reqBody = io.NewSectionReader(body, 0, body.Size())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = body.Size()
+	}
+	signV4(req, u.cfg.Credentials, u.cfg.Region, payloadHash, u.now())
+
+	resp, err := u.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		statusErr := StatusError{StatusCode: resp.StatusCode}
+		var doc completeMultipartUploadResult
+		if xml.Unmarshal(respBody, &doc) == nil {
+			statusErr.Code, statusErr.Message = doc.Code, doc.Message
+		}
+		return nil, statusErr
+	}
+	if onSuccess != nil {
+		onSuccess(resp)
+	}
+	return respBody, nil
+}
@@ -0,0 +1,129 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// EncryptedExt is the extension added to bundles that are encrypted with age.
+const EncryptedExt = ".age"
+
+// ParseRecipients parses s, which is either an age public key, such as "age1...", or the path to a file of them, one
+// per line, as written by `age-keygen -y`.
+func ParseRecipients(s string) ([]age.Recipient, error) {
+	if strings.HasPrefix(s, "age1") {
+		r, err := age.ParseX25519Recipient(s)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Recipient{r}, nil
+	}
+
+	f, err := os.Open(s)
+	if err != nil {
+		return nil, fmt.Errorf("recipient is neither an age public key nor a readable file of them: %w", err)
+	}
+	defer f.Close()
+	recipients, err := age.ParseRecipients(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read recipients from %s: %w", s, err)
+	}
+	return recipients, nil
+}
+
+// ParseIdentities reads the age private keys in the file at path, as written by `age-keygen`.
+func ParseIdentities(path string) ([]age.Identity, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read identities from %s: %w", path, err)
+	}
+	return identities, nil
+}
+
+// ArchiveEncrypted archives and compresses the files in sourceDir, like Archive, and encrypts the archive to
+// recipients with age as it's written to destFileName, so that the unencrypted archive is never written to disk.
+// destFileName is removed if it can't be written in full. FormatDir can't be encrypted.
+func ArchiveEncrypted(sourceDir string, destFileName string, baseName string, format ArchiveFormat, level int, recipients []age.Recipient) (err error) {
+	if format == FormatDir {
+		return fmt.Errorf("archive format %s can't be encrypted", format)
+	}
+
+	destFile, err := os.Create(destFileName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := destFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(destFileName)
+		}
+	}()
+
+	return WriteArchiveEncrypted(destFile, sourceDir, baseName, format, level, recipients)
+}
+
+// WriteArchiveEncrypted archives and compresses the files in sourceDir, like WriteArchive, and encrypts the archive to
+// recipients with age as it's written to w.
+func WriteArchiveEncrypted(w io.Writer, sourceDir string, baseName string, format ArchiveFormat, level int, recipients []age.Recipient) error {
+	ew, err := age.Encrypt(w, recipients...)
+	if err != nil {
+		return err
+	}
+	if err := WriteArchive(ew, sourceDir, baseName, format, level); err != nil {
+		return err
+	}
+	// Closing the age writer encrypts and writes the final chunk.
+	return ew.Close()
+}
+
+// Decrypt decrypts the age-encrypted file at src, such as a bundle written by ArchiveEncrypted, with identities, and
+// writes the result to dst, which must not already exist. dst is only readable by its owner, and is removed if the
+// decryption fails, such as because src was truncated or modified. src may also be the index of a file that was split
+// into parts, which are decrypted as one file.
+func Decrypt(src string, dst string, identities []age.Identity) (err error) {
+	in, size, closer, err := openArchive(src)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	r, err := age.Decrypt(io.NewSectionReader(in, 0, size), identities...)
+	if err != nil {
+		var noMatch *age.NoIdentityMatchError
+		if errors.As(err, &noMatch) {
+			return fmt.Errorf("%s was not encrypted to any of the given identities: %w", src, err)
+		}
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+
+	_, err = io.Copy(out, r)
+	return err
+}
@@ -0,0 +1,123 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package util
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchiveEncrypted(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "results.json"), []byte(`{"secret": "raft config"}`), 0600))
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	encrypted := filepath.Join(dir, "hcdiag.tar.gz"+EncryptedExt)
+	require.NoError(t, ArchiveEncrypted(src, encrypted, "hcdiag", FormatTarGz, DefaultCompressionLevel, []age.Recipient{identity.Recipient()}))
+
+	bts, err := os.ReadFile(encrypted)
+	require.NoError(t, err)
+	assert.NotContains(t, string(bts), "raft config")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "only the encrypted bundle should be written to the destination")
+
+	t.Run("decrypt", func(t *testing.T) {
+		decrypted := filepath.Join(t.TempDir(), "hcdiag.tar.gz")
+		require.NoError(t, Decrypt(encrypted, decrypted, []age.Identity{identity}))
+		if runtime.GOOS != "windows" {
+			info, err := os.Stat(decrypted)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+		}
+
+		f, err := os.Open(decrypted)
+		require.NoError(t, err)
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		tr := tar.NewReader(gz)
+		header, err := tr.Next()
+		require.NoError(t, err)
+		assert.Equal(t, "hcdiag/results.json", header.Name)
+		contents, err := io.ReadAll(tr)
+		require.NoError(t, err)
+		assert.Equal(t, `{"secret": "raft config"}`, string(contents))
+	})
+
+	t.Run("wrong identity", func(t *testing.T) {
+		decrypted := filepath.Join(t.TempDir(), "hcdiag.tar.gz")
+		assert.Error(t, Decrypt(encrypted, decrypted, []age.Identity{other}))
+		assert.NoFileExists(t, decrypted)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		truncated := filepath.Join(t.TempDir(), "truncated.tar.gz"+EncryptedExt)
+		require.NoError(t, os.WriteFile(truncated, bts[:len(bts)-10], 0600))
+		decrypted := filepath.Join(t.TempDir(), "hcdiag.tar.gz")
+		assert.Error(t, Decrypt(truncated, decrypted, []age.Identity{identity}))
+		assert.NoFileExists(t, decrypted)
+	})
+
+	t.Run("destination exists", func(t *testing.T) {
+		assert.Error(t, Decrypt(encrypted, encrypted, []age.Identity{identity}))
+	})
+}
+
+func TestParseRecipients(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	key := identity.Recipient().String()
+
+	recipients, err := ParseRecipients(key)
+	require.NoError(t, err)
+	assert.Len(t, recipients, 1)
+
+	file := filepath.Join(t.TempDir(), "recipients.txt")
+	other, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(file, []byte("# support\n"+key+"\n"+other.Recipient().String()+"\n"), 0600))
+	recipients, err = ParseRecipients(file)
+	require.NoError(t, err)
+	assert.Len(t, recipients, 2)
+
+	_, err = ParseRecipients("age1notakey")
+	assert.Error(t, err)
+	_, err = ParseRecipients(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
+
+func TestParseIdentities(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	buf.WriteString("# created: 2022-01-01T00:00:00Z\n")
+	buf.WriteString("# public key: " + identity.Recipient().String() + "\n")
+	buf.WriteString(identity.String() + "\n")
+	file := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(file, buf.Bytes(), 0600))
+
+	identities, err := ParseIdentities(file)
+	require.NoError(t, err)
+	assert.Len(t, identities, 1)
+
+	_, err = ParseIdentities(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,447 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package util
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/klauspost/compress/zstd"
+)
+
+// ArchiveFormat is a format that a bundle can be written in.
+type ArchiveFormat string
+
+const (
+	// FormatTarGz is a tar archive compressed with gzip. It's the default, and what earlier versions of hcdiag wrote.
+	FormatTarGz ArchiveFormat = "tar.gz"
+	// FormatTarZst is a tar archive compressed with zstd, which is much faster than gzip at a similar ratio.
+	FormatTarZst ArchiveFormat = "tar.zst"
+	// FormatZip is a zip archive, with each file compressed with deflate.
+	FormatZip ArchiveFormat = "zip"
+	// FormatDir is a plain directory, which is neither archived nor compressed, for inspecting a bundle locally.
+	FormatDir ArchiveFormat = "dir"
+)
+
+// ArchiveFormats lists every ArchiveFormat, with the default first.
+var ArchiveFormats = []ArchiveFormat{FormatTarGz, FormatTarZst, FormatZip, FormatDir}
+
+// DefaultCompressionLevel selects the default compression level of each format.
+const DefaultCompressionLevel = 0
+
+var (
+	// ErrEncrypted is returned when reading an archive that was encrypted with age, which must be decrypted first.
+	ErrEncrypted = errors.New("archive is encrypted with age, and must be decrypted first")
+	// ErrUnknownFormat is returned when reading a file that isn't in any ArchiveFormat.
+	ErrUnknownFormat = errors.New("unrecognized archive format")
+)
+
+// ParseArchiveFormat returns the ArchiveFormat named s, such as "tar.zst".
+func ParseArchiveFormat(s string) (ArchiveFormat, error) {
+	names := make([]string, 0, len(ArchiveFormats))
+	for _, f := range ArchiveFormats {
+		if string(f) == s {
+			return f, nil
+		}
+		names = append(names, string(f))
+	}
+	return "", fmt.Errorf("unknown archive format %q, must be one of: %s", s, strings.Join(names, ", "))
+}
+
+// Ext returns the extension of files written in f, including the leading dot. FormatDir has no extension.
+func (f ArchiveFormat) Ext() string {
+	if f == FormatDir {
+		return ""
+	}
+	return "." + string(f)
+}
+
+// CompressionLevels returns the lowest and highest compression levels that f accepts, besides
+// DefaultCompressionLevel. Both are zero for FormatDir, which isn't compressed. zstd's levels follow the zstd CLI, and
+// are mapped onto the handful of levels that the encoder implements.
+func (f ArchiveFormat) CompressionLevels() (min, max int) {
+	switch f {
+	case FormatTarGz, FormatZip:
+		return flate.BestSpeed, flate.BestCompression
+	case FormatTarZst:
+		return 1, 22
+	default:
+		return 0, 0
+	}
+}
+
+// ValidateCompressionLevel returns an error if f doesn't accept level.
+func (f ArchiveFormat) ValidateCompressionLevel(level int) error {
+	if level == DefaultCompressionLevel {
+		return nil
+	}
+	min, max := f.CompressionLevels()
+	if max == 0 {
+		return fmt.Errorf("archive format %s isn't compressed, so it doesn't accept a compression level", f)
+	}
+	if level < min || max < level {
+		return fmt.Errorf("compression level %d is out of range for archive format %s, must be between %d and %d", level, f, min, max)
+	}
+	return nil
+}
+
+// Archive writes the files in sourceDir to destName in format, at the given compression level. Like TarGz, every file
+// is archived under baseName, which is the name of the directory that's created when the archive is extracted. For
+// FormatDir, destName is the directory itself, which must not already exist, and the files are copied straight into it.
+// destName is removed if it can't be written in full.
+func Archive(sourceDir string, destName string, baseName string, format ArchiveFormat, level int) (err error) {
+	if format == FormatDir {
+		if err := format.ValidateCompressionLevel(level); err != nil {
+			return err
+		}
+		if _, err := os.Stat(destName); err == nil {
+			return fmt.Errorf("destination %s already exists", destName)
+		}
+		if err := os.CopyFS(destName, os.DirFS(sourceDir)); err != nil {
+			_ = os.RemoveAll(destName)
+			return err
+		}
+		return nil
+	}
+
+	destFile, err := os.Create(destName)
+	if err != nil {
+		hclog.L().Error("Archive", "error creating archive", err)
+		return err
+	}
+	defer func() {
+		if closeErr := destFile.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(destName)
+		}
+	}()
+
+	return WriteArchive(destFile, sourceDir, baseName, format, level)
+}
+
+// WriteArchive archives and compresses the files in sourceDir, like Archive, but writes the archive to w, rather than
+// to a file. The archive is streamed to w as it's created. FormatDir can't be written to a stream.
+func WriteArchive(w io.Writer, sourceDir string, baseName string, format ArchiveFormat, level int) error {
+	if err := format.ValidateCompressionLevel(level); err != nil {
+		return err
+	}
+
+	switch format {
+	case FormatTarGz:
+		if level == DefaultCompressionLevel {
+			level = gzip.DefaultCompression
+		}
+		gzWriter, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return err
+		}
+		if err := writeTar(gzWriter, sourceDir, baseName); err != nil {
+			return err
+		}
+		return gzWriter.Close()
+	case FormatTarZst:
+		opts := []zstd.EOption{zstd.WithEncoderConcurrency(1)}
+		if level != DefaultCompressionLevel {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+		zstdWriter, err := zstd.NewWriter(w, opts...)
+		if err != nil {
+			return err
+		}
+		if err := writeTar(zstdWriter, sourceDir, baseName); err != nil {
+			_ = zstdWriter.Close()
+			return err
+		}
+		return zstdWriter.Close()
+	case FormatZip:
+		return writeZip(w, sourceDir, baseName, level)
+	default:
+		return fmt.Errorf("archive format %s can't be written to a stream", format)
+	}
+}
+
+// writeTar writes the files in sourceDir to w as a tar archive, under baseName.
+func writeTar(w io.Writer, sourceDir string, baseName string) error {
+	tarWriter := tar.NewWriter(w)
+	err := walkFiles(sourceDir, baseName, func(name string, info fs.FileInfo, r io.Reader) error {
+		header := &tar.Header{
+			Name:    name,
+			Size:    info.Size(),
+			Mode:    int64(info.Mode()),
+			ModTime: info.ModTime(),
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			hclog.L().Error("Archive", "error writing header for tar", err)
+			return err
+		}
+		if _, err := io.Copy(tarWriter, r); err != nil {
+			hclog.L().Error("Archive", "error copying file to tarball", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Closing the writer flushes the end of the archive, so its error matters.
+	return tarWriter.Close()
+}
+
+// writeZip writes the files in sourceDir to w as a zip archive, under baseName, deflated at level.
+func writeZip(w io.Writer, sourceDir string, baseName string, level int) error {
+	if level == DefaultCompressionLevel {
+		level = flate.DefaultCompression
+	}
+	zipWriter := zip.NewWriter(w)
+	zipWriter.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(out, level)
+	})
+
+	err := walkFiles(sourceDir, baseName, func(name string, info fs.FileInfo, r io.Reader) error {
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = name
+		header.Method = zip.Deflate
+		fw, err := zipWriter.CreateHeader(header)
+		if err != nil {
+			hclog.L().Error("Archive", "error writing header for zip", err)
+			return err
+		}
+		if _, err := io.Copy(fw, r); err != nil {
+			hclog.L().Error("Archive", "error copying file to zip", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return zipWriter.Close()
+}
+
+// walkFiles calls fn with each regular file in sourceDir, named as it should be in an archive: baseName, followed by
+// its path relative to sourceDir, with forward slashes.
+func walkFiles(sourceDir string, baseName string, fn func(name string, info fs.FileInfo, r io.Reader) error) error {
+	return filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		sourceFile, err := os.Open(path)
+		if err != nil {
+			hclog.L().Error("Archive", "error opening source file", err)
+			return err
+		}
+		defer func(sourceFile *os.File) {
+			if closeErr := sourceFile.Close(); closeErr != nil {
+				hclog.L().Warn("error closing source file", "component", "Archive", "error", closeErr)
+			}
+		}(sourceFile)
+
+		stat, err := sourceFile.Stat()
+		if err != nil {
+			hclog.L().Error("Archive", "error checking source file", err)
+			return err
+		}
+		name := filepath.ToSlash(getTarRelativePathName(baseName, path, sourceDir))
+		return fn(name, stat, sourceFile)
+	})
+}
+
+// Magic numbers at the start of each kind of file that DetectArchiveFormat recognizes.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+	zipMagic  = []byte("PK\x03\x04")
+	// zipEmptyMagic starts a zip archive with no files in it.
+	zipEmptyMagic = []byte("PK\x05\x06")
+	ageMagic      = []byte("age-encryption.org/")
+)
+
+// DetectArchiveFormat returns the format of the archive at path, which is detected from its contents, rather than its
+// name, or FormatDir if path is a directory. path may be the index of an archive that was split into parts, which are
+// checked against the index first. ErrEncrypted is returned if the archive was encrypted with age.
+func DetectArchiveFormat(path string) (ArchiveFormat, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return FormatDir, nil
+	}
+
+	r, size, closer, err := openArchive(path)
+	if err != nil {
+		return "", err
+	}
+	defer closer.Close()
+	return detectFormat(bufio.NewReader(io.NewSectionReader(r, 0, size)))
+}
+
+// openArchive opens the file at path for reading, along with its size. If path is the index of a file that was split
+// into parts, the parts are checked against it, and read as if they were a single file.
+func openArchive(path string) (r io.ReaderAt, size int64, closer io.Closer, err error) {
+	if IsPartsIndex(path) {
+		parts, err := OpenParts(path)
+		if err != nil {
+			return nil, 0, nil, err
+		}
+		return parts, parts.Size(), parts, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, nil, err
+	}
+	return f, info.Size(), f, nil
+}
+
+// detectFormat returns the format of the archive that r reads, from its first few bytes, without consuming them.
+func detectFormat(r *bufio.Reader) (ArchiveFormat, error) {
+	// Peek returns what it can along with an error for short files, which are then simply unrecognized.
+	head, _ := r.Peek(len(ageMagic))
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		return FormatTarGz, nil
+	case bytes.HasPrefix(head, zstdMagic):
+		return FormatTarZst, nil
+	case bytes.HasPrefix(head, zipMagic), bytes.HasPrefix(head, zipEmptyMagic):
+		return FormatZip, nil
+	case bytes.HasPrefix(head, ageMagic):
+		return "", ErrEncrypted
+	default:
+		return "", ErrUnknownFormat
+	}
+}
+
+// ArchiveEntry describes a regular file in an archive, as passed to the function given to WalkArchive.
+type ArchiveEntry struct {
+	// Name is the file's path in the archive, with forward slashes, such as "hcdiag2022-01-01T000000Z/results.json".
+	Name    string
+	Size    int64
+	Mode    fs.FileMode
+	ModTime time.Time
+}
+
+// WalkArchive calls fn with each regular file in the archive at path, in the order they were archived, along with a
+// reader of its contents, which is only valid until fn returns. The archive may be in any ArchiveFormat other than
+// FormatDir, which is detected with DetectArchiveFormat, and may have been split into parts, in which case path is the
+// index. If fn returns an error, the walk stops and returns it.
+func WalkArchive(path string, fn func(entry ArchiveEntry, r io.Reader) error) error {
+	format, err := DetectArchiveFormat(path)
+	if err != nil {
+		return err
+	}
+	if format == FormatDir {
+		return fmt.Errorf("%s is a directory, not an archive", path)
+	}
+
+	r, size, closer, err := openArchive(path)
+	if err != nil {
+		return err
+	}
+	defer closer.Close()
+
+	switch format {
+	case FormatZip:
+		zr, err := zip.NewReader(r, size)
+		if err != nil {
+			return err
+		}
+		return walkZip(zr, fn)
+	case FormatTarZst:
+		zr, err := zstd.NewReader(io.NewSectionReader(r, 0, size), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		return walkTar(zr, fn)
+	default:
+		gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return walkTar(gz, fn)
+	}
+}
+
+// walkTar calls fn with each regular file in the tar archive that r reads.
+func walkTar(r io.Reader, fn func(entry ArchiveEntry, r io.Reader) error) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		entry := ArchiveEntry{
+			Name:    header.Name,
+			Size:    header.Size,
+			Mode:    header.FileInfo().Mode(),
+			ModTime: header.ModTime,
+		}
+		if err := fn(entry, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// walkZip calls fn with each regular file in the zip archive that zr reads.
+func walkZip(zr *zip.Reader, fn func(entry ArchiveEntry, r io.Reader) error) error {
+	for _, f := range zr.File {
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		entry := ArchiveEntry{
+			Name:    f.Name,
+			Size:    int64(f.UncompressedSize64),
+			Mode:    f.Mode(),
+			ModTime: f.Modified,
+		}
+		if err := walkZipFile(f, entry, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkZipFile calls fn with a reader of f, and closes it once fn returns.
+func walkZipFile(f *zip.File, entry ArchiveEntry, fn func(entry ArchiveEntry, r io.Reader) error) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return fn(entry, rc)
+}
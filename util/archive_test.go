@@ -0,0 +1,145 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package util
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeArchiveSource writes a directory of files to archive, including one in a subdirectory.
+func writeArchiveSource(t *testing.T) string {
+	t.Helper()
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "results.json"), []byte(`{"host": {}}`), 0600))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "VaultDebug"), 0700))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "VaultDebug", "metrics.json"), []byte(`[1, 2, 3]`), 0644))
+	return src
+}
+
+func TestArchive(t *testing.T) {
+	testTable := []struct {
+		format ArchiveFormat
+		level  int
+	}{
+		{format: FormatTarGz, level: DefaultCompressionLevel},
+		{format: FormatTarGz, level: 1},
+		{format: FormatTarZst, level: DefaultCompressionLevel},
+		{format: FormatTarZst, level: 19},
+		{format: FormatZip, level: DefaultCompressionLevel},
+		{format: FormatZip, level: 9},
+	}
+
+	src := writeArchiveSource(t)
+	for _, tc := range testTable {
+		t.Run(string(tc.format), func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "hcdiag"+tc.format.Ext())
+			require.NoError(t, Archive(src, dest, "hcdiag", tc.format, tc.level))
+
+			format, err := DetectArchiveFormat(dest)
+			require.NoError(t, err)
+			assert.Equal(t, tc.format, format)
+
+			contents := make(map[string]string)
+			err = WalkArchive(dest, func(entry ArchiveEntry, r io.Reader) error {
+				bts, err := io.ReadAll(r)
+				if err != nil {
+					return err
+				}
+				assert.Equal(t, int64(len(bts)), entry.Size, entry.Name)
+				assert.True(t, entry.Mode.IsRegular(), entry.Name)
+				contents[entry.Name] = string(bts)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{
+				"hcdiag/results.json":            `{"host": {}}`,
+				"hcdiag/VaultDebug/metrics.json": `[1, 2, 3]`,
+			}, contents)
+		})
+	}
+
+	t.Run("dir", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "hcdiag")
+		require.NoError(t, Archive(src, dest, "hcdiag", FormatDir, DefaultCompressionLevel))
+		bts, err := os.ReadFile(filepath.Join(dest, "VaultDebug", "metrics.json"))
+		require.NoError(t, err)
+		assert.Equal(t, `[1, 2, 3]`, string(bts))
+
+		format, err := DetectArchiveFormat(dest)
+		require.NoError(t, err)
+		assert.Equal(t, FormatDir, format)
+		assert.Error(t, WalkArchive(dest, func(ArchiveEntry, io.Reader) error { return nil }))
+		assert.Error(t, Archive(src, dest, "hcdiag", FormatDir, DefaultCompressionLevel), "the destination already exists")
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		dest := filepath.Join(t.TempDir(), "hcdiag.tar.gz")
+		assert.Error(t, Archive(src, dest, "hcdiag", FormatTarGz, 10))
+		assert.NoFileExists(t, dest)
+	})
+}
+
+func TestValidateCompressionLevel(t *testing.T) {
+	testTable := []struct {
+		format  ArchiveFormat
+		level   int
+		wantErr bool
+	}{
+		{format: FormatTarGz, level: DefaultCompressionLevel},
+		{format: FormatTarGz, level: 9},
+		{format: FormatTarGz, level: 10, wantErr: true},
+		{format: FormatTarGz, level: -1, wantErr: true},
+		{format: FormatTarZst, level: 22},
+		{format: FormatTarZst, level: 23, wantErr: true},
+		{format: FormatZip, level: 1},
+		{format: FormatDir, level: DefaultCompressionLevel},
+		{format: FormatDir, level: 1, wantErr: true},
+	}
+
+	for _, tc := range testTable {
+		err := tc.format.ValidateCompressionLevel(tc.level)
+		if tc.wantErr {
+			assert.Error(t, err, "%s level %d", tc.format, tc.level)
+		} else {
+			assert.NoError(t, err, "%s level %d", tc.format, tc.level)
+		}
+	}
+}
+
+func TestParseArchiveFormat(t *testing.T) {
+	for _, f := range ArchiveFormats {
+		parsed, err := ParseArchiveFormat(string(f))
+		require.NoError(t, err)
+		assert.Equal(t, f, parsed)
+	}
+	_, err := ParseArchiveFormat("rar")
+	assert.Error(t, err)
+}
+
+func TestDetectArchiveFormat(t *testing.T) {
+	dir := t.TempDir()
+
+	t.Run("unknown", func(t *testing.T) {
+		path := filepath.Join(dir, "results.json")
+		require.NoError(t, os.WriteFile(path, []byte(`{}`), 0600))
+		_, err := DetectArchiveFormat(path)
+		assert.ErrorIs(t, err, ErrUnknownFormat)
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		identity, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+		path := filepath.Join(dir, "hcdiag.tar.zst"+EncryptedExt)
+		require.NoError(t, ArchiveEncrypted(writeArchiveSource(t), path, "hcdiag", FormatTarZst, DefaultCompressionLevel, []age.Recipient{identity.Recipient()}))
+		_, err = DetectArchiveFormat(path)
+		assert.ErrorIs(t, err, ErrEncrypted)
+	})
+}
@@ -0,0 +1,384 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package util
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"filippo.io/age"
+)
+
+// PartsIndexExt is the extension of the index that describes a bundle that was split into parts. It's appended to the
+// name of the bundle, such as "hcdiag2022-01-01T000000Z.tar.gz.parts.json".
+const PartsIndexExt = ".parts.json"
+
+// PartsIndex describes a file that was split into parts by a PartWriter. The parts are in the same directory as the
+// index, and concatenating them in order reproduces the file.
+type PartsIndex struct {
+	// Name is the name of the file that the parts make up.
+	Name string `json:"name"`
+	// Size is the size of the whole file, in bytes.
+	Size int64 `json:"size"`
+	// SHA256 is the hex-encoded SHA-256 of the whole file.
+	SHA256 string `json:"sha256"`
+	// Parts lists the parts, in order.
+	Parts []Part `json:"parts"`
+}
+
+// Part is one part of a split file.
+type Part struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// ErrPartMismatch is returned when a part doesn't match its size or checksum in the index, such as because it was
+// truncated, or is from another bundle.
+var ErrPartMismatch = errors.New("part doesn't match the index")
+
+// IsPartsIndex returns true if path names the index of a split file.
+func IsPartsIndex(path string) bool {
+	return strings.HasSuffix(path, PartsIndexExt)
+}
+
+// PartWriter is an io.WriteCloser that splits what's written to it into numbered parts of at most a maximum size,
+// named after the file they make up, such as "hcdiag.tar.gz.part001". Each part is written as soon as it's full, so
+// the whole file is never written to disk. Close writes the index, which is the name of the file, followed by
+// PartsIndexExt.
+type PartWriter struct {
+	dest    string
+	maxSize int64
+
+	index   PartsIndex
+	whole   hash.Hash
+	current *os.File
+	partSum hash.Hash
+	written int64
+}
+
+// NewPartWriter returns a PartWriter that splits destFileName into parts of at most maxSize bytes.
+func NewPartWriter(destFileName string, maxSize int64) (*PartWriter, error) {
+	if maxSize <= 0 {
+		return nil, fmt.Errorf("maximum part size must be positive, got %d", maxSize)
+	}
+	if _, err := os.Stat(destFileName + PartsIndexExt); err == nil {
+		return nil, fmt.Errorf("destination %s already exists", destFileName+PartsIndexExt)
+	}
+	return &PartWriter{
+		dest:    destFileName,
+		maxSize: maxSize,
+		index:   PartsIndex{Name: filepath.Base(destFileName), Parts: make([]Part, 0)},
+		whole:   sha256.New(),
+	}, nil
+}
+
+// Write writes p across as many parts as it takes, starting a new part whenever the current one is full.
+func (w *PartWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		if w.current == nil || w.written == w.maxSize {
+			if err := w.nextPart(); err != nil {
+				return n, err
+			}
+		}
+		chunk := p
+		if room := w.maxSize - w.written; int64(len(chunk)) > room {
+			chunk = chunk[:room]
+		}
+		written, err := w.current.Write(chunk)
+		w.partSum.Write(chunk[:written])
+		w.whole.Write(chunk[:written])
+		w.written += int64(written)
+		n += written
+		if err != nil {
+			return n, err
+		}
+		p = p[written:]
+	}
+	return n, nil
+}
+
+// nextPart closes the current part, if there is one, and starts the next.
+func (w *PartWriter) nextPart() error {
+	if err := w.closePart(); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s.part%03d", filepath.Base(w.dest), len(w.index.Parts)+1)
+	f, err := os.OpenFile(filepath.Join(filepath.Dir(w.dest), name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	w.current = f
+	w.partSum = sha256.New()
+	w.written = 0
+	w.index.Parts = append(w.index.Parts, Part{Name: name})
+	return nil
+}
+
+// closePart closes the current part, if there is one, and records its size and checksum in the index.
+func (w *PartWriter) closePart() error {
+	if w.current == nil {
+		return nil
+	}
+	err := w.current.Close()
+	w.current = nil
+	part := &w.index.Parts[len(w.index.Parts)-1]
+	part.Size = w.written
+	part.SHA256 = hex.EncodeToString(w.partSum.Sum(nil))
+	w.index.Size += w.written
+	return err
+}
+
+// Close closes the last part, and writes the index. At least one part is always written, even if it's empty.
+func (w *PartWriter) Close() error {
+	if w.current == nil && len(w.index.Parts) == 0 {
+		if err := w.nextPart(); err != nil {
+			return err
+		}
+	}
+	if err := w.closePart(); err != nil {
+		return err
+	}
+	w.index.SHA256 = hex.EncodeToString(w.whole.Sum(nil))
+	return WriteJSON(w.index, w.dest+PartsIndexExt)
+}
+
+// Remove removes every part that has been written, and the index, such as after a failed write.
+func (w *PartWriter) Remove() {
+	if w.current != nil {
+		_ = w.current.Close()
+		w.current = nil
+	}
+	dir := filepath.Dir(w.dest)
+	for _, part := range w.index.Parts {
+		_ = os.Remove(filepath.Join(dir, part.Name))
+	}
+	_ = os.Remove(w.dest + PartsIndexExt)
+}
+
+// ArchiveSplit archives and compresses the files in sourceDir, like Archive, or ArchiveEncrypted if there are any
+// recipients, but splits the archive into parts of at most maxSize bytes with a PartWriter as it's written, and
+// returns the path to the index. Every part is removed if the archive can't be written in full. FormatDir can't be
+// split.
+func ArchiveSplit(sourceDir string, destFileName string, baseName string, format ArchiveFormat, level int, recipients []age.Recipient, maxSize int64) (string, error) {
+	if format == FormatDir {
+		return "", fmt.Errorf("archive format %s can't be split into parts", format)
+	}
+	w, err := NewPartWriter(destFileName, maxSize)
+	if err != nil {
+		return "", err
+	}
+	if 0 < len(recipients) {
+		err = WriteArchiveEncrypted(w, sourceDir, baseName, format, level, recipients)
+	} else {
+		err = WriteArchive(w, sourceDir, baseName, format, level)
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		w.Remove()
+		return "", err
+	}
+	return destFileName + PartsIndexExt, nil
+}
+
+// ReadPartsIndex reads the index of a split file at path.
+func ReadPartsIndex(path string) (PartsIndex, error) {
+	var index PartsIndex
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return index, err
+	}
+	if err := json.Unmarshal(bts, &index); err != nil {
+		return index, fmt.Errorf("unable to decode %s: %w", path, err)
+	}
+	if len(index.Parts) == 0 {
+		return index, fmt.Errorf("no parts listed in %s", path)
+	}
+	for _, part := range index.Parts {
+		// Parts are always next to the index, so a path in a part's name can only be a mistake, or an attempt to read
+		// something else.
+		if part.Name != filepath.Base(part.Name) || !filepath.IsLocal(part.Name) {
+			return index, fmt.Errorf("unexpected part %q in %s", part.Name, path)
+		}
+	}
+	return index, nil
+}
+
+// Parts reads the file that the parts listed in an index make up, as if it were a single file.
+type Parts struct {
+	files   []*os.File
+	offsets []int64
+	size    int64
+	r       io.Reader
+}
+
+// OpenParts opens the parts listed in the index at path, once each one has been checked against its size and
+// checksum in the index. ErrPartMismatch is returned if any of them doesn't match.
+func OpenParts(indexPath string) (*Parts, error) {
+	index, err := ReadPartsIndex(indexPath)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Parts{}
+	readers := make([]io.Reader, 0, len(index.Parts))
+	dir := filepath.Dir(indexPath)
+	for _, part := range index.Parts {
+		f, err := os.Open(filepath.Join(dir, part.Name))
+		if err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+		p.files = append(p.files, f)
+		if err := checkPart(f, part); err != nil {
+			_ = p.Close()
+			return nil, err
+		}
+		p.offsets = append(p.offsets, p.size)
+		p.size += part.Size
+		readers = append(readers, io.NewSectionReader(f, 0, part.Size))
+	}
+	if p.size != index.Size {
+		_ = p.Close()
+		return nil, fmt.Errorf("parts in %s add up to %d bytes, but the index has %d: %w", indexPath, p.size, index.Size, ErrPartMismatch)
+	}
+	p.r = io.MultiReader(readers...)
+	return p, nil
+}
+
+// checkPart checks that the contents of f match part's size and checksum.
+func checkPart(f *os.File, part Part) error {
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if n != part.Size || hex.EncodeToString(h.Sum(nil)) != part.SHA256 {
+		return fmt.Errorf("%s: %w", part.Name, ErrPartMismatch)
+	}
+	return nil
+}
+
+// Read reads the parts in order, as a single stream.
+func (p *Parts) Read(b []byte) (int, error) {
+	return p.r.Read(b)
+}
+
+// ReadAt reads len(b) bytes at off in the file that the parts make up, across as many parts as it takes.
+func (p *Parts) ReadAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	var n int
+	for i, f := range p.files {
+		start := p.offsets[i]
+		end := p.size
+		if i+1 < len(p.files) {
+			end = p.offsets[i+1]
+		}
+		if off >= end || len(b) == 0 {
+			continue
+		}
+		chunk := b
+		if remaining := end - off; int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+		read, err := f.ReadAt(chunk, off-start)
+		n += read
+		off += int64(read)
+		b = b[read:]
+		if err != nil && err != io.EOF {
+			return n, err
+		}
+	}
+	if len(b) > 0 {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Size returns the size of the file that the parts make up.
+func (p *Parts) Size() int64 {
+	return p.size
+}
+
+// Close closes every part.
+func (p *Parts) Close() error {
+	var errs []error
+	for _, f := range p.files {
+		errs = append(errs, f.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// JoinParts reassembles the file split into the parts listed in the index at indexPath, and writes it to dst, which
+// must not already exist. dst is removed if it can't be written in full.
+func JoinParts(indexPath string, dst string) (err error) {
+	parts, err := OpenParts(indexPath)
+	if err != nil {
+		return err
+	}
+	defer parts.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			_ = os.Remove(dst)
+		}
+	}()
+	_, err = io.Copy(out, parts)
+	return err
+}
+
+// ParseSize parses a size in bytes, such as "1048576", "500MB", or "2GiB". KB, MB, and GB are powers of 1000, and KiB,
+// MiB, and GiB are powers of 1024. Units are case-insensitive.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return !unicode.IsDigit(r) })
+	if i == -1 {
+		i = len(s)
+	}
+	n, err := strconv.ParseInt(s[:i], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	units := map[string]int64{
+		"":    1,
+		"b":   1,
+		"kb":  1000,
+		"mb":  1000 * 1000,
+		"gb":  1000 * 1000 * 1000,
+		"kib": 1 << 10,
+		"mib": 1 << 20,
+		"gib": 1 << 30,
+	}
+	unit, ok := units[strings.ToLower(strings.TrimSpace(s[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q, the unit must be one of B, KB, MB, GB, KiB, MiB, or GiB", s)
+	}
+	if n > (1<<63-1)/unit {
+		return 0, fmt.Errorf("size %q is too large", s)
+	}
+	return n * unit, nil
+}
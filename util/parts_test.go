@@ -0,0 +1,208 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package util
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeParts splits contents into parts of at most maxSize bytes, and returns the path to the index.
+func writeParts(t *testing.T, contents []byte, maxSize int64) string {
+	t.Helper()
+	dest := filepath.Join(t.TempDir(), "hcdiag.tar.gz")
+	w, err := NewPartWriter(dest, maxSize)
+	require.NoError(t, err)
+	// Write in uneven chunks, so that writes straddle the boundaries between parts.
+	for r := bytes.NewReader(contents); r.Len() > 0; {
+		chunk := make([]byte, 7)
+		n, _ := r.Read(chunk)
+		_, err := w.Write(chunk[:n])
+		require.NoError(t, err)
+	}
+	require.NoError(t, w.Close())
+	return dest + PartsIndexExt
+}
+
+func TestPartWriter(t *testing.T) {
+	contents := make([]byte, 100)
+	_, err := rand.Read(contents)
+	require.NoError(t, err)
+	sum := sha256.Sum256(contents)
+
+	testCases := []struct {
+		name    string
+		maxSize int64
+		sizes   []int64
+	}{
+		{name: "uneven", maxSize: 30, sizes: []int64{30, 30, 30, 10}},
+		{name: "even", maxSize: 50, sizes: []int64{50, 50}},
+		{name: "one part", maxSize: 1000, sizes: []int64{100}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			indexPath := writeParts(t, contents, tc.maxSize)
+			index, err := ReadPartsIndex(indexPath)
+			require.NoError(t, err)
+			assert.Equal(t, "hcdiag.tar.gz", index.Name)
+			assert.Equal(t, int64(100), index.Size)
+			assert.Equal(t, hex.EncodeToString(sum[:]), index.SHA256)
+
+			sizes := make([]int64, len(index.Parts))
+			for i, part := range index.Parts {
+				sizes[i] = part.Size
+				info, err := os.Stat(filepath.Join(filepath.Dir(indexPath), part.Name))
+				require.NoError(t, err)
+				assert.Equal(t, part.Size, info.Size())
+			}
+			assert.Equal(t, tc.sizes, sizes)
+			assert.Equal(t, "hcdiag.tar.gz.part001", index.Parts[0].Name)
+
+			parts, err := OpenParts(indexPath)
+			require.NoError(t, err)
+			defer parts.Close()
+			all, err := io.ReadAll(parts)
+			require.NoError(t, err)
+			assert.Equal(t, contents, all)
+
+			// Reads at an offset may span parts.
+			buf := make([]byte, 45)
+			n, err := parts.ReadAt(buf, 25)
+			require.NoError(t, err)
+			assert.Equal(t, 45, n)
+			assert.Equal(t, contents[25:70], buf)
+			n, err = parts.ReadAt(buf, 90)
+			assert.ErrorIs(t, err, io.EOF)
+			assert.Equal(t, contents[90:], buf[:n])
+
+			joined := filepath.Join(t.TempDir(), "joined.tar.gz")
+			require.NoError(t, JoinParts(indexPath, joined))
+			bts, err := os.ReadFile(joined)
+			require.NoError(t, err)
+			assert.Equal(t, contents, bts)
+		})
+	}
+
+	t.Run("modified part", func(t *testing.T) {
+		indexPath := writeParts(t, contents, 30)
+		part := filepath.Join(filepath.Dir(indexPath), "hcdiag.tar.gz.part002")
+		require.NoError(t, os.WriteFile(part, make([]byte, 30), 0644))
+
+		_, err := OpenParts(indexPath)
+		assert.ErrorIs(t, err, ErrPartMismatch)
+		joined := filepath.Join(t.TempDir(), "joined.tar.gz")
+		assert.Error(t, JoinParts(indexPath, joined))
+		assert.NoFileExists(t, joined)
+	})
+
+	t.Run("missing part", func(t *testing.T) {
+		indexPath := writeParts(t, contents, 30)
+		require.NoError(t, os.Remove(filepath.Join(filepath.Dir(indexPath), "hcdiag.tar.gz.part004")))
+		_, err := OpenParts(indexPath)
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("part outside the directory", func(t *testing.T) {
+		indexPath := filepath.Join(t.TempDir(), "hcdiag.tar.gz"+PartsIndexExt)
+		require.NoError(t, os.WriteFile(indexPath, []byte(`{"parts": [{"name": "../secret", "size": 1}]}`), 0644))
+		_, err := OpenParts(indexPath)
+		assert.Error(t, err)
+	})
+}
+
+func TestArchiveSplit(t *testing.T) {
+	src := writeArchiveSource(t)
+	// A large file that doesn't compress, so that the archive is split into several parts.
+	random := make([]byte, 64*1024)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(src, "random.bin"), random, 0600))
+
+	for _, format := range []ArchiveFormat{FormatTarGz, FormatZip} {
+		t.Run(string(format), func(t *testing.T) {
+			dest := filepath.Join(t.TempDir(), "hcdiag"+format.Ext())
+			indexPath, err := ArchiveSplit(src, dest, "hcdiag", format, DefaultCompressionLevel, nil, 16*1024)
+			require.NoError(t, err)
+			assert.Equal(t, dest+PartsIndexExt, indexPath)
+			assert.NoFileExists(t, dest, "the whole archive should never be written")
+
+			index, err := ReadPartsIndex(indexPath)
+			require.NoError(t, err)
+			assert.Len(t, index.Parts, 5)
+
+			detected, err := DetectArchiveFormat(indexPath)
+			require.NoError(t, err)
+			assert.Equal(t, format, detected)
+			names := make([]string, 0)
+			require.NoError(t, WalkArchive(indexPath, func(entry ArchiveEntry, r io.Reader) error {
+				names = append(names, entry.Name)
+				_, err := io.Copy(io.Discard, r)
+				return err
+			}))
+			assert.ElementsMatch(t, []string{"hcdiag/results.json", "hcdiag/VaultDebug/metrics.json", "hcdiag/random.bin"}, names)
+		})
+	}
+
+	t.Run("encrypted", func(t *testing.T) {
+		identity, err := age.GenerateX25519Identity()
+		require.NoError(t, err)
+		dest := filepath.Join(t.TempDir(), "hcdiag.tar.gz"+EncryptedExt)
+		indexPath, err := ArchiveSplit(src, dest, "hcdiag", FormatTarGz, DefaultCompressionLevel, []age.Recipient{identity.Recipient()}, 16*1024)
+		require.NoError(t, err)
+		_, err = DetectArchiveFormat(indexPath)
+		assert.ErrorIs(t, err, ErrEncrypted)
+
+		decrypted := filepath.Join(t.TempDir(), "hcdiag.tar.gz")
+		require.NoError(t, Decrypt(indexPath, decrypted, []age.Identity{identity}))
+		format, err := DetectArchiveFormat(decrypted)
+		require.NoError(t, err)
+		assert.Equal(t, FormatTarGz, format)
+	})
+
+	t.Run("dir", func(t *testing.T) {
+		_, err := ArchiveSplit(src, filepath.Join(t.TempDir(), "hcdiag"), "hcdiag", FormatDir, DefaultCompressionLevel, nil, 1024)
+		assert.Error(t, err)
+	})
+}
+
+func TestParseSize(t *testing.T) {
+	testCases := []struct {
+		size    string
+		expect  int64
+		wantErr bool
+	}{
+		{size: "1048576", expect: 1048576},
+		{size: "10B", expect: 10},
+		{size: "500MB", expect: 500 * 1000 * 1000},
+		{size: "2GiB", expect: 2 << 30},
+		{size: "25 mib", expect: 25 << 20},
+		{size: "1KB", expect: 1000},
+		{size: "", wantErr: true},
+		{size: "MB", wantErr: true},
+		{size: "10TB", wantErr: true},
+		{size: "1.5GB", wantErr: true},
+		{size: "-1MB", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		size, err := ParseSize(tc.size)
+		if tc.wantErr {
+			assert.Error(t, err, tc.size)
+			continue
+		}
+		require.NoError(t, err, tc.size)
+		assert.Equal(t, tc.expect, size, tc.size)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package util
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// ParseSigningKey reads a PEM-encoded ed25519 private key in PKCS #8 form from the file at path, such as one written
+// by `openssl genpkey -algorithm ed25519`.
+func ParseSigningKey(path string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse private key in %s: %w", path, err)
+	}
+	ed, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key in %s is a %T, not an ed25519 key", path, key)
+	}
+	return ed, nil
+}
+
+// ParseVerifyingKey reads a PEM-encoded ed25519 public key in PKIX form from the file at path, such as one written by
+// `openssl pkey -pubout`.
+func ParseVerifyingKey(path string) (ed25519.PublicKey, error) {
+	der, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse public key in %s: %w", path, err)
+	}
+	ed, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key in %s is a %T, not an ed25519 key", path, key)
+	}
+	return ed, nil
+}
+
+// readPEM returns the contents of the first PEM block in the file at path, which must be of blockType.
+func readPEM(path, blockType string) ([]byte, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(bts)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in %s", path)
+	}
+	if block.Type != blockType {
+		return nil, fmt.Errorf("expected a %q PEM block in %s, found %q", blockType, path, block.Type)
+	}
+	return block.Bytes, nil
+}
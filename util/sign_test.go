@@ -0,0 +1,71 @@
+// Copyright IBM Corp. 2021, 2025
+// SPDX-License-Identifier: MPL-2.0
+
+package util
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSigningKeys(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	privPath, pubPath := writeTestSigningKeys(t, pub, priv)
+
+	parsedPriv, err := ParseSigningKey(privPath)
+	require.NoError(t, err)
+	assert.Equal(t, priv, parsedPriv)
+	parsedPub, err := ParseVerifyingKey(pubPath)
+	require.NoError(t, err)
+	assert.Equal(t, pub, parsedPub)
+
+	// Each key is rejected where the other is expected.
+	_, err = ParseSigningKey(pubPath)
+	assert.Error(t, err)
+	_, err = ParseVerifyingKey(privPath)
+	assert.Error(t, err)
+
+	t.Run("not ed25519", func(t *testing.T) {
+		ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(ec)
+		require.NoError(t, err)
+		path := filepath.Join(t.TempDir(), "ec.pem")
+		require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600))
+		_, err = ParseSigningKey(path)
+		assert.ErrorContains(t, err, "not an ed25519 key")
+	})
+
+	t.Run("not PEM", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "key.txt")
+		require.NoError(t, os.WriteFile(path, []byte("not a key"), 0600))
+		_, err := ParseSigningKey(path)
+		assert.ErrorContains(t, err, "no PEM data")
+	})
+}
+
+// writeTestSigningKeys writes pub and priv to PEM files, and returns their paths.
+func writeTestSigningKeys(t *testing.T, pub ed25519.PublicKey, priv ed25519.PrivateKey) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	privPath, pubPath := filepath.Join(dir, "signing.pem"), filepath.Join(dir, "signing.pub.pem")
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0600))
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0600))
+	return privPath, pubPath
+}
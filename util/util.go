@@ -4,11 +4,8 @@
 package util
 
 import (
-	"archive/tar"
-	"compress/gzip"
 	"encoding/json"
 	"fmt"
-	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -20,83 +17,10 @@ import (
 
 // TarGz is a utility function for archiving and compressing files. Its arguments include the source directory that
 // you wish to archive; a destination filename, which is the .tar.gz file you want to create; and a baseName, which
-// is the name of the directory that should be created when the resulting .tar.gz file is later extracted.
+// is the name of the directory that should be created when the resulting .tar.gz file is later extracted. It's the
+// same as Archive with FormatTarGz, at the default compression level.
 func TarGz(sourceDir string, destFileName string, baseName string) error {
-	// tar
-	destFile, err := os.Create(destFileName)
-	if err != nil {
-		hclog.L().Error("TarGz", "error creating tarball", err)
-		return err
-	}
-	defer func(destFile *os.File) {
-		err := destFile.Close()
-		if err != nil {
-			hclog.L().Warn(err.Error())
-		}
-	}(destFile)
-
-	gzWriter := gzip.NewWriter(destFile)
-	defer func(gzWriter *gzip.Writer) {
-		err := gzWriter.Close()
-		if err != nil {
-			hclog.L().Warn(err.Error())
-		}
-	}(gzWriter)
-
-	tarWriter := tar.NewWriter(gzWriter)
-	defer func(tarWriter *tar.Writer) {
-		err := tarWriter.Close()
-		if err != nil {
-			hclog.L().Warn(err.Error())
-		}
-	}(tarWriter)
-
-	err = filepath.Walk(sourceDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			sourceFile, err := os.Open(path)
-			if err != nil {
-				hclog.L().Error("TarGz", "error opening source file", err)
-				return err
-			}
-			defer func(sourceFile *os.File) {
-				if closeErr := sourceFile.Close(); closeErr != nil {
-					hclog.L().Warn("error closing source file", "component", "TarGz", "error", closeErr)
-				}
-			}(sourceFile)
-
-			stat, err := sourceFile.Stat()
-			if err != nil {
-				hclog.L().Error("TarGz", "error checking source file", err)
-				return err
-			}
-
-			header := &tar.Header{
-				Name:    getTarRelativePathName(baseName, path, sourceDir),
-				Size:    stat.Size(),
-				Mode:    int64(stat.Mode()),
-				ModTime: stat.ModTime(),
-			}
-
-			if err := tarWriter.WriteHeader(header); err != nil {
-				hclog.L().Error("TarGz", "error writing header for tar", err)
-				return err
-			}
-
-			if _, err := io.Copy(tarWriter, sourceFile); err != nil {
-				hclog.L().Error("TarGz", "error copying file to tarball", err)
-				return err
-			}
-		}
-		return nil
-	})
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return Archive(sourceDir, destFileName, baseName, FormatTarGz, DefaultCompressionLevel)
 }
 
 // getTarRelativePathName is a helper for building the Name of archived files in a way that allows for clean extraction.
@@ -167,6 +91,24 @@ func SplitFilepath(path string) (dir string, file string) {
 	return dir, file
 }
 
+// IsInside returns true if path is dir, or is anywhere under it. Both are made absolute first, but symlinks aren't
+// followed.
+func IsInside(path, dir string) (bool, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false, err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false, err
+	}
+	rel, err := filepath.Rel(absDir, absPath)
+	if err != nil {
+		return false, err
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)), nil
+}
+
 func IsInRange(target, since, until time.Time) bool {
 	// Default true if no range provided
 	if since.IsZero() {
@@ -171,6 +171,27 @@ func TestIsInRange(t *testing.T) {
 	}
 }
 
+func TestIsInside(t *testing.T) {
+	testTable := []struct {
+		desc      string
+		path, dir string
+		expect    bool
+	}{
+		{desc: "The dir itself is inside", path: "bundle", dir: "bundle", expect: true},
+		{desc: "A file in the dir is inside", path: "bundle/file.json", dir: "bundle", expect: true},
+		{desc: "A relative path that resolves into the dir is inside", path: "other/../bundle/file.json", dir: "bundle", expect: true},
+		{desc: "A sibling is outside", path: "pseudonyms.json", dir: "bundle", expect: false},
+		{desc: "A sibling with the dir as a prefix is outside", path: "bundle-pseudonyms.json", dir: "bundle", expect: false},
+		{desc: "A file named like a parent reference is inside", path: "bundle/..file", dir: "bundle", expect: true},
+	}
+
+	for _, c := range testTable {
+		res, err := IsInside(c.path, c.dir)
+		assert.NoError(t, err, c.desc)
+		assert.Equal(t, c.expect, res, c.desc)
+	}
+}
+
 func Test_getTarRelativePathName(t *testing.T) {
 	type arguments struct {
 		baseName string